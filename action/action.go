@@ -2,8 +2,10 @@ package action
 
 import (
 	"fmt"
+	"net/url"
 	"time"
 
+	"github.com/antchfx/xmlquery"
 	"github.com/peterstace/simplefeatures/geom"
 )
 
@@ -18,8 +20,20 @@ type Action struct {
 	Type         ActionType
 	InputFormat  Format
 	OutputFormat Format
-	// change it to a variadic opts ...
-	Func func(any) (any, error)
+	// Params asked to the user before applying the action, their values are passed in order to Func
+	Params []Param
+	// Args values bound to Params, see WithArgs
+	Args []string
+	Func func(in any, args ...string) (any, error)
+}
+
+// Param describes a value needed by an action
+type Param struct {
+	Name    string
+	Doc     string
+	Default string
+	// Choices lists the accepted values if any, so the value can be picked
+	Choices func() []string
 }
 
 type Format struct {
@@ -36,12 +50,53 @@ var (
 	jsonFormat     = Format{"json", "j"}
 	geoFormat      = Format{"geometry", "g"}
 	textListFormat = Format{"textList", "l"}
+	tableFormat    = Format{"table", "tb"}
+	xmlFormat      = Format{"xml", "x"}
+	numberFormat   = Format{"number", "n"}
+	durationFormat = Format{"duration", "d"}
+	ipFormat       = Format{"ip", "i"}
+	urlFormat      = Format{"url", "u"}
+	colorFormat    = Format{"color", "c"}
+	idFormat       = Format{"id", "id"}
+	imageFormat    = Format{"image", "img"}
+	markdownFormat = Format{"markdown", "md"}
+	logFormat      = Format{"log", "lg"}
+	stackFormat    = Format{"stack", "st"}
+	certFormat     = Format{"certificate", "crt"}
+	jwtFormat      = Format{"jwt", "jwt"}
+	cronFormat     = Format{"cron", "cr"}
+	htmlFormat     = Format{"html", "h"}
+	httpFormat     = Format{"http", "ht"}
+	diffFormat     = Format{"diff", "df"}
 )
 
+// WithArgs returns a copy of the action with args bound to its params,
+// so it can be stored in a Data stack and replayed
+func (a *Action) WithArgs(args ...string) *Action {
+	na := *a
+	na.Args = args
+	return &na
+}
+
+// args returns the bound args, completed with the params defaults
+func (a *Action) args() []string {
+	args := make([]string, len(a.Params))
+	for i, p := range a.Params {
+		args[i] = p.Default
+		if i < len(a.Args) && a.Args[i] != "" {
+			args[i] = a.Args[i]
+		}
+	}
+	return args
+}
+
 func (a *Action) Transform(in *Data) (*Data, error) {
 	var data any
 	var err error
 
+	outputFormat := a.OutputFormat
+	args := a.args()
+
 	switch a.InputFormat {
 	case textFormat:
 		// the input format of the action needs to be applied to all
@@ -54,30 +109,40 @@ func (a *Action) Transform(in *Data) (*Data, error) {
 
 			resp := make([]string, len(l))
 			for i, s := range l {
-				v, err := a.Func([]byte(s))
+				v, err := a.Func([]byte(s), args...)
 				if err != nil {
 					return nil, err
 				}
 				resp[i] = string(v.([]byte))
 			}
 			data = resp
-			a.OutputFormat = textListFormat
+			outputFormat = textListFormat
 		} else {
 			if len(in.RawValue) == 0 {
 				return nil, fmt.Errorf("value is empty")
 			}
-			data, err = a.Func(in.RawValue)
+			data, err = a.Func(in.RawValue, args...)
 			if err != nil {
 				return nil, err
 			}
 		}
 
+	case binFormat:
+		// text is also bytes
+		if in.Format != binFormat && in.Format != textFormat {
+			return nil, fmt.Errorf("input not bytes")
+		}
+		data, err = a.Func(in.RawValue, args...)
+		if err != nil {
+			return nil, err
+		}
+
 	case textListFormat:
 		_, ok := in.Value.([]string)
 		if !ok {
 			return nil, fmt.Errorf("input not a list of string")
 		}
-		data, err = a.Func(in.Value)
+		data, err = a.Func(in.Value, args...)
 		if err != nil {
 			return nil, err
 		}
@@ -86,8 +151,8 @@ func (a *Action) Transform(in *Data) (*Data, error) {
 		if !ok {
 			return nil, fmt.Errorf("input not a geometry")
 		}
-		data, err = a.Func(in.Value)
-		if !ok {
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
 			return nil, err
 		}
 
@@ -96,21 +161,219 @@ func (a *Action) Transform(in *Data) (*Data, error) {
 		if !ok {
 			return nil, fmt.Errorf("input not a time.Time")
 		}
-		data, err = a.Func(in.Value)
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case jsonFormat:
+		if in.Format != jsonFormat {
+			return nil, fmt.Errorf("input not a JSON tree")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case tableFormat:
+		_, ok := in.Value.(*Table)
+		if !ok {
+			return nil, fmt.Errorf("input not a table")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case xmlFormat:
+		_, ok := in.Value.(*xmlquery.Node)
+		if !ok {
+			return nil, fmt.Errorf("input not an XML document")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case durationFormat:
+		_, ok := in.Value.(time.Duration)
+		if !ok {
+			return nil, fmt.Errorf("input not a duration")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case ipFormat:
+		_, ok := in.Value.(*IPNet)
+		if !ok {
+			return nil, fmt.Errorf("input not an IP")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case urlFormat:
+		_, ok := in.Value.(*url.URL)
+		if !ok {
+			return nil, fmt.Errorf("input not an URL")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case colorFormat:
+		_, ok := in.Value.(*Color)
+		if !ok {
+			return nil, fmt.Errorf("input not a color")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case idFormat:
+		_, ok := in.Value.(*ID)
+		if !ok {
+			return nil, fmt.Errorf("input not an identifier")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case imageFormat:
+		_, ok := in.Value.(*Image)
+		if !ok {
+			return nil, fmt.Errorf("input not an image")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case markdownFormat:
+		_, ok := in.Value.(*Markdown)
+		if !ok {
+			return nil, fmt.Errorf("input not markdown")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case logFormat:
+		_, ok := in.Value.(*Logs)
+		if !ok {
+			return nil, fmt.Errorf("input not logs")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case stackFormat:
+		_, ok := in.Value.(*Stacks)
+		if !ok {
+			return nil, fmt.Errorf("input not stacks")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case certFormat:
+		_, ok := in.Value.(*Certificate)
+		if !ok {
+			return nil, fmt.Errorf("input not a certificate")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case jwtFormat:
+		_, ok := in.Value.(*JWT)
 		if !ok {
+			return nil, fmt.Errorf("input not a JWT")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case cronFormat:
+		_, ok := in.Value.(*Cron)
+		if !ok {
+			return nil, fmt.Errorf("input not a cron schedule")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case htmlFormat:
+		_, ok := in.Value.(*HTML)
+		if !ok {
+			return nil, fmt.Errorf("input not an HTML document")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case httpFormat:
+		_, ok := in.Value.(*HTTPMessage)
+		if !ok {
+			return nil, fmt.Errorf("input not an HTTP message")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case diffFormat:
+		_, ok := in.Value.(*Diff)
+		if !ok {
+			return nil, fmt.Errorf("input not a diff")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
+			return nil, err
+		}
+
+	case numberFormat:
+		_, ok := in.Value.(*Number)
+		if !ok {
+			return nil, fmt.Errorf("input not a number")
+		}
+		data, err = a.Func(in.Value, args...)
+		if err != nil {
 			return nil, err
 		}
 	default:
 		return nil, fmt.Errorf("unknown input format")
 	}
 
-	switch a.OutputFormat {
+	switch outputFormat {
 	case textFormat:
 		b, ok := data.([]byte)
 		if !ok {
 			return nil, fmt.Errorf("function does not return []byte")
 		}
+		if GuessFormatIsBinary(b) {
+			return in.StoreBinValue(b, a), err
+		}
 		return in.StoreTextValue(b, a), err
+	case binFormat:
+		b, ok := data.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("function does not return []byte")
+		}
+		return in.StoreBinValue(b, a), err
 	case textListFormat:
 		l, ok := data.([]string)
 		if !ok {
@@ -129,6 +392,116 @@ func (a *Action) Transform(in *Data) (*Data, error) {
 			return nil, fmt.Errorf("function does not return a geom")
 		}
 		return in.StoreGeomValue(g, a), err
+	case jsonFormat:
+		return in.StoreJSONValue(data, a), err
+	case tableFormat:
+		t, ok := data.(*Table)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a table")
+		}
+		return in.StoreTableValue(t, a), err
+	case xmlFormat:
+		doc, ok := data.(*xmlquery.Node)
+		if !ok {
+			return nil, fmt.Errorf("function does not return an XML document")
+		}
+		return in.StoreXMLValue(doc, a), err
+	case numberFormat:
+		n, ok := data.(*Number)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a number")
+		}
+		return in.StoreNumberValue(n, a), err
+	case ipFormat:
+		n, ok := data.(*IPNet)
+		if !ok {
+			return nil, fmt.Errorf("function does not return an IP")
+		}
+		return in.StoreIPValue(n, a), err
+	case urlFormat:
+		u, ok := data.(*url.URL)
+		if !ok {
+			return nil, fmt.Errorf("function does not return an URL")
+		}
+		return in.StoreURLValue(u, a), err
+	case colorFormat:
+		c, ok := data.(*Color)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a color")
+		}
+		return in.StoreColorValue(c, a), err
+	case idFormat:
+		id, ok := data.(*ID)
+		if !ok {
+			return nil, fmt.Errorf("function does not return an identifier")
+		}
+		return in.StoreIDValue(id, a), err
+	case imageFormat:
+		img, ok := data.(*Image)
+		if !ok {
+			return nil, fmt.Errorf("function does not return an image")
+		}
+		return in.StoreImageValue(img, a), err
+	case markdownFormat:
+		md, ok := data.(*Markdown)
+		if !ok {
+			return nil, fmt.Errorf("function does not return markdown")
+		}
+		return in.StoreMarkdownValue(md, a), err
+	case logFormat:
+		l, ok := data.(*Logs)
+		if !ok {
+			return nil, fmt.Errorf("function does not return logs")
+		}
+		return in.StoreLogsValue(l, a), err
+	case stackFormat:
+		st, ok := data.(*Stacks)
+		if !ok {
+			return nil, fmt.Errorf("function does not return stacks")
+		}
+		return in.StoreStacksValue(st, a), err
+	case certFormat:
+		c, ok := data.(*Certificate)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a certificate")
+		}
+		return in.StoreCertificateValue(c, a), err
+	case jwtFormat:
+		j, ok := data.(*JWT)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a JWT")
+		}
+		return in.StoreJWTValue(j, a), err
+	case cronFormat:
+		c, ok := data.(*Cron)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a cron schedule")
+		}
+		return in.StoreCronValue(c, a), err
+	case htmlFormat:
+		h, ok := data.(*HTML)
+		if !ok {
+			return nil, fmt.Errorf("function does not return an HTML document")
+		}
+		return in.StoreHTMLValue(h, a), err
+	case httpFormat:
+		m, ok := data.(*HTTPMessage)
+		if !ok {
+			return nil, fmt.Errorf("function does not return an HTTP message")
+		}
+		return in.StoreHTTPValue(m, a), err
+	case diffFormat:
+		d, ok := data.(*Diff)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a diff")
+		}
+		return in.StoreDiffValue(d, a), err
+	case durationFormat:
+		d, ok := data.(time.Duration)
+		if !ok {
+			return nil, fmt.Errorf("function does not return a duration")
+		}
+		return in.StoreDurationValue(d, a), err
 
 	default:
 		return nil, fmt.Errorf("unknown output format")
@@ -1,11 +1,16 @@
 package action
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/antchfx/xmlquery"
 	"github.com/peterstace/simplefeatures/geom"
 )
 
@@ -28,6 +33,10 @@ func (d *Data) StoreTextValue(v []byte, a *Action) *Data {
 	return &Data{RawValue: v, Format: textFormat, Stack: append(d.Stack, a)}
 }
 
+func (d *Data) StoreBinValue(v []byte, a *Action) *Data {
+	return &Data{RawValue: v, Format: binFormat, Stack: append(d.Stack, a)}
+}
+
 func (d *Data) StoreTextListValue(l []string, a *Action) *Data {
 	return &Data{Value: l, Format: textListFormat, Stack: append(d.Stack, a)}
 }
@@ -40,8 +49,81 @@ func (d *Data) StoreGeomValue(g geom.Geometry, a *Action) *Data {
 	return &Data{Value: g, Stack: append(d.Stack, a), Format: geoFormat}
 }
 
-func (d *Data) StoreJSONValue(t time.Time, a *Action) *Data {
-	return &Data{Value: t, Stack: append(d.Stack, a), Format: jsonFormat}
+func (d *Data) StoreTableValue(t *Table, a *Action) *Data {
+	return &Data{Value: t, Stack: append(d.Stack, a), Format: tableFormat}
+}
+
+func (d *Data) StoreXMLValue(doc *xmlquery.Node, a *Action) *Data {
+	return &Data{Value: doc, Stack: append(d.Stack, a), Format: xmlFormat}
+}
+
+func (d *Data) StoreNumberValue(n *Number, a *Action) *Data {
+	return &Data{Value: n, Stack: append(d.Stack, a), Format: numberFormat}
+}
+
+func (d *Data) StoreDurationValue(v time.Duration, a *Action) *Data {
+	return &Data{Value: v, Stack: append(d.Stack, a), Format: durationFormat}
+}
+
+func (d *Data) StoreIPValue(n *IPNet, a *Action) *Data {
+	return &Data{Value: n, Stack: append(d.Stack, a), Format: ipFormat}
+}
+
+func (d *Data) StoreURLValue(u *url.URL, a *Action) *Data {
+	return &Data{Value: u, Stack: append(d.Stack, a), Format: urlFormat}
+}
+
+func (d *Data) StoreColorValue(c *Color, a *Action) *Data {
+	return &Data{Value: c, Stack: append(d.Stack, a), Format: colorFormat}
+}
+
+func (d *Data) StoreIDValue(id *ID, a *Action) *Data {
+	return &Data{Value: id, Stack: append(d.Stack, a), Format: idFormat}
+}
+
+func (d *Data) StoreImageValue(img *Image, a *Action) *Data {
+	return &Data{Value: img, Stack: append(d.Stack, a), Format: imageFormat}
+}
+
+func (d *Data) StoreMarkdownValue(md *Markdown, a *Action) *Data {
+	return &Data{Value: md, Stack: append(d.Stack, a), Format: markdownFormat}
+}
+
+func (d *Data) StoreLogsValue(l *Logs, a *Action) *Data {
+	return &Data{Value: l, Stack: append(d.Stack, a), Format: logFormat}
+}
+
+func (d *Data) StoreStacksValue(s *Stacks, a *Action) *Data {
+	return &Data{Value: s, Stack: append(d.Stack, a), Format: stackFormat}
+}
+
+func (d *Data) StoreCertificateValue(c *Certificate, a *Action) *Data {
+	return &Data{Value: c, Stack: append(d.Stack, a), Format: certFormat}
+}
+
+func (d *Data) StoreJWTValue(j *JWT, a *Action) *Data {
+	return &Data{Value: j, Stack: append(d.Stack, a), Format: jwtFormat}
+}
+
+func (d *Data) StoreCronValue(c *Cron, a *Action) *Data {
+	return &Data{Value: c, Stack: append(d.Stack, a), Format: cronFormat}
+}
+
+func (d *Data) StoreHTMLValue(h *HTML, a *Action) *Data {
+	return &Data{Value: h, Stack: append(d.Stack, a), Format: htmlFormat}
+}
+
+func (d *Data) StoreHTTPValue(m *HTTPMessage, a *Action) *Data {
+	return &Data{Value: m, Stack: append(d.Stack, a), Format: httpFormat}
+}
+
+func (d *Data) StoreDiffValue(df *Diff, a *Action) *Data {
+	return &Data{Value: df, Stack: append(d.Stack, a), Format: diffFormat}
+}
+
+// StoreJSONValue stores a JSON tree, as decoded by encoding/json with UseNumber
+func (d *Data) StoreJSONValue(v any, a *Action) *Data {
+	return &Data{Value: v, Stack: append(d.Stack, a), Format: jsonFormat}
 }
 
 // Undo removed the last actions if any
@@ -70,10 +152,59 @@ func (d *Data) Undo(in []byte) (*Data, *Action, error) {
 func (d *Data) String() string {
 	switch d.Format {
 	case textFormat:
+		// binary input such as an image from the clipboard
+		if !utf8.Valid(d.RawValue) {
+			return hex.Dump(d.RawValue)
+		}
 		return string(d.RawValue)
+	case binFormat:
+		return hex.Dump(d.RawValue)
 	case timeFormat:
 		t := d.Value.(time.Time)
 		return t.String()
+	case jsonFormat:
+		b, err := json.MarshalIndent(d.Value, "", "  ")
+		if err != nil {
+			return fmt.Sprintf("%v", d.Value)
+		}
+		return string(b)
+	case xmlFormat:
+		return xmlString(d.Value.(*xmlquery.Node))
+	case numberFormat:
+		return d.Value.(*Number).String()
+	case durationFormat:
+		return d.Value.(time.Duration).String()
+	case ipFormat:
+		return d.Value.(*IPNet).String()
+	case urlFormat:
+		return urlString(d.Value.(*url.URL))
+	case colorFormat:
+		return d.Value.(*Color).String()
+	case idFormat:
+		return d.Value.(*ID).String()
+	case imageFormat:
+		return d.Value.(*Image).String()
+	case markdownFormat:
+		return d.Value.(*Markdown).String()
+	case logFormat:
+		return d.Value.(*Logs).String()
+	case stackFormat:
+		return d.Value.(*Stacks).String()
+	case certFormat:
+		return d.Value.(*Certificate).String()
+	case jwtFormat:
+		return d.Value.(*JWT).String()
+	case cronFormat:
+		return d.Value.(*Cron).String()
+	case htmlFormat:
+		return d.Value.(*HTML).String()
+	case httpFormat:
+		return d.Value.(*HTTPMessage).String()
+	case diffFormat:
+		return d.Value.(*Diff).String()
+	case tableFormat:
+		t := d.Value.(*Table)
+		return string(t.CSV(t.Delimiter))
 	default:
 		return fmt.Sprintf("%v", d.Value)
 	}
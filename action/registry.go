@@ -20,8 +20,8 @@ var all = []Action{
 	md5HashAction, sha1HashAction, sha256HashAction, sha512HashAction,
 	toHexStringAction, fromHexStringAction, toBase64StringAction, fromBase64StringAction,
 	parseJSONDateStringAction, epochTimeAction,
-	estTimeAction, etTimeAction, utcTimeAction, isoTimeAction, timeEpochAction,
-	commaTextListAction, jwtTextListAction, textListJoinCommaAction, jsonCompactAction,
+	isoTimeAction, timeEpochAction,
+	commaTextListAction, textListJoinCommaAction, jsonCompactAction,
 	textListFirstAction, textListLastAction,
 }
 
@@ -61,9 +61,12 @@ func (r *ActionRegistry) RegisterAction(a Action) {
 // ActionsForText returns a list of actions, prefix by search, all if search is empty
 // ordered alphabetically
 func (r *ActionRegistry) ActionsForText(search string) (actions []*Action) {
+	seen := make(map[*Action]bool)
 	for k, a := range r.m {
-		if strings.HasPrefix(k, textFormat.Prefix+",") {
+		// bin decoders also apply to text
+		if !seen[a] && (strings.HasPrefix(k, textFormat.Prefix+",") || strings.HasPrefix(k, binFormat.Prefix+",")) {
 			actions = append(actions, a)
+			seen[a] = true
 		}
 
 		sort.Slice(actions, func(i, j int) bool { return actions[i].Names[0] < actions[j].Names[0] })
@@ -72,14 +75,32 @@ func (r *ActionRegistry) ActionsForText(search string) (actions []*Action) {
 }
 
 func (r *ActionRegistry) ActionsForData(data *Data) (actions []*Action) {
+	// actions are registered once per name, aliases must be listed once
+	seen := make(map[*Action]bool)
 	for k, a := range r.m {
+		if seen[a] {
+			continue
+		}
+
 		if strings.HasPrefix(k, data.Format.Prefix+",") {
 			actions = append(actions, a)
+			seen[a] = true
 		}
 
 		// in case we have a textList we also want to apply text filter, that can output text
-		if data.Format == textListFormat && a.InputFormat == textFormat && a.OutputFormat == textFormat {
+		if !seen[a] && data.Format == textListFormat && a.InputFormat == textFormat && a.OutputFormat == textFormat {
+			actions = append(actions, a)
+			seen[a] = true
+		}
+
+		// text and bin are both bytes, text filters apply to bin, bin decoders apply to text
+		if !seen[a] && data.Format == binFormat && a.InputFormat == textFormat && a.OutputFormat == textFormat {
+			actions = append(actions, a)
+			seen[a] = true
+		}
+		if !seen[a] && data.Format == textFormat && a.InputFormat == binFormat {
 			actions = append(actions, a)
+			seen[a] = true
 		}
 
 		sort.Slice(actions, func(i, j int) bool { return actions[i].Names[0] < actions[j].Names[0] })
@@ -26,7 +26,7 @@ var upperAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		caser := cases.Upper(language.Und)
 		upper := caser.String(string(in.([]byte)))
 		return []byte(upper), nil
@@ -39,7 +39,7 @@ var lowerAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		caser := cases.Lower(language.Und)
 		lower := caser.String(string(in.([]byte)))
 		return []byte(lower), nil
@@ -52,7 +52,7 @@ var titleAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		caser := cases.Title(language.Und)
 		titleStr := caser.String(string(in.([]byte)))
 		return []byte(titleStr), nil
@@ -65,7 +65,7 @@ var trimSpaceAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(strings.TrimSpace(string(in.([]byte)))), nil
 	},
 }
@@ -76,7 +76,7 @@ var quoteAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(strconv.Quote(string(in.([]byte)))), nil
 	},
 }
@@ -87,7 +87,7 @@ var unquoteAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		unescape, err := strconv.Unquote(string(in.([]byte)))
 		return []byte(unescape), err
 	},
@@ -99,7 +99,7 @@ var md5HashAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		h := md5.New()
 		io.WriteString(h, string(in.([]byte)))
 		return []byte(hex.EncodeToString(h.Sum(nil))), nil
@@ -112,7 +112,7 @@ var sha1HashAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		h := sha1.New()
 		io.WriteString(h, string(in.([]byte)))
 		return []byte(hex.EncodeToString(h.Sum(nil))), nil
@@ -125,7 +125,7 @@ var sha256HashAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		h := sha256.New()
 		io.WriteString(h, string(in.([]byte)))
 		return []byte(hex.EncodeToString(h.Sum(nil))), nil
@@ -138,7 +138,7 @@ var sha512HashAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		h := sha512.New()
 		io.WriteString(h, string(in.([]byte)))
 		return []byte(hex.EncodeToString(h.Sum(nil))), nil
@@ -151,7 +151,7 @@ var fromBase64StringAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return base64.StdEncoding.DecodeString(string(in.([]byte)))
 	},
 }
@@ -162,7 +162,7 @@ var parseJSONDateStringAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: timeFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return time.Parse("2006-01-02T15:04:05Z0700", string(in.([]byte)))
 	},
 }
@@ -173,7 +173,7 @@ var jsonCompactAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		dst := &bytes.Buffer{}
 		if err := json.Compact(dst, in.([]byte)); err != nil {
 			return nil, err
@@ -188,7 +188,7 @@ var toBase64StringAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(base64.StdEncoding.EncodeToString(in.([]byte))), nil
 	},
 }
@@ -199,7 +199,7 @@ var fromHexStringAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return hex.DecodeString(strings.ReplaceAll(string(in.([]byte)), " ", ""))
 	},
 }
@@ -210,54 +210,18 @@ var toHexStringAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(hex.EncodeToString(in.([]byte))), nil
 	},
 }
 
-var estTimeAction = Action{
-	Doc:          "Change time to EST timezone",
-	Names:        []string{"est"},
-	Type:         TransformAction,
-	InputFormat:  timeFormat,
-	OutputFormat: timeFormat,
-	Func: func(in any) (any, error) {
-		est, _ := time.LoadLocation("EST")
-		return in.(time.Time).In(est), nil
-	},
-}
-
-var etTimeAction = Action{
-	Doc:          "Change time to ET timezone",
-	Names:        []string{"et"},
-	Type:         TransformAction,
-	InputFormat:  timeFormat,
-	OutputFormat: timeFormat,
-	Func: func(in any) (any, error) {
-		est, _ := time.LoadLocation("ET")
-		return in.(time.Time).In(est), nil
-	},
-}
-
-var utcTimeAction = Action{
-	Doc:          "Change time to UTC timezone",
-	Names:        []string{"utc"},
-	Type:         TransformAction,
-	InputFormat:  timeFormat,
-	OutputFormat: timeFormat,
-	Func: func(in any) (any, error) {
-		est, _ := time.LoadLocation("UTC")
-		return in.(time.Time).In(est), nil
-	},
-}
-
 var isoTimeAction = Action{
 	Doc:          "time to ISO RFC3339 text",
 	Names:        []string{"iso"},
 	Type:         TransformAction,
 	InputFormat:  timeFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(in.(time.Time).Format(time.RFC3339)), nil
 	},
 }
@@ -268,7 +232,7 @@ var timeEpochAction = Action{
 	Type:         TransformAction,
 	InputFormat:  timeFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(fmt.Sprintf("%d", in.(time.Time).Unix())), nil
 	},
 }
@@ -279,7 +243,7 @@ var epochTimeAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: timeFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		ts, err := strconv.Atoi(string(in.([]byte)))
 		if err != nil {
 			return nil, err
@@ -295,7 +259,7 @@ var commaTextListAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textFormat,
 	OutputFormat: textListFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		l := strings.Split(string(in.([]byte)), ",")
 		if len(l) <= 1 {
 			return []string{}, errors.New("can't split using ,")
@@ -305,41 +269,13 @@ var commaTextListAction = Action{
 	},
 }
 
-var jwtTextListAction = Action{
-	Doc:          "Parse a JWT and show the 3 JSON parts,",
-	Names:        []string{"jwt"},
-	Type:         TransformAction,
-	InputFormat:  textFormat,
-	OutputFormat: textListFormat,
-	Func: func(in any) (any, error) {
-		l := strings.Split(string(in.([]byte)), ".")
-		if len(l) != 3 {
-			return []string{}, errors.New("not a valid JWT")
-		}
-
-		out := make([]string, 2)
-		for i, t := range l[0:2] {
-			j, err := base64.StdEncoding.DecodeString(t)
-			if err != nil {
-				// The shorter version (67 characters) is probably just missing a padding character (=) to be correct Base64.
-				j, err = base64.RawStdEncoding.DecodeString(t)
-				if err != nil {
-					return nil, fmt.Errorf("can't decode base64 part of the JWT: %w", err)
-				}
-			}
-			out[i] = string(j)
-		}
-		return out, nil
-	},
-}
-
 var textListJoinCommaAction = Action{
 	Doc:          "Join a list separated by ,",
 	Names:        []string{"comma"},
 	Type:         TransformAction,
 	InputFormat:  textListFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		l := in.([]string)
 		return []byte(strings.Join(l, ",")), nil
 	},
@@ -351,7 +287,7 @@ var textListFirstAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textListFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		l := in.([]string)
 		return []byte(l[0]), nil
 	},
@@ -363,7 +299,7 @@ var textListLastAction = Action{
 	Type:         TransformAction,
 	InputFormat:  textListFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		l := in.([]string)
 		return []byte(l[len(l)-1]), nil
 	},
@@ -0,0 +1,650 @@
+package action
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Values JSON can't express are kept as objects with a single $ key, following MongoDB extended JSON:
+//   - binary: {"$binary": {"base64": "...", "subType": "00"}}
+//   - timestamp: {"$date": "2024-01-02T15:04:05Z"}
+//   - CBOR tag: {"$tag": 32, "$value": "https://example.com"}
+//   - MessagePack extension: {"$ext": {"type": 1, "base64": "..."}}
+//   - CBOR simple value: {"$simple": 20}
+//   - map with keys other than strings, as key value pairs: {"$map": [[1, "a"], [true, "b"]]}
+//
+// BSON uses the relaxed extended JSON ($oid, $numberDecimal ...).
+
+// msgpackTimestampExt is the MessagePack extension type of timestamps
+const msgpackTimestampExt = -1
+
+var binaryActions = []Action{
+	msgpackDecodeAction, msgpackEncodeAction, cborDecodeAction, cborEncodeAction,
+	bsonDecodeAction, bsonEncodeAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(binaryActions...)
+}
+
+var msgpackDecodeAction = Action{
+	Doc:          "Decode MessagePack into a JSON tree",
+	Names:        []string{"msgpack", "messagepack"},
+	Type:         ParseAction,
+	InputFormat:  binFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		b := in.([]byte)
+		r := bytes.NewReader(b)
+		d := msgpack.NewDecoder(r)
+		v, err := msgpackValue(d, r)
+		if err != nil {
+			return nil, fmt.Errorf("offset %d: %w", len(b)-r.Len(), err)
+		}
+		if r.Len() > 0 {
+			return nil, fmt.Errorf("offset %d: unexpected data after MessagePack value", len(b)-r.Len())
+		}
+		return v, nil
+	},
+}
+
+var msgpackEncodeAction = Action{
+	Doc:          "Encode a JSON tree to MessagePack",
+	Names:        []string{"tomsgpack", "tomessagepack"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: binFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		if err := encodeMsgpack(enc, in); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	},
+}
+
+var cborDecodeAction = Action{
+	Doc:          "Decode CBOR into a JSON tree",
+	Names:        []string{"cbor"},
+	Type:         ParseAction,
+	InputFormat:  binFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var v any
+		if err := cbor.Unmarshal(in.([]byte), &v); err != nil {
+			return nil, err
+		}
+		return cborJSON(v)
+	},
+}
+
+var cborEncodeAction = Action{
+	Doc:          "Encode a JSON tree to CBOR",
+	Names:        []string{"tocbor"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: binFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		v, err := cborValue(in)
+		if err != nil {
+			return nil, err
+		}
+		em, err := cbor.EncOptions{Sort: cbor.SortBytewiseLexical, Time: cbor.TimeRFC3339Nano, TimeTag: cbor.EncTagRequired}.EncMode()
+		if err != nil {
+			return nil, err
+		}
+		return em.Marshal(v)
+	},
+}
+
+var bsonDecodeAction = Action{
+	Doc:          "Decode BSON documents into a JSON tree using relaxed extended JSON, several documents become an array",
+	Names:        []string{"bson"},
+	Type:         ParseAction,
+	InputFormat:  binFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		b := in.([]byte)
+		var docs []any
+		for off := 0; off < len(b); {
+			if len(b)-off < 4 {
+				return nil, fmt.Errorf("offset %d: truncated document", off)
+			}
+			l := int(binary.LittleEndian.Uint32(b[off:]))
+			if l < 5 || off+l > len(b) {
+				return nil, fmt.Errorf("offset %d: invalid document length %d", off, l)
+			}
+			raw := bson.Raw(b[off : off+l])
+			if err := raw.Validate(); err != nil {
+				return nil, fmt.Errorf("offset %d: %w", off, err)
+			}
+			j, err := bson.MarshalExtJSON(raw, false, false)
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeJSON(j)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, v)
+			off += l
+		}
+
+		if len(docs) == 1 {
+			return docs[0], nil
+		}
+		return docs, nil
+	},
+}
+
+var bsonEncodeAction = Action{
+	Doc:          "Encode a JSON tree using extended JSON to BSON, an array is encoded as a sequence of documents",
+	Names:        []string{"tobson"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: binFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		docs, ok := in.([]any)
+		if !ok {
+			docs = []any{in}
+		}
+
+		var out []byte
+		for i, doc := range docs {
+			if _, ok := doc.(map[string]any); !ok {
+				return nil, fmt.Errorf("document %d: a BSON document must be a JSON object", i)
+			}
+			j, err := json.Marshal(doc)
+			if err != nil {
+				return nil, err
+			}
+			var d bson.D
+			if err := bson.UnmarshalExtJSON(j, false, &d); err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+			b, err := bson.Marshal(d)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b...)
+		}
+		return out, nil
+	},
+}
+
+// msgpackValue decodes the next MessagePack value to a JSON tree value,
+// r is the input of d, declared lengths are checked against its remaining bytes
+func msgpackValue(d *msgpack.Decoder, r *bytes.Reader) (any, error) {
+	c, err := d.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+		n, err := d.DecodeMapLen()
+		if err != nil {
+			return nil, err
+		}
+		// every key and value takes at least a byte
+		if n > r.Len()/2 {
+			return nil, fmt.Errorf("map length %d exceeds the input", n)
+		}
+		var pairs [][2]any
+		for i := 0; i < n; i++ {
+			k, err := msgpackValue(d, r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := msgpackValue(d, r)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, [2]any{k, v})
+		}
+		return mapJSON(pairs), nil
+
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		n, err := d.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		if n > r.Len() {
+			return nil, fmt.Errorf("array length %d exceeds the input", n)
+		}
+		l := []any{}
+		for i := 0; i < n; i++ {
+			v, err := msgpackValue(d, r)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		return l, nil
+
+	case msgpcode.IsBin(c):
+		b, err := d.DecodeBytes()
+		if err != nil {
+			return nil, err
+		}
+		return binaryJSON(b), nil
+
+	case msgpcode.IsExt(c):
+		id, l, err := d.DecodeExtHeader()
+		if err != nil {
+			return nil, err
+		}
+		if l > r.Len() {
+			return nil, fmt.Errorf("extension length %d exceeds the input", l)
+		}
+		b := make([]byte, l)
+		if err := d.ReadFull(b); err != nil {
+			return nil, err
+		}
+		if id == msgpackTimestampExt {
+			t, err := msgpackTimestamp(b)
+			if err != nil {
+				return nil, err
+			}
+			return dateJSON(t), nil
+		}
+		return map[string]any{"$ext": map[string]any{
+			"type":   jsonInt(int64(id)),
+			"base64": base64.StdEncoding.EncodeToString(b),
+		}}, nil
+	}
+
+	v, err := d.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case float32:
+		return jsonFloat(float64(v), 32), nil
+	case float64:
+		return jsonFloat(v, 64), nil
+	case nil, bool, string:
+		return v, nil
+	default:
+		// all the integer types
+		return json.Number(fmt.Sprint(v)), nil
+	}
+}
+
+// msgpackTimestamp decodes the 3 timestamp extension layouts
+func msgpackTimestamp(b []byte) (time.Time, error) {
+	switch len(b) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&0x3ffffffff), int64(v>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(b)
+		sec := int64(binary.BigEndian.Uint64(b[4:]))
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp length %d", len(b))
+}
+
+// encodeMsgpack encodes a JSON tree value, $ objects are encoded to their MessagePack types
+func encodeMsgpack(enc *msgpack.Encoder, v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		if b, ok, err := jsonBinary(v); ok {
+			if err != nil {
+				return err
+			}
+			return enc.EncodeBytes(b)
+		}
+		if t, ok, err := jsonDate(v); ok {
+			if err != nil {
+				return err
+			}
+			return enc.EncodeTime(t)
+		}
+		if ext, ok := v["$ext"].(map[string]any); ok && len(v) == 1 {
+			id, err := strconv.ParseInt(jsonCell(ext["type"]), 10, 8)
+			if err != nil {
+				return fmt.Errorf("invalid $ext type: %w", err)
+			}
+			b, err := base64.StdEncoding.DecodeString(jsonCell(ext["base64"]))
+			if err != nil {
+				return fmt.Errorf("invalid $ext base64: %w", err)
+			}
+			if err := enc.EncodeExtHeader(int8(id), len(b)); err != nil {
+				return err
+			}
+			_, err = enc.Writer().Write(b)
+			return err
+		}
+		if pairs, ok, err := jsonMap(v); ok {
+			if err != nil {
+				return err
+			}
+			if err := enc.EncodeMapLen(len(pairs)); err != nil {
+				return err
+			}
+			for _, p := range pairs {
+				if err := encodeMsgpack(enc, p[0]); err != nil {
+					return err
+				}
+				if err := encodeMsgpack(enc, p[1]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if err := enc.EncodeMapLen(len(v)); err != nil {
+			return err
+		}
+		for _, k := range sortedKeys(v) {
+			if err := enc.EncodeString(k); err != nil {
+				return err
+			}
+			if err := encodeMsgpack(enc, v[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		if err := enc.EncodeArrayLen(len(v)); err != nil {
+			return err
+		}
+		for _, e := range v {
+			if err := encodeMsgpack(enc, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		n, err := jsonNumberValue(v)
+		if err != nil {
+			return err
+		}
+		switch n := n.(type) {
+		case int64:
+			return enc.EncodeInt(n)
+		case uint64:
+			return enc.EncodeUint(n)
+		case float64:
+			return enc.EncodeFloat64(n)
+		}
+		return fmt.Errorf("number %s too large", v)
+	default:
+		return enc.Encode(v)
+	}
+}
+
+// cborJSON converts a decoded CBOR value to a JSON tree value
+func cborJSON(v any) (any, error) {
+	switch v := v.(type) {
+	case map[any]any:
+		pairs := make([][2]any, 0, len(v))
+		for k, e := range v {
+			jk, err := cborJSON(k)
+			if err != nil {
+				return nil, err
+			}
+			jv, err := cborJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, [2]any{jk, jv})
+		}
+		// the decoded map has no order
+		sort.Slice(pairs, func(i, j int) bool { return jsonCell(pairs[i][0]) < jsonCell(pairs[j][0]) })
+		return mapJSON(pairs), nil
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			jv, err := cborJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = jv
+		}
+		return l, nil
+	case []byte:
+		return binaryJSON(v), nil
+	case cbor.ByteString:
+		// byte strings used as map keys
+		return binaryJSON([]byte(v)), nil
+	case time.Time:
+		return dateJSON(v), nil
+	case big.Int:
+		return json.Number(v.String()), nil
+	case cbor.Tag:
+		content, err := cborJSON(v.Content)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"$tag": jsonUint(v.Number), "$value": content}, nil
+	case cbor.SimpleValue:
+		return map[string]any{"$simple": jsonUint(uint64(v))}, nil
+	case float32:
+		return jsonFloat(float64(v), 32), nil
+	case float64:
+		return jsonFloat(v, 64), nil
+	case uint64:
+		return jsonUint(v), nil
+	case int64:
+		return jsonInt(v), nil
+	case nil, bool, string:
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported CBOR value %T", v)
+}
+
+// cborValue converts a JSON tree value to the Go value to encode, $ objects are converted to their CBOR types
+func cborValue(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		if b, ok, err := jsonBinary(v); ok {
+			return b, err
+		}
+		if t, ok, err := jsonDate(v); ok {
+			return t, err
+		}
+		if n, ok := v["$tag"]; ok && len(v) == 2 {
+			num, err := strconv.ParseUint(jsonCell(n), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid $tag: %w", err)
+			}
+			content, err := cborValue(v["$value"])
+			if err != nil {
+				return nil, err
+			}
+			return cbor.Tag{Number: num, Content: content}, nil
+		}
+		if n, ok := v["$simple"]; ok && len(v) == 1 {
+			num, err := strconv.ParseUint(jsonCell(n), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid $simple: %w", err)
+			}
+			return cbor.SimpleValue(num), nil
+		}
+		if pairs, ok, err := jsonMap(v); ok {
+			if err != nil {
+				return nil, err
+			}
+			m := make(map[any]any, len(pairs))
+			for _, p := range pairs {
+				k, err := cborValue(p[0])
+				if err != nil {
+					return nil, err
+				}
+				if k, err = cborKey(k); err != nil {
+					return nil, err
+				}
+				if m[k], err = cborValue(p[1]); err != nil {
+					return nil, err
+				}
+			}
+			return m, nil
+		}
+
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			cv, err := cborValue(e)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = cv
+		}
+		return m, nil
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			cv, err := cborValue(e)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = cv
+		}
+		return l, nil
+	case json.Number:
+		n, err := jsonNumberValue(v)
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			// integers out of 64 bits are encoded as bignums
+			bi, ok := new(big.Int).SetString(string(v), 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid number %s", v)
+			}
+			return bi, nil
+		}
+		return n, nil
+	}
+	return v, nil
+}
+
+// jsonNumberValue returns n as an int64, an uint64 or a float64,
+// nil for integers out of 64 bits
+func jsonNumberValue(n json.Number) (any, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u, nil
+	}
+	if _, ok := new(big.Int).SetString(string(n), 10); ok {
+		return nil, nil
+	}
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// mapJSON returns the pairs as an object, or as a $map when a key is not a string
+func mapJSON(pairs [][2]any) map[string]any {
+	m := make(map[string]any, len(pairs))
+	for _, p := range pairs {
+		k, ok := p[0].(string)
+		if !ok {
+			l := make([]any, len(pairs))
+			for i, p := range pairs {
+				l[i] = []any{p[0], p[1]}
+			}
+			return map[string]any{"$map": l}
+		}
+		m[k] = p[1]
+	}
+	return m
+}
+
+// jsonMap returns the key value pairs of a $map
+func jsonMap(v map[string]any) (pairs [][2]any, ok bool, err error) {
+	m, ok := v["$map"]
+	if !ok || len(v) != 1 {
+		return nil, false, nil
+	}
+	l, isList := m.([]any)
+	if !isList {
+		return nil, true, fmt.Errorf("invalid $map: not a list of pairs")
+	}
+	for _, e := range l {
+		p, isPair := e.([]any)
+		if !isPair || len(p) != 2 {
+			return nil, true, fmt.Errorf("invalid $map: not a list of pairs")
+		}
+		pairs = append(pairs, [2]any{p[0], p[1]})
+	}
+	return pairs, true, nil
+}
+
+// cborKey returns k as a Go map key, byte strings are keys as ByteString, maps and arrays can't be keys
+func cborKey(k any) (any, error) {
+	switch k := k.(type) {
+	case []byte:
+		return cbor.ByteString(k), nil
+	case map[string]any, map[any]any, []any:
+		return nil, fmt.Errorf("unsupported $map key %s", jsonCell(k))
+	case cbor.Tag:
+		content, err := cborKey(k.Content)
+		return cbor.Tag{Number: k.Number, Content: content}, err
+	}
+	return k, nil
+}
+
+func binaryJSON(b []byte) map[string]any {
+	return map[string]any{"$binary": map[string]any{
+		"base64":  base64.StdEncoding.EncodeToString(b),
+		"subType": "00",
+	}}
+}
+
+func dateJSON(t time.Time) map[string]any {
+	return map[string]any{"$date": t.Format(time.RFC3339Nano)}
+}
+
+// jsonBinary returns the bytes of a $binary object, ok is false if v is not a $binary
+func jsonBinary(v map[string]any) (b []byte, ok bool, err error) {
+	bin, ok := v["$binary"].(map[string]any)
+	if !ok || len(v) != 1 {
+		return nil, false, nil
+	}
+	b, err = base64.StdEncoding.DecodeString(jsonCell(bin["base64"]))
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid $binary base64: %w", err)
+	}
+	return b, true, nil
+}
+
+// jsonDate returns the time of a $date object, ok is false if v is not a $date
+func jsonDate(v map[string]any) (t time.Time, ok bool, err error) {
+	d, ok := v["$date"].(string)
+	if !ok || len(v) != 1 {
+		return time.Time{}, false, nil
+	}
+	t, err = time.Parse(time.RFC3339Nano, d)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("invalid $date: %w", err)
+	}
+	return t, true, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+package action
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_BinJSONDecodeTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(binaryActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{
+			"msgpack map",
+			"msgpack",
+			"82 a161 01 a162 93 c3 c0 a178",
+			`{"a":1,"b":[true,null,"x"]}`,
+			false,
+		},
+		{"msgpack numbers", "msgpack", "92 d09c cb3ff8000000000000", `[-100,1.5]`, false},
+		{"msgpack binary", "msgpack", "c402fffe", `{"$binary":{"base64":"//4=","subType":"00"}}`, false},
+		{"msgpack timestamp", "msgpack", "d6ff65940000", `{"$date":"2024-01-02T12:22:24Z"}`, false},
+		{"msgpack extension", "msgpack", "d4012a", `{"$ext":{"base64":"Kg==","type":1}}`, false},
+		{"msgpack non string keys", "msgpack", "82 01 a161 c3 a162", `{"$map":[[1,"a"],[true,"b"]]}`, false},
+		{"msgpack truncated", "msgpack", "82a161", "", true},
+		{"msgpack trailing data", "msgpack", "0101", "", true},
+		{"msgpack empty", "msgpack", "92 80 90", `[{},[]]`, false},
+		{"msgpack huge map", "msgpack", "df ffffffff", "", true},
+		{"msgpack huge array", "msgpack", "dd ffffffff", "", true},
+		{"msgpack huge extension", "msgpack", "c9 ffffffff 01", "", true},
+		{"cbor huge map", "cbor", "bb ffffffffffffffff", "", true},
+		{
+			"cbor map",
+			"cbor",
+			"a2 6161 01 6162 83 f5 f6 6178",
+			`{"a":1,"b":[true,null,"x"]}`,
+			false,
+		},
+		{"cbor epoch time", "cbor", "c11a65940000", `{"$date":"2024-01-02T12:22:24Z"}`, false},
+		{"cbor tag", "cbor", "d8206178", `{"$tag":32,"$value":"x"}`, false},
+		{"cbor bytes", "cbor", "42fffe", `{"$binary":{"base64":"//4=","subType":"00"}}`, false},
+		{"cbor simple value", "cbor", "f0", `{"$simple":16}`, false},
+		{"cbor bignum", "cbor", "c249010000000000000000", `18446744073709551616`, false},
+		{"cbor non string keys", "cbor", "a2 f5 6162 01 6161", `{"$map":[[1,"a"],[true,"b"]]}`, false},
+		{"cbor invalid", "cbor", "ff", "", true},
+		{"bson", "bson", "0c000000 10 6100 01000000 00", `{"a":1}`, false},
+		{
+			"bson object id",
+			"bson",
+			"16000000 07 5f696400 65940000aabbccddeeff0011 00",
+			`{"_id":{"$oid":"65940000aabbccddeeff0011"}}`,
+			false,
+		},
+		{
+			"bson documents",
+			"bson",
+			"0c000000 10 6100 01000000 00 0c000000 10 6200 02000000 00",
+			`[{"a":1},{"b":2}]`,
+			false,
+		},
+		{"bson invalid length", "bson", "ff000000106100010000000000", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := hex.DecodeString(stripSpaces(tt.in))
+			require.NoError(t, err)
+
+			got, err := r.BinJSONAction(tt.action, in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			b, err := json.Marshal(got)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(b))
+		})
+	}
+}
+
+func TestAction_JSONBinRoundTrip(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(binaryActions...)
+
+	tests := []struct {
+		name    string
+		encode  string
+		decode  string
+		in      string
+		wantErr bool
+	}{
+		{"msgpack", "tomsgpack", "msgpack", `{"a":[1,-2,1.5,"x",true,null],"b":{"c":18446744073709551615}}`, false},
+		{"msgpack markers", "tomsgpack", "msgpack", `[{"$binary":{"base64":"//4=","subType":"00"}},{"$date":"2024-01-02T12:22:24.5Z"},{"$ext":{"base64":"Kg==","type":1}}]`, false},
+		{"msgpack invalid date", "tomsgpack", "msgpack", `{"$date":"yesterday"}`, true},
+		{"msgpack non string keys", "tomsgpack", "msgpack", `{"$map":[[1,"a"],[{"$binary":{"base64":"//4=","subType":"00"}},{"$map":[[[1],"b"]]}]]}`, false},
+		{"msgpack invalid map", "tomsgpack", "msgpack", `{"$map":[[1]]}`, true},
+		{"cbor", "tocbor", "cbor", `{"a":[1,-2,1.5,"x",true,null],"b":{"c":18446744073709551616}}`, false},
+		{"cbor markers", "tocbor", "cbor", `[{"$binary":{"base64":"//4=","subType":"00"}},{"$date":"2024-01-02T12:22:24.5Z"},{"$tag":32,"$value":"x"},{"$simple":16}]`, false},
+		{"cbor invalid binary", "tocbor", "cbor", `{"$binary":{"base64":"!","subType":"00"}}`, true},
+		{"cbor non string keys", "tocbor", "cbor", `{"$map":[[-1,"a"],[{"$binary":{"base64":"//4=","subType":"00"}},"b"]]}`, false},
+		{"cbor array key", "tocbor", "cbor", `{"$map":[[[1],"a"]]}`, true},
+		{"bson", "tobson", "bson", `{"_id":{"$oid":"65940000aabbccddeeff0011"},"a":1,"b":[true,"x"]}`, false},
+		{"bson documents", "tobson", "bson", `[{"a":1},{"b":2}]`, false},
+		{"bson not a document", "tobson", "bson", `[1]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := decodeJSON([]byte(tt.in))
+			require.NoError(t, err)
+
+			b, err := r.JSONTextAction(tt.encode, in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := r.BinJSONAction(tt.decode, b)
+			require.NoError(t, err)
+			require.Equal(t, in, got)
+		})
+	}
+}
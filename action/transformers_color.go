@@ -0,0 +1,597 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Color is a sRGB color, A is the alpha from 0 (transparent) to 1 (opaque)
+type Color struct {
+	R, G, B uint8
+	A       float64
+}
+
+var colorActions = []Action{
+	parseColorAction, colorHexAction, colorRGBAction, colorHSLAction, colorANSIAction, colorNameAction,
+	colorLightenAction, colorDarkenAction, colorContrastAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(colorActions...)
+}
+
+var parseColorAction = Action{
+	Doc:          "Parse a color: #hex, rgb(), hsl(), a CSS name or an ANSI 256 index",
+	Names:        []string{"color", "colour"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: colorFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseColor(string(in.([]byte)))
+	},
+}
+
+var colorHexAction = Action{
+	Doc:          "Transforms the color to hex #rrggbb, #rrggbbaa if transparent",
+	Names:        []string{"hex", "tohex"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(in.(*Color).Hex()), nil
+	},
+}
+
+var colorRGBAction = Action{
+	Doc:          "Transforms the color to rgb()",
+	Names:        []string{"rgb", "torgb"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		c := in.(*Color)
+		if c.A < 1 {
+			return []byte(fmt.Sprintf("rgba(%d, %d, %d, %s)", c.R, c.G, c.B, formatColorFloat(c.A, 3))), nil
+		}
+		return []byte(fmt.Sprintf("rgb(%d, %d, %d)", c.R, c.G, c.B)), nil
+	},
+}
+
+var colorHSLAction = Action{
+	Doc:          "Transforms the color to hsl()",
+	Names:        []string{"hsl", "tohsl"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		c := in.(*Color)
+		h, s, l := c.hsl()
+		hsl := fmt.Sprintf("%s, %s%%, %s%%", formatColorFloat(h, 1), formatColorFloat(s*100, 1), formatColorFloat(l*100, 1))
+		if c.A < 1 {
+			return []byte("hsla(" + hsl + ", " + formatColorFloat(c.A, 3) + ")"), nil
+		}
+		return []byte("hsl(" + hsl + ")"), nil
+	},
+}
+
+var colorANSIAction = Action{
+	Doc:          "Transforms the color to the nearest ANSI 256 index",
+	Names:        []string{"ansi", "toansi"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: numberFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		c := in.(*Color)
+		best, bestDist := 0, math.Inf(1)
+		// the 16 first colors are often redefined by terminal themes
+		for i := 16; i < 256; i++ {
+			if d := colorDistance(c, ansiColor(i)); d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		return intNumber(big.NewInt(int64(best))), nil
+	},
+}
+
+var colorNameAction = Action{
+	Doc:          "Find the nearest CSS named color",
+	Names:        []string{"name", "nearest"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(nearestColorName(in.(*Color))), nil
+	},
+}
+
+var colorLightenAction = Action{
+	Doc:          "Lighten the color, increasing the HSL lightness",
+	Names:        []string{"lighten"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: colorFormat,
+	Params:       []Param{{Name: "amount", Doc: "lightness percentage to add", Default: "10"}},
+	Func: func(in any, args ...string) (any, error) {
+		return adjustLightness(in.(*Color), args[0], 1)
+	},
+}
+
+var colorDarkenAction = Action{
+	Doc:          "Darken the color, decreasing the HSL lightness",
+	Names:        []string{"darken"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: colorFormat,
+	Params:       []Param{{Name: "amount", Doc: "lightness percentage to remove", Default: "10"}},
+	Func: func(in any, args ...string) (any, error) {
+		return adjustLightness(in.(*Color), args[0], -1)
+	},
+}
+
+var colorContrastAction = Action{
+	Doc:          "Compute the WCAG contrast ratio against another color",
+	Names:        []string{"contrast"},
+	Type:         TransformAction,
+	InputFormat:  colorFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{{Name: "color", Doc: "color to compare with", Default: "white"}},
+	Func: func(in any, args ...string) (any, error) {
+		o, err := parseColor(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		l1, l2 := in.(*Color).luminance(), o.luminance()
+		if l1 < l2 {
+			l1, l2 = l2, l1
+		}
+		ratio := (l1 + 0.05) / (l2 + 0.05)
+
+		pass := func(threshold float64) string {
+			if ratio >= threshold {
+				return "pass"
+			}
+			return "fail"
+		}
+		return &Table{
+			Header: []string{"level", "result"},
+			Rows: [][]string{
+				{"ratio", formatColorFloat(ratio, 2) + ":1"},
+				{"AA", pass(4.5)},
+				{"AA large", pass(3)},
+				{"AAA", pass(7)},
+				{"AAA large", pass(4.5)},
+			},
+			Delimiter: ',',
+		}, nil
+	},
+}
+
+// Hex returns the color as #rrggbb, #rrggbbaa if transparent
+func (c *Color) Hex() string {
+	if c.A < 1 {
+		return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, uint8(math.Round(c.A*255)))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (c *Color) String() string {
+	return c.Hex()
+}
+
+// hsl returns the hue in degrees, the saturation and lightness from 0 to 1
+func (c *Color) hsl() (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l
+	}
+
+	d := hi - lo
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// luminance returns the WCAG relative luminance
+func (c *Color) luminance() float64 {
+	return 0.2126*linearRGB(c.R) + 0.7152*linearRGB(c.G) + 0.0722*linearRGB(c.B)
+}
+
+// linearRGB removes the sRGB gamma of a channel
+func linearRGB(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+// lab returns the CIELAB coordinates, D65 white point
+func (c *Color) lab() (l, a, b float64) {
+	r, g, bl := linearRGB(c.R), linearRGB(c.G), linearRGB(c.B)
+	x := (0.4124*r + 0.3576*g + 0.1805*bl) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*bl
+	z := (0.0193*r + 0.1192*g + 0.9505*bl) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// colorDistance is the CIE76 distance between two colors
+func colorDistance(c1, c2 *Color) float64 {
+	l1, a1, b1 := c1.lab()
+	l2, a2, b2 := c2.lab()
+	return math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+}
+
+// hslColor returns the color for a hue in degrees, a saturation and lightness from 0 to 1
+func hslColor(h, s, l, a float64) *Color {
+	h = math.Mod(math.Mod(h, 360)+360, 360)
+	ch := (1 - math.Abs(2*l-1)) * s
+	x := ch * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - ch/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = ch, x
+	case h < 120:
+		r, g = x, ch
+	case h < 180:
+		g, b = ch, x
+	case h < 240:
+		g, b = x, ch
+	case h < 300:
+		r, b = x, ch
+	default:
+		r, b = ch, x
+	}
+	v := func(f float64) uint8 { return uint8(math.Round((f + m) * 255)) }
+	return &Color{R: v(r), G: v(g), B: v(b), A: a}
+}
+
+// ansiColor returns the xterm color of an ANSI 256 index
+func ansiColor(i int) *Color {
+	switch {
+	case i < 16:
+		c := ansiBaseColors[i]
+		return &Color{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 1}
+	case i < 232:
+		levels := []uint8{0, 95, 135, 175, 215, 255}
+		i -= 16
+		return &Color{R: levels[i/36], G: levels[i/6%6], B: levels[i%6], A: 1}
+	default:
+		v := uint8(8 + (i-232)*10)
+		return &Color{R: v, G: v, B: v, A: 1}
+	}
+}
+
+func parseColor(s string) (*Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return nil, errors.New("empty color")
+	}
+
+	if c, ok := cssColors[strings.ReplaceAll(s, " ", "")]; ok {
+		return &Color{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 1}, nil
+	}
+	if s == "transparent" {
+		return &Color{}, nil
+	}
+
+	if fn, rest, ok := strings.Cut(s, "("); ok {
+		if !strings.HasSuffix(rest, ")") {
+			return nil, fmt.Errorf("invalid color %q, missing )", s)
+		}
+		return parseColorFunc(strings.TrimSpace(fn), strings.TrimSuffix(rest, ")"))
+	}
+
+	// 3 digits are an ANSI index rather than a short hex without #
+	if i, err := strconv.Atoi(s); err == nil && i >= 0 && i < 256 {
+		return ansiColor(i), nil
+	}
+
+	return parseHexColor(strings.TrimPrefix(s, "#"))
+}
+
+func parseHexColor(s string) (*Color, error) {
+	if len(s) == 3 || len(s) == 4 {
+		var b strings.Builder
+		for _, r := range s {
+			b.WriteRune(r)
+			b.WriteRune(r)
+		}
+		s = b.String()
+	}
+	if len(s) != 6 && len(s) != 8 {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	c := &Color{A: 1}
+	if len(s) == 8 {
+		c.A = float64(v&0xff) / 255
+		v >>= 8
+	}
+	c.R, c.G, c.B = uint8(v>>16), uint8(v>>8), uint8(v)
+	return c, nil
+}
+
+// parseColorFunc parses the arguments of rgb(), rgba(), hsl() and hsla(),
+// comma or space separated with an optional / alpha
+func parseColorFunc(fn, args string) (*Color, error) {
+	fields := strings.FieldsFunc(args, func(r rune) bool {
+		return r == ',' || r == '/' || r == ' '
+	})
+	if len(fields) != 3 && len(fields) != 4 {
+		return nil, fmt.Errorf("invalid %s(), 3 or 4 values expected", fn)
+	}
+
+	a := 1.0
+	if len(fields) == 4 {
+		v, err := colorValue(fields[3], 1)
+		if err != nil {
+			return nil, err
+		}
+		a = v
+	}
+
+	switch fn {
+	case "rgb", "rgba":
+		var rgb [3]uint8
+		for i, f := range fields[:3] {
+			v, err := colorValue(f, 255)
+			if err != nil {
+				return nil, err
+			}
+			rgb[i] = uint8(math.Round(v))
+		}
+		return &Color{R: rgb[0], G: rgb[1], B: rgb[2], A: a}, nil
+	case "hsl", "hsla":
+		h, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "deg"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hue %q", fields[0])
+		}
+		s, err := colorValue(fields[1], 1)
+		if err != nil {
+			return nil, err
+		}
+		l, err := colorValue(fields[2], 1)
+		if err != nil {
+			return nil, err
+		}
+		return hslColor(h, s, l, a), nil
+	}
+	return nil, fmt.Errorf("unknown color function %s()", fn)
+}
+
+// colorValue parses a number or a percentage of limit, clamped to [0, limit]
+func colorValue(s string, limit float64) (float64, error) {
+	pct := strings.HasSuffix(s, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid color value %q", s)
+	}
+	if pct {
+		v = v / 100 * limit
+	}
+	return math.Max(0, math.Min(limit, v)), nil
+}
+
+func adjustLightness(c *Color, amount string, sign float64) (*Color, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(amount), "%"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	h, s, l := c.hsl()
+	l = math.Max(0, math.Min(1, l+sign*v/100))
+	return hslColor(h, s, l, c.A), nil
+}
+
+// nearestColorName returns the CSS name of the closest color
+func nearestColorName(c *Color) string {
+	names := make([]string, 0, len(cssColors))
+	for name := range cssColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var best string
+	bestDist := math.Inf(1)
+	for _, name := range names {
+		v := cssColors[name]
+		d := colorDistance(c, &Color{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 1})
+		if d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func formatColorFloat(f float64, decimals int) string {
+	p := math.Pow10(decimals)
+	return strconv.FormatFloat(math.Round(f*p)/p, 'f', -1, 64)
+}
+
+// ansiBaseColors are the xterm default 16 colors
+var ansiBaseColors = [16]uint32{
+	0x000000, 0x800000, 0x008000, 0x808000, 0x000080, 0x800080, 0x008080, 0xc0c0c0,
+	0x808080, 0xff0000, 0x00ff00, 0xffff00, 0x0000ff, 0xff00ff, 0x00ffff, 0xffffff,
+}
+
+// cssColors are the CSS Color Module Level 4 named colors
+var cssColors = map[string]uint32{
+	"aliceblue":            0xf0f8ff,
+	"antiquewhite":         0xfaebd7,
+	"aqua":                 0x00ffff,
+	"aquamarine":           0x7fffd4,
+	"azure":                0xf0ffff,
+	"beige":                0xf5f5dc,
+	"bisque":               0xffe4c4,
+	"black":                0x000000,
+	"blanchedalmond":       0xffebcd,
+	"blue":                 0x0000ff,
+	"blueviolet":           0x8a2be2,
+	"brown":                0xa52a2a,
+	"burlywood":            0xdeb887,
+	"cadetblue":            0x5f9ea0,
+	"chartreuse":           0x7fff00,
+	"chocolate":            0xd2691e,
+	"coral":                0xff7f50,
+	"cornflowerblue":       0x6495ed,
+	"cornsilk":             0xfff8dc,
+	"crimson":              0xdc143c,
+	"cyan":                 0x00ffff,
+	"darkblue":             0x00008b,
+	"darkcyan":             0x008b8b,
+	"darkgoldenrod":        0xb8860b,
+	"darkgray":             0xa9a9a9,
+	"darkgreen":            0x006400,
+	"darkgrey":             0xa9a9a9,
+	"darkkhaki":            0xbdb76b,
+	"darkmagenta":          0x8b008b,
+	"darkolivegreen":       0x556b2f,
+	"darkorange":           0xff8c00,
+	"darkorchid":           0x9932cc,
+	"darkred":              0x8b0000,
+	"darksalmon":           0xe9967a,
+	"darkseagreen":         0x8fbc8f,
+	"darkslateblue":        0x483d8b,
+	"darkslategray":        0x2f4f4f,
+	"darkslategrey":        0x2f4f4f,
+	"darkturquoise":        0x00ced1,
+	"darkviolet":           0x9400d3,
+	"deeppink":             0xff1493,
+	"deepskyblue":          0x00bfff,
+	"dimgray":              0x696969,
+	"dimgrey":              0x696969,
+	"dodgerblue":           0x1e90ff,
+	"firebrick":            0xb22222,
+	"floralwhite":          0xfffaf0,
+	"forestgreen":          0x228b22,
+	"fuchsia":              0xff00ff,
+	"gainsboro":            0xdcdcdc,
+	"ghostwhite":           0xf8f8ff,
+	"gold":                 0xffd700,
+	"goldenrod":            0xdaa520,
+	"gray":                 0x808080,
+	"green":                0x008000,
+	"greenyellow":          0xadff2f,
+	"grey":                 0x808080,
+	"honeydew":             0xf0fff0,
+	"hotpink":              0xff69b4,
+	"indianred":            0xcd5c5c,
+	"indigo":               0x4b0082,
+	"ivory":                0xfffff0,
+	"khaki":                0xf0e68c,
+	"lavender":             0xe6e6fa,
+	"lavenderblush":        0xfff0f5,
+	"lawngreen":            0x7cfc00,
+	"lemonchiffon":         0xfffacd,
+	"lightblue":            0xadd8e6,
+	"lightcoral":           0xf08080,
+	"lightcyan":            0xe0ffff,
+	"lightgoldenrodyellow": 0xfafad2,
+	"lightgray":            0xd3d3d3,
+	"lightgreen":           0x90ee90,
+	"lightgrey":            0xd3d3d3,
+	"lightpink":            0xffb6c1,
+	"lightsalmon":          0xffa07a,
+	"lightseagreen":        0x20b2aa,
+	"lightskyblue":         0x87cefa,
+	"lightslategray":       0x778899,
+	"lightslategrey":       0x778899,
+	"lightsteelblue":       0xb0c4de,
+	"lightyellow":          0xffffe0,
+	"lime":                 0x00ff00,
+	"limegreen":            0x32cd32,
+	"linen":                0xfaf0e6,
+	"magenta":              0xff00ff,
+	"maroon":               0x800000,
+	"mediumaquamarine":     0x66cdaa,
+	"mediumblue":           0x0000cd,
+	"mediumorchid":         0xba55d3,
+	"mediumpurple":         0x9370db,
+	"mediumseagreen":       0x3cb371,
+	"mediumslateblue":      0x7b68ee,
+	"mediumspringgreen":    0x00fa9a,
+	"mediumturquoise":      0x48d1cc,
+	"mediumvioletred":      0xc71585,
+	"midnightblue":         0x191970,
+	"mintcream":            0xf5fffa,
+	"mistyrose":            0xffe4e1,
+	"moccasin":             0xffe4b5,
+	"navajowhite":          0xffdead,
+	"navy":                 0x000080,
+	"oldlace":              0xfdf5e6,
+	"olive":                0x808000,
+	"olivedrab":            0x6b8e23,
+	"orange":               0xffa500,
+	"orangered":            0xff4500,
+	"orchid":               0xda70d6,
+	"palegoldenrod":        0xeee8aa,
+	"palegreen":            0x98fb98,
+	"paleturquoise":        0xafeeee,
+	"palevioletred":        0xdb7093,
+	"papayawhip":           0xffefd5,
+	"peachpuff":            0xffdab9,
+	"peru":                 0xcd853f,
+	"pink":                 0xffc0cb,
+	"plum":                 0xdda0dd,
+	"powderblue":           0xb0e0e6,
+	"purple":               0x800080,
+	"rebeccapurple":        0x663399,
+	"red":                  0xff0000,
+	"rosybrown":            0xbc8f8f,
+	"royalblue":            0x4169e1,
+	"saddlebrown":          0x8b4513,
+	"salmon":               0xfa8072,
+	"sandybrown":           0xf4a460,
+	"seagreen":             0x2e8b57,
+	"seashell":             0xfff5ee,
+	"sienna":               0xa0522d,
+	"silver":               0xc0c0c0,
+	"skyblue":              0x87ceeb,
+	"slateblue":            0x6a5acd,
+	"slategray":            0x708090,
+	"slategrey":            0x708090,
+	"snow":                 0xfffafa,
+	"springgreen":          0x00ff7f,
+	"steelblue":            0x4682b4,
+	"tan":                  0xd2b48c,
+	"teal":                 0x008080,
+	"thistle":              0xd8bfd8,
+	"tomato":               0xff6347,
+	"turquoise":            0x40e0d0,
+	"violet":               0xee82ee,
+	"wheat":                0xf5deb3,
+	"white":                0xffffff,
+	"whitesmoke":           0xf5f5f5,
+	"yellow":               0xffff00,
+	"yellowgreen":          0x9acd32,
+}
@@ -0,0 +1,163 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) ColorAction(action string, in *Color, args ...string) (any, error) {
+	a, ok := r.m[colorFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for color input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextColorTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(colorActions...)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"hex", "#FF8800", "#ff8800", false},
+		{"hex no #", "ff8800", "#ff8800", false},
+		{"short hex", "#f80", "#ff8800", false},
+		{"hex alpha", "#ff880080", "#ff880080", false},
+		{"short hex alpha", "#f808", "#ff880088", false},
+		{"rgb", "rgb(255, 136, 0)", "#ff8800", false},
+		{"rgb spaces", "rgb(255 136 0 / 50%)", "#ff880080", false},
+		{"rgba", "rgba(100%, 0%, 0%, 0.5)", "#ff000080", false},
+		{"rgb clamped", "rgb(300, -1, 0)", "#ff0000", false},
+		{"hsl", "hsl(120, 100%, 25%)", "#008000", false},
+		{"hsl deg", "hsla(240deg 100% 50% / 1)", "#0000ff", false},
+		{"name", " RebeccaPurple\n", "#663399", false},
+		{"name with spaces", "dark slate gray", "#2f4f4f", false},
+		{"black", "black", "#000000", false},
+		{"transparent", "transparent", "#00000000", false},
+		{"ansi", "196", "#ff0000", false},
+		{"ansi base", "4", "#000080", false},
+		{"ansi gray", "244", "#808080", false},
+		{"hex digits", "111111", "#111111", false},
+		{"invalid", "not a color", "", true},
+		{"short hex 4 digits", "#ff88", "#ffff8888", false},
+		{"bad hex", "#ggg", "", true},
+		{"missing paren", "rgb(1, 2, 3", "", true},
+		{"wrong count", "rgb(1, 2)", "", true},
+		{"unknown function", "cmyk(1, 2, 3, 4)", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",color"].Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*Color).Hex())
+		})
+	}
+}
+
+func TestAction_ColorTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(colorActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"hex", "hex", "rgb(255, 136, 0)", nil, "#ff8800", false},
+		{"rgb", "rgb", "#ff8800", nil, "rgb(255, 136, 0)", false},
+		{"rgba", "rgb", "#ff880080", nil, "rgba(255, 136, 0, 0.502)", false},
+		{"hsl", "hsl", "#ff8800", nil, "hsl(32, 100%, 50%)", false},
+		{"hsl gray", "hsl", "#808080", nil, "hsl(0, 0%, 50.2%)", false},
+		{"hsla", "hsl", "rgba(0, 0, 255, 0.25)", nil, "hsla(240, 100%, 50%, 0.25)", false},
+		{"ansi", "ansi", "#ff0000", nil, "196", false},
+		{"ansi gray", "ansi", "#7f7f7f", nil, "244", false},
+		{"name", "name", "#ff0001", nil, "red", false},
+		{"name exact", "name", "#663399", nil, "rebeccapurple", false},
+		{"lighten", "lighten", "hsl(0, 100%, 40%)", nil, "#ff0000", false},
+		{"lighten max", "lighten", "#eeeeee", []string{"50%"}, "#ffffff", false},
+		{"darken", "darken", "#ff0000", []string{"20"}, "#990000", false},
+		{"darken keeps alpha", "darken", "#ff000080", nil, "#cc000080", false},
+		{"darken invalid", "darken", "#ff0000", []string{"a lot"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := parseColor(tt.in)
+			require.NoError(t, err)
+
+			got, err := r.ColorAction(tt.action, in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch v := got.(type) {
+			case []byte:
+				require.Equal(t, tt.want, string(v))
+			case *Number:
+				require.Equal(t, tt.want, v.String())
+			case *Color:
+				require.Equal(t, tt.want, v.Hex())
+			}
+		})
+	}
+}
+
+func TestAction_ColorContrastTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(colorActions...)
+
+	tests := []struct {
+		name    string
+		in      string
+		args    []string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			"black on white", "black", nil,
+			[][]string{{"ratio", "21:1"}, {"AA", "pass"}, {"AA large", "pass"}, {"AAA", "pass"}, {"AAA large", "pass"}},
+			false,
+		},
+		{
+			"gray on white", "#777777", nil,
+			[][]string{{"ratio", "4.48:1"}, {"AA", "fail"}, {"AA large", "pass"}, {"AAA", "fail"}, {"AAA large", "fail"}},
+			false,
+		},
+		{
+			"symmetric", "white", []string{"#777"},
+			[][]string{{"ratio", "4.48:1"}, {"AA", "fail"}, {"AA large", "pass"}, {"AAA", "fail"}, {"AAA large", "fail"}},
+			false,
+		},
+		{"invalid", "white", []string{"nope"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := parseColor(tt.in)
+			require.NoError(t, err)
+
+			got, err := r.ColorAction("contrast", in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*Table).Rows)
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Cron is a parsed cron schedule, with the command when parsed from a crontab line
+type Cron struct {
+	Spec     string
+	Command  string
+	Schedule cron.Schedule
+}
+
+// cronParser accepts 5 fields, an optional leading seconds field and @ descriptors
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+var (
+	cronMonths   = []string{"", "January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	cronWeekdays = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+// cronDescriptors are the descriptions of the @ macros, @every is handled apart
+var cronDescriptors = map[string]string{
+	"@yearly":   "at 00:00 on January 1",
+	"@annually": "at 00:00 on January 1",
+	"@monthly":  "at 00:00 on day 1 of the month",
+	"@weekly":   "at 00:00 on Sunday",
+	"@daily":    "at 00:00 every day",
+	"@midnight": "at 00:00 every day",
+	"@hourly":   "at minute 0 of every hour",
+}
+
+var cronActions = []Action{parseCronAction, cronDescribeAction, cronNextAction, cronNextRunAction}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(cronActions...)
+}
+
+var parseCronAction = Action{
+	Doc:          "Parse a cron expression or crontab line: 5 fields, optional seconds, @daily, @every 1h",
+	Names:        []string{"cron", "crontab"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: cronFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseCron(string(in.([]byte)))
+	},
+}
+
+var cronDescribeAction = Action{
+	Doc:          "Describe the schedule in English",
+	Names:        []string{"describe", "human"},
+	Type:         TransformAction,
+	InputFormat:  cronFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		s, err := describeCron(in.(*Cron).Spec)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	},
+}
+
+var cronNextAction = Action{
+	Doc:          "Next fire times from now",
+	Names:        []string{"next"},
+	Type:         TransformAction,
+	InputFormat:  cronFormat,
+	OutputFormat: textListFormat,
+	Params: []Param{
+		{Name: "count", Doc: "number of times", Default: "5"},
+		{Name: "zone", Doc: "IANA timezone, e.g. Europe/Paris, or Local", Default: "Local", Choices: TimeZones},
+	},
+	Func: func(in any, args ...string) (any, error) {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid count %q", args[0])
+		}
+		loc, err := loadLocation(args[1])
+		if err != nil {
+			return nil, err
+		}
+		var l []string
+		for _, t := range in.(*Cron).Next(time.Now().In(loc), n) {
+			l = append(l, t.Format(time.RFC3339))
+		}
+		return l, nil
+	},
+}
+
+var cronNextRunAction = Action{
+	Doc:          "Next fire time from now",
+	Names:        []string{"nextrun"},
+	Type:         TransformAction,
+	InputFormat:  cronFormat,
+	OutputFormat: timeFormat,
+	Params:       []Param{{Name: "zone", Doc: "IANA timezone, e.g. Europe/Paris, or Local", Default: "Local", Choices: TimeZones}},
+	Func: func(in any, args ...string) (any, error) {
+		loc, err := loadLocation(args[0])
+		if err != nil {
+			return nil, err
+		}
+		l := in.(*Cron).Next(time.Now().In(loc), 1)
+		if len(l) == 0 {
+			return nil, errors.New("the schedule never fires")
+		}
+		return l[0], nil
+	},
+}
+
+func (c *Cron) String() string {
+	s := c.Spec
+	if d, err := describeCron(c.Spec); err == nil {
+		s += "\n" + d
+	}
+	if c.Command != "" {
+		s += "\nruns " + c.Command
+	}
+	return s
+}
+
+// Next returns the n next fire times after t, in the location of t unless the spec sets one
+func (c *Cron) Next(t time.Time, n int) []time.Time {
+	var l []time.Time
+	for i := 0; i < n; i++ {
+		t = c.Schedule.Next(t)
+		// a schedule matching no date returns the zero time
+		if t.IsZero() {
+			break
+		}
+		l = append(l, t)
+	}
+	return l
+}
+
+// parseCron parses an expression, a trailing command like in a crontab line is kept apart
+func parseCron(s string) (*Cron, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, errors.New("empty cron expression")
+	}
+
+	// the number of fields of the schedule, the timezone prefix is a field of its own
+	tz := 0
+	if strings.HasPrefix(fields[0], "TZ=") || strings.HasPrefix(fields[0], "CRON_TZ=") {
+		tz = 1
+	}
+	var sizes []int
+	switch {
+	case len(fields) > tz && fields[tz] == "@every":
+		sizes = []int{2}
+	case len(fields) > tz && strings.HasPrefix(fields[tz], "@"):
+		sizes = []int{1}
+	default:
+		sizes = []int{6, 5}
+	}
+
+	if sizes[0] == 2 && len(fields) < tz+2 {
+		return nil, errors.New("invalid cron expression: @every needs a duration")
+	}
+
+	var err error
+	for _, size := range sizes {
+		if len(fields) < tz+size {
+			continue
+		}
+		spec := strings.Join(fields[:tz+size], " ")
+		var sched cron.Schedule
+		sched, err = cronParser.Parse(spec)
+		if err == nil {
+			return &Cron{Spec: spec, Command: strings.Join(fields[tz+size:], " "), Schedule: sched}, nil
+		}
+	}
+	if err == nil {
+		err = errors.New("expected 5 or 6 fields")
+	}
+	return nil, fmt.Errorf("invalid cron expression: %w", err)
+}
+
+// describeCron describes a valid spec in English
+func describeCron(spec string) (string, error) {
+	fields := strings.Fields(spec)
+	zone := ""
+	if strings.Contains(fields[0], "TZ=") {
+		zone = " (" + fields[0][strings.Index(fields[0], "=")+1:] + ")"
+		fields = fields[1:]
+	}
+
+	if fields[0] == "@every" {
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return "", err
+		}
+		return "every " + humanDuration(d, len(humanUnits)), nil
+	}
+	if d, ok := cronDescriptors[fields[0]]; ok {
+		return d + zone, nil
+	}
+	if len(fields) == 5 {
+		fields = append([]string{""}, fields...)
+	}
+
+	sec, minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+	var parts []string
+	atTime := isCronNumber(minute) && isCronNumber(hour) && (sec == "" || isCronNumber(sec))
+	if atTime {
+		h, _ := strconv.Atoi(hour)
+		m, _ := strconv.Atoi(minute)
+		at := fmt.Sprintf("at %02d:%02d", h, m)
+		if s, _ := strconv.Atoi(sec); s != 0 {
+			at += fmt.Sprintf(":%02d", s)
+		}
+		parts = append(parts, at)
+	} else {
+		// the smallest wildcard unit is the frequency, larger wildcards are implied
+		smallest := true
+		for _, f := range []struct{ v, unit, units string }{
+			{sec, "second", "seconds"}, {minute, "minute", "minutes"}, {hour, "hour", "hours"},
+		} {
+			if f.v == "" {
+				continue
+			}
+			if isCronWildcard(f.v) {
+				if smallest {
+					parts = append(parts, "every "+f.unit)
+				}
+				smallest = false
+				continue
+			}
+			smallest = false
+			d, err := describeCronField(f.v, f.unit, f.units, strconv.Itoa, false)
+			if err != nil {
+				return "", err
+			}
+			if !strings.HasPrefix(d, "every") && len(parts) == 0 {
+				d = "at " + d
+			}
+			parts = append(parts, d)
+		}
+	}
+
+	var days []string
+	if !isCronWildcard(dom) {
+		d, err := describeCronField(dom, "day", "days", strconv.Itoa, false)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(d, "every") {
+			d = "on " + d + " of the month"
+		}
+		days = append(days, d)
+	}
+	if !isCronWildcard(dow) {
+		d, err := describeCronField(dow, "day", "days", cronName(cronWeekdays), true)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(d, "every") {
+			d = "on " + d
+		}
+		days = append(days, d)
+	}
+	// both day fields restricted match either of them
+	if len(days) > 0 {
+		parts = append(parts, strings.Join(days, " or "))
+	}
+
+	if !isCronWildcard(month) {
+		d, err := describeCronField(month, "month", "months", cronName(cronMonths), true)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(d, "every") {
+			d = "in " + d
+		}
+		parts = append(parts, d)
+	} else if atTime && len(days) == 0 {
+		parts = append(parts, "every day")
+	}
+
+	// qualifiers read as a sentence, other parts are enumerated
+	s := parts[0]
+	for _, p := range parts[1:] {
+		if strings.HasPrefix(p, "on ") || strings.HasPrefix(p, "in ") || strings.HasPrefix(p, "every ") {
+			s += " " + p
+		} else {
+			s += ", " + p
+		}
+	}
+	return s + zone, nil
+}
+
+// describeCronField describes a field made of values, ranges, steps and lists, named values are not prefixed by the unit
+func describeCronField(f, unit, units string, name func(int) string, named bool) (string, error) {
+	valueUnit, valueUnits := unit, units
+	if named {
+		valueUnit, valueUnits = "", ""
+	}
+
+	var singles, descs []string
+	for _, item := range strings.Split(f, ",") {
+		rng, step, hasStep := strings.Cut(item, "/")
+		from, to, isRange := strings.Cut(rng, "-")
+		value := func(s string) (string, error) {
+			v, err := cronValue(s)
+			if err != nil {
+				return "", err
+			}
+			return name(v), nil
+		}
+
+		var d string
+		switch {
+		case hasStep:
+			every := "every " + step + " " + units
+			if step == "1" {
+				every = "every " + unit
+			}
+			if isCronWildcard(rng) {
+				d = every
+				break
+			}
+			a, err := value(from)
+			if err != nil {
+				return "", err
+			}
+			if !isRange {
+				d = every + " from " + a
+				break
+			}
+			b, err := value(to)
+			if err != nil {
+				return "", err
+			}
+			d = every + " from " + a + " through " + b
+		case isRange:
+			a, err := value(from)
+			if err != nil {
+				return "", err
+			}
+			b, err := value(to)
+			if err != nil {
+				return "", err
+			}
+			d = strings.TrimPrefix(valueUnits+" "+a+" through "+b, " ")
+		default:
+			a, err := value(item)
+			if err != nil {
+				return "", err
+			}
+			singles = append(singles, a)
+			d = strings.TrimPrefix(valueUnit+" "+a, " ")
+		}
+		descs = append(descs, d)
+	}
+
+	if len(singles) == len(descs) && len(singles) > 1 {
+		return strings.TrimPrefix(valueUnits+" "+joinAnd(singles), " "), nil
+	}
+	return joinAnd(descs), nil
+}
+
+// cronValue parses a number or a 3 letters month or weekday name
+func cronValue(s string) (int, error) {
+	if v, err := strconv.Atoi(s); err == nil {
+		return v, nil
+	}
+	for _, names := range [][]string{cronMonths, cronWeekdays} {
+		for i, n := range names {
+			if len(n) >= 3 && strings.EqualFold(n[:3], s) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid cron value %q", s)
+}
+
+// cronName returns a function naming values with names, out of range values are kept as numbers
+func cronName(names []string) func(int) string {
+	return func(v int) string {
+		if v == 7 && len(names) == 7 {
+			v = 0
+		}
+		if v >= 0 && v < len(names) && names[v] != "" {
+			return names[v]
+		}
+		return strconv.Itoa(v)
+	}
+}
+
+func isCronWildcard(f string) bool {
+	return f == "*" || f == "?"
+}
+
+func isCronNumber(f string) bool {
+	_, err := strconv.Atoi(f)
+	return err == nil
+}
+
+// joinAnd joins l with commas and a final and
+func joinAnd(l []string) string {
+	if len(l) <= 1 {
+		return strings.Join(l, "")
+	}
+	return strings.Join(l[:len(l)-1], ", ") + " and " + l[len(l)-1]
+}
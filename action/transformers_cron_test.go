@@ -0,0 +1,163 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) CronAction(action string, in *Cron, args ...string) (any, error) {
+	a, ok := r.m[cronFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for cron input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextCronTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(cronActions...)
+
+	tests := []struct {
+		name        string
+		in          string
+		wantSpec    string
+		wantCommand string
+		wantErr     bool
+	}{
+		{"5 fields", "*/5 * * * *", "*/5 * * * *", "", false},
+		{"seconds", " 0 30 6 * * SUN,SAT\n", "0 30 6 * * SUN,SAT", "", false},
+		{"crontab line", "5 4 * * 0 /usr/bin/backup --all", "5 4 * * 0", "/usr/bin/backup --all", false},
+		{"timezone", "CRON_TZ=Europe/Paris 0 9 * * 1", "CRON_TZ=Europe/Paris 0 9 * * 1", "", false},
+		{"descriptor", "@daily run.sh", "@daily", "run.sh", false},
+		{"every", "@every 1h30m", "@every 1h30m", "", false},
+		{"every without duration", "@every", "", "", true},
+		{"unknown descriptor", "@weekly2", "", "", true},
+		{"out of range", "61 * * * *", "", "", true},
+		{"too few fields", "* * * *", "", "", true},
+		{"empty", " ", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",cron"].Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			c := got.(*Cron)
+			require.Equal(t, tt.wantSpec, c.Spec)
+			require.Equal(t, tt.wantCommand, c.Command)
+		})
+	}
+}
+
+func TestAction_CronDescribeTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(cronActions...)
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0 9 * * *", "at 09:00 every day"},
+		{"*/15 9-17 * * 1-5", "every 15 minutes, hours 9 through 17 on Monday through Friday"},
+		{"30 * * * *", "at minute 30"},
+		{"0 */2 * * *", "at minute 0 every 2 hours"},
+		{"0 0 1 * *", "at 00:00 on day 1 of the month"},
+		{"0 12 */2 * *", "at 12:00 every 2 days"},
+		{"*/10 * * * * *", "every 10 seconds"},
+		{"* * * * *", "every minute"},
+		{"5-10/1 * * * *", "every minute from 5 through 10"},
+		{"0 0,12 * * *", "at minute 0, hours 0 and 12"},
+		{"0 0 1,15 * MON", "at 00:00 on days 1 and 15 of the month or on Monday"},
+		{"15 10 * JAN-MAR/2 *", "at 10:15 every 2 months from January through March"},
+		{"0 0 * 12 *", "at 00:00 in December"},
+		{"15 30 6 * * SUN,SAT", "at 06:30:15 on Sunday and Saturday"},
+		{"CRON_TZ=Europe/Paris 0 9 * * 1", "at 09:00 on Monday (Europe/Paris)"},
+		{"@weekly", "at 00:00 on Sunday"},
+		{"@every 90s", "every 1 minute 30 seconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			c, err := parseCron(tt.in)
+			require.NoError(t, err)
+			got, err := r.CronAction("describe", c)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got.([]byte)))
+		})
+	}
+}
+
+func TestAction_CronNextTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(cronActions...)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	from := time.Date(2024, 3, 29, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		from time.Time
+		want []string
+	}{
+		{"0 9 * * 1-5", from, []string{"2024-04-01T09:00:00Z", "2024-04-02T09:00:00Z", "2024-04-03T09:00:00Z"}},
+		// 02:30 does not exist on the DST change
+		{"30 2 * * *", from.In(paris), []string{"2024-03-30T02:30:00+01:00", "2024-04-01T02:30:00+02:00", "2024-04-02T02:30:00+02:00"}},
+		// times are in the location of from, the spec zone only sets when it fires
+		{"CRON_TZ=Europe/Paris 0 9 * * *", from, []string{"2024-03-30T08:00:00Z", "2024-03-31T07:00:00Z", "2024-04-01T07:00:00Z"}},
+		{"*/20 * * * * *", from, []string{"2024-03-29T10:00:20Z", "2024-03-29T10:00:40Z", "2024-03-29T10:01:00Z"}},
+		{"@every 1h", from, []string{"2024-03-29T11:00:00Z", "2024-03-29T12:00:00Z", "2024-03-29T13:00:00Z"}},
+		{"0 0 30 2 *", from, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			c, err := parseCron(tt.in)
+			require.NoError(t, err)
+			var got []string
+			for _, ts := range c.Next(tt.from, 3) {
+				got = append(got, ts.Format(time.RFC3339))
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	c, err := parseCron("0 * * * *")
+	require.NoError(t, err)
+	got, err := r.CronAction("next", c, "4", "Asia/Kolkata")
+	require.NoError(t, err)
+	l := got.([]string)
+	require.Len(t, l, 4)
+	for i, s := range l {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		require.Equal(t, "+05:30", s[len(s)-6:])
+		require.Equal(t, 0, ts.Minute())
+		if i > 0 {
+			prev, _ := time.Parse(time.RFC3339, l[i-1])
+			require.Equal(t, time.Hour, ts.Sub(prev))
+		}
+	}
+
+	got, err = r.CronAction("nextrun", c, "UTC")
+	require.NoError(t, err)
+	next := got.(time.Time)
+	require.Equal(t, 0, next.Minute())
+	require.WithinDuration(t, time.Now(), next, time.Hour)
+
+	_, err = r.CronAction("next", c, "0")
+	require.Error(t, err)
+	_, err = r.CronAction("next", c, "3", "Mars/Olympus")
+	require.Error(t, err)
+
+	c, err = parseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	_, err = r.CronAction("nextrun", c)
+	require.Error(t, err)
+}
@@ -0,0 +1,458 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/go-diff/diff"
+)
+
+// Diff is a unified or git diff made of files and their hunks
+type Diff struct {
+	Files []*diff.FileDiff
+}
+
+// diffLine is a line of a hunk, op is one of ' ', '-' or '+'
+type diffLine struct {
+	op        byte
+	text      string
+	noNewline bool
+}
+
+var diffActions = []Action{
+	parseDiffAction, diffFilesAction, diffStatAction, diffHunksAction,
+	diffApplyAction, diffReverseAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(diffActions...)
+}
+
+var parseDiffAction = Action{
+	Doc:          "Parse a unified or git diff into files and hunks",
+	Names:        []string{"diff", "patch"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: diffFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseDiff(in.([]byte))
+	},
+}
+
+var diffFilesAction = Action{
+	Doc:          "List the files touched by the diff",
+	Names:        []string{"files"},
+	Type:         TransformAction,
+	InputFormat:  diffFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var l []string
+		for _, fd := range in.(*Diff).Files {
+			l = append(l, diffFileName(fd))
+		}
+		return l, nil
+	},
+}
+
+var diffStatAction = Action{
+	Doc:          "Lines added and removed per file",
+	Names:        []string{"stat", "diffstat"},
+	Type:         TransformAction,
+	InputFormat:  diffFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := &Table{Header: []string{"file", "status", "hunks", "added", "removed"}, Delimiter: ','}
+		for _, fd := range in.(*Diff).Files {
+			var added, removed int
+			for _, h := range fd.Hunks {
+				a, r := hunkStat(h)
+				added += a
+				removed += r
+			}
+			t.Rows = append(t.Rows, []string{
+				diffFileName(fd), diffFileStatus(fd), strconv.Itoa(len(fd.Hunks)),
+				strconv.Itoa(added), strconv.Itoa(removed),
+			})
+		}
+		return t, nil
+	},
+}
+
+var diffHunksAction = Action{
+	Doc:          "Lines added and removed per hunk",
+	Names:        []string{"hunks"},
+	Type:         TransformAction,
+	InputFormat:  diffFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := &Table{Header: []string{"file", "hunk", "section", "added", "removed"}, Delimiter: ','}
+		for _, fd := range in.(*Diff).Files {
+			for _, h := range fd.Hunks {
+				a, r := hunkStat(h)
+				t.Rows = append(t.Rows, []string{
+					diffFileName(fd),
+					fmt.Sprintf("-%d,%d +%d,%d", h.OrigStartLine, h.OrigLines, h.NewStartLine, h.NewLines),
+					h.Section, strconv.Itoa(a), strconv.Itoa(r),
+				})
+			}
+		}
+		return t, nil
+	},
+}
+
+var diffApplyAction = Action{
+	Doc:          "Apply the patch of a file to the original content, hunks may have moved",
+	Names:        []string{"apply"},
+	Type:         TransformAction,
+	InputFormat:  diffFormat,
+	OutputFormat: textFormat,
+	Params: []Param{
+		{Name: "original", Doc: "the content to patch"},
+		{Name: "file", Doc: "the file of the diff to apply, the first one if empty"},
+	},
+	Func: func(in any, args ...string) (any, error) {
+		fd, err := in.(*Diff).file(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return applyFileDiff(fd, args[0])
+	},
+}
+
+var diffReverseAction = Action{
+	Doc:          "Reverse the diff, undoing the changes",
+	Names:        []string{"reverse", "unapply"},
+	Type:         TransformAction,
+	InputFormat:  diffFormat,
+	OutputFormat: diffFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		d := in.(*Diff)
+		r := &Diff{Files: make([]*diff.FileDiff, len(d.Files))}
+		for i, fd := range d.Files {
+			r.Files[i] = reverseFileDiff(fd)
+		}
+		return r, nil
+	},
+}
+
+func (d *Diff) String() string {
+	b, err := diff.PrintMultiFileDiff(d.Files)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// file returns the file diff named name, the first one when name is empty
+func (d *Diff) file(name string) (*diff.FileDiff, error) {
+	if name == "" {
+		return d.Files[0], nil
+	}
+	for _, fd := range d.Files {
+		if diffFileName(fd) == name || diffName(fd, fd.OrigName) == name {
+			return fd, nil
+		}
+	}
+	return nil, fmt.Errorf("no file %q in the diff", name)
+}
+
+func parseDiff(b []byte) (*Diff, error) {
+	files, err := diff.ParseMultiFileDiff(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid diff: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no file in the diff")
+	}
+	return &Diff{Files: files}, nil
+}
+
+func isGitDiff(fd *diff.FileDiff) bool {
+	return len(fd.Extended) > 0 && strings.HasPrefix(fd.Extended[0], "diff --git ")
+}
+
+// diffName removes the a/ and b/ prefixes of git diffs
+func diffName(fd *diff.FileDiff, name string) string {
+	if !isGitDiff(fd) {
+		return name
+	}
+	if n, ok := strings.CutPrefix(name, "a/"); ok {
+		return n
+	}
+	return strings.TrimPrefix(name, "b/")
+}
+
+// diffFileName is the name of the file after the patch, the original one for a deleted file
+func diffFileName(fd *diff.FileDiff) string {
+	if fd.NewName == "" || fd.NewName == "/dev/null" {
+		return diffName(fd, fd.OrigName)
+	}
+	return diffName(fd, fd.NewName)
+}
+
+func diffFileStatus(fd *diff.FileDiff) string {
+	hasHeader := func(prefix string) bool {
+		for _, h := range fd.Extended {
+			if strings.HasPrefix(h, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case fd.OrigName == "/dev/null" || hasHeader("new file mode"):
+		return "added"
+	case fd.NewName == "/dev/null" || hasHeader("deleted file mode"):
+		return "deleted"
+	case hasHeader("copy from"):
+		return "copied"
+	case hasHeader("rename from") || (isGitDiff(fd) && diffName(fd, fd.OrigName) != diffName(fd, fd.NewName)):
+		return "renamed"
+	}
+	return "modified"
+}
+
+// hunkStat counts the added and removed lines
+func hunkStat(h *diff.Hunk) (added, removed int) {
+	for _, l := range hunkLines(h) {
+		switch l.op {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// hunkLines splits the body of a hunk, the original side missing a final newline is recorded
+// as an offset in the body, the new side or both as a body without a final newline
+func hunkLines(h *diff.Hunk) []diffLine {
+	var l []diffLine
+	body := h.Body
+	offset := 0
+	for len(body) > 0 {
+		line := body
+		noNewline := true
+		if i := bytes.IndexByte(body, '\n'); i >= 0 {
+			line = body[:i]
+			noNewline = false
+			offset += i + 1
+			body = body[i+1:]
+		} else {
+			body = nil
+		}
+
+		// some tools strip the space of empty context lines
+		dl := diffLine{op: ' '}
+		if len(line) > 0 {
+			dl = diffLine{op: line[0], text: string(line[1:])}
+		}
+		dl.noNewline = noNewline || (dl.op == '-' && h.OrigNoNewlineAt > 0 && int(h.OrigNoNewlineAt) == offset)
+		l = append(l, dl)
+	}
+	return l
+}
+
+// setHunkLines sets the body and the line counts of h from lines
+func setHunkLines(h *diff.Hunk, lines []diffLine) {
+	var buf bytes.Buffer
+	h.OrigLines, h.NewLines, h.OrigNoNewlineAt = 0, 0, 0
+	for _, l := range lines {
+		if l.op != '+' {
+			h.OrigLines++
+		}
+		if l.op != '-' {
+			h.NewLines++
+		}
+		buf.WriteByte(l.op)
+		buf.WriteString(l.text)
+		if l.noNewline && l.op != '-' {
+			continue
+		}
+		buf.WriteByte('\n')
+		if l.noNewline {
+			h.OrigNoNewlineAt = int32(buf.Len())
+		}
+	}
+	h.Body = buf.Bytes()
+}
+
+// reverseFileDiff swaps the original and new sides, removals are kept before additions
+func reverseFileDiff(fd *diff.FileDiff) *diff.FileDiff {
+	r := &diff.FileDiff{
+		OrigName: fd.NewName,
+		OrigTime: fd.NewTime,
+		NewName:  fd.OrigName,
+		NewTime:  fd.OrigTime,
+		Extended: reverseGitHeaders(fd.Extended),
+	}
+	if isGitDiff(fd) {
+		r.OrigName = swapPrefix(r.OrigName, "b/", "a/")
+		r.NewName = swapPrefix(r.NewName, "a/", "b/")
+	}
+
+	for _, h := range fd.Hunks {
+		rh := &diff.Hunk{
+			OrigStartLine: h.NewStartLine,
+			NewStartLine:  h.OrigStartLine,
+			Section:       h.Section,
+			StartPosition: h.StartPosition,
+		}
+		var lines, removed, added []diffLine
+		flush := func() {
+			lines = append(append(lines, removed...), added...)
+			removed, added = nil, nil
+		}
+		for _, l := range hunkLines(h) {
+			switch l.op {
+			case '+':
+				l.op = '-'
+				removed = append(removed, l)
+			case '-':
+				l.op = '+'
+				added = append(added, l)
+			default:
+				flush()
+				lines = append(lines, l)
+			}
+		}
+		flush()
+		setHunkLines(rh, lines)
+		r.Hunks = append(r.Hunks, rh)
+	}
+	return r
+}
+
+// reverseGitHeaders swaps the modes, the renames and the blob hashes of git extended headers
+func reverseGitHeaders(headers []string) []string {
+	r := make([]string, len(headers))
+	copy(r, headers)
+	for i, h := range r {
+		switch {
+		case strings.HasPrefix(h, "diff --git a/"):
+			args := strings.TrimPrefix(h, "diff --git ")
+			if j := strings.LastIndex(args, " b/"); j > 0 {
+				r[i] = "diff --git a/" + args[j+3:] + " b/" + args[2:j]
+			}
+		case strings.HasPrefix(h, "new file mode "):
+			r[i] = "deleted" + strings.TrimPrefix(h, "new")
+		case strings.HasPrefix(h, "deleted file mode "):
+			r[i] = "new" + strings.TrimPrefix(h, "deleted")
+		case strings.HasPrefix(h, "index "):
+			hashes, mode, _ := strings.Cut(strings.TrimPrefix(h, "index "), " ")
+			if from, to, ok := strings.Cut(hashes, ".."); ok {
+				r[i] = strings.TrimSuffix("index "+to+".."+from+" "+mode, " ")
+			}
+		}
+	}
+
+	// the values are exchanged, keeping the usual order of the lines
+	for _, pair := range [][2]string{{"old mode ", "new mode "}, {"rename from ", "rename to "}} {
+		from, to := -1, -1
+		for i, h := range r {
+			if strings.HasPrefix(h, pair[0]) {
+				from = i
+			} else if strings.HasPrefix(h, pair[1]) {
+				to = i
+			}
+		}
+		if from >= 0 && to >= 0 {
+			r[from], r[to] = pair[0]+strings.TrimPrefix(r[to], pair[1]), pair[1]+strings.TrimPrefix(r[from], pair[0])
+		}
+	}
+	return r
+}
+
+func swapPrefix(name, from, to string) string {
+	if n, ok := strings.CutPrefix(name, from); ok {
+		return to + n
+	}
+	return name
+}
+
+// applyFileDiff applies the hunks to original, a hunk is searched around its position
+// when the previous lines have changed
+func applyFileDiff(fd *diff.FileDiff, original string) ([]byte, error) {
+	lines := strings.Split(original, "\n")
+	newline := true
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		newline = false
+	}
+
+	var out []string
+	cursor, drift := 0, 0
+	for i, h := range fd.Hunks {
+		var old, updated []string
+		noNewline := false
+		for _, l := range hunkLines(h) {
+			if l.op != '+' {
+				old = append(old, l.text)
+			}
+			if l.op != '-' {
+				updated = append(updated, l.text)
+				noNewline = l.noNewline
+			}
+		}
+
+		// an empty original side is inserted after its start line
+		want := int(h.OrigStartLine) + drift
+		if len(old) > 0 {
+			want--
+		}
+		pos := findLines(lines, old, cursor, want)
+		if pos < 0 {
+			return nil, fmt.Errorf("hunk %d of %s does not apply", i+1, diffFileName(fd))
+		}
+		drift = pos - int(h.OrigStartLine)
+		if len(old) > 0 {
+			drift++
+		}
+
+		out = append(append(out, lines[cursor:pos]...), updated...)
+		cursor = pos + len(old)
+		if cursor == len(lines) {
+			newline = !noNewline
+		}
+	}
+	out = append(out, lines[cursor:]...)
+
+	s := strings.Join(out, "\n")
+	if newline && len(out) > 0 {
+		s += "\n"
+	}
+	return []byte(s), nil
+}
+
+// findLines returns the position of sub in lines closest to want, not before from, or -1
+func findLines(lines, sub []string, from, want int) int {
+	match := func(p int) bool {
+		if p < from || p+len(sub) > len(lines) {
+			return false
+		}
+		for i, s := range sub {
+			if lines[p+i] != s {
+				return false
+			}
+		}
+		return true
+	}
+	// a hunk header can hold any start line, the search stays within lines
+	want = max(from, min(want, len(lines)))
+	for d := 0; want-d >= from || want+d+len(sub) <= len(lines); d++ {
+		if match(want - d) {
+			return want - d
+		}
+		if match(want + d) {
+			return want + d
+		}
+	}
+	return -1
+}
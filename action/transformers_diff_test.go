@@ -0,0 +1,280 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) DiffAction(action string, in *Diff, args ...string) (any, error) {
+	a, ok := r.m[diffFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for diff input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+const testGitDiff = `diff --git a/main.go b/main.go
+index 3b18e51..a042389 100644
+--- a/main.go
++++ b/main.go
+@@ -1,5 +1,7 @@ package main
+ package main
+ 
+-import "fmt"
++import (
++	"fmt"
++)
+ 
+ func main() {
+@@ -8,3 +10,3 @@ func main() {
+ 	fmt.Println("a")
+-	fmt.Println("b")
++	fmt.Println("c")
+ }
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index e69de29..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1,1 +0,0 @@
+-bye
+diff --git a/README b/README
+new file mode 100644
+index 0000000..ce01362
+--- /dev/null
++++ b/README
+@@ -0,0 +1,1 @@
++hello
+\ No newline at end of file
+diff --git a/a.txt b/b.txt
+similarity index 100%
+rename from a.txt
+rename to b.txt
+`
+
+const testMainGo = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("start")
+	fmt.Println("a")
+	fmt.Println("a")
+	fmt.Println("b")
+}
+`
+
+func TestAction_TextDiffTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(diffActions...)
+
+	tests := []struct {
+		name      string
+		in        string
+		wantFiles int
+		wantErr   bool
+	}{
+		{"git", testGitDiff, 4, false},
+		{"unified", "--- f.txt\t2024-01-01 10:00:00.000000000 +0000\n+++ f.txt\t2024-01-02 10:00:00.000000000 +0000\n@@ -1,1 +1,1 @@\n-a\n+b\n", 1, false},
+		{"text", "hello world\n", 0, true},
+		{"empty", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",diff"].Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got.(*Diff).Files, tt.wantFiles)
+			require.Equal(t, tt.in, got.(*Diff).String())
+		})
+	}
+}
+
+func TestAction_DiffStatTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(diffActions...)
+
+	d, err := parseDiff([]byte(testGitDiff))
+	require.NoError(t, err)
+
+	got, err := r.DiffAction("files", d)
+	require.NoError(t, err)
+	require.Equal(t, []string{"main.go", "old.txt", "README", "b.txt"}, got)
+
+	got, err = r.DiffAction("stat", d)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"main.go", "modified", "2", "4", "2"},
+		{"old.txt", "deleted", "1", "0", "1"},
+		{"README", "added", "1", "1", "0"},
+		{"b.txt", "renamed", "0", "0", "0"},
+	}, got.(*Table).Rows)
+
+	got, err = r.DiffAction("hunks", d)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"main.go", "-1,5 +1,7", "package main", "3", "1"},
+		{"main.go", "-8,3 +10,3", "func main() {", "1", "1"},
+		{"old.txt", "-1,1 +0,0", "", "0", "1"},
+		{"README", "-0,0 +1,1", "", "1", "0"},
+	}, got.(*Table).Rows)
+}
+
+func TestAction_DiffApplyTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(diffActions...)
+
+	d, err := parseDiff([]byte(testGitDiff))
+	require.NoError(t, err)
+
+	// the second hunk has moved by one line, the first matching lines are patched
+	patched := "package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {\n\tfmt.Println(\"start\")\n\tfmt.Println(\"a\")\n\tfmt.Println(\"a\")\n\tfmt.Println(\"c\")\n}\n"
+
+	tests := []struct {
+		name     string
+		original string
+		file     string
+		want     string
+		wantErr  bool
+	}{
+		{"first file", testMainGo, "", patched, false},
+		{"named", testMainGo, "main.go", patched, false},
+		{"deleted", "bye\n", "old.txt", "", false},
+		{"added without newline", "", "README", "hello", false},
+		{"not matching", "package other\n", "main.go", "", true},
+		{"unknown file", testMainGo, "nope.go", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.DiffAction("apply", d, tt.original, tt.file)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got.([]byte)))
+		})
+	}
+
+	// a start line far after the end is searched from the end
+	far, err := parseDiff([]byte("--- f\n+++ f\n@@ -2000000000,1 +2000000000,1 @@\n-a\n+b\n"))
+	require.NoError(t, err)
+	got, err := r.DiffAction("apply", far, "a\nc\n")
+	require.NoError(t, err)
+	require.Equal(t, "b\nc\n", string(got.([]byte)))
+}
+
+func TestAction_DiffReverseTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(diffActions...)
+
+	d, err := parseDiff([]byte(testGitDiff))
+	require.NoError(t, err)
+
+	got, err := r.DiffAction("reverse", d)
+	require.NoError(t, err)
+	rev := got.(*Diff)
+	require.Equal(t, `diff --git a/main.go b/main.go
+index a042389..3b18e51 100644
+--- a/main.go
++++ b/main.go
+@@ -1,7 +1,5 @@ package main
+ package main
+ 
+-import (
+-	"fmt"
+-)
++import "fmt"
+ 
+ func main() {
+@@ -10,3 +8,3 @@ func main() {
+ 	fmt.Println("a")
+-	fmt.Println("c")
++	fmt.Println("b")
+ }
+diff --git a/old.txt b/old.txt
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/old.txt
+@@ -0,0 +1,1 @@
++bye
+diff --git a/README b/README
+deleted file mode 100644
+index ce01362..0000000
+--- a/README
++++ /dev/null
+@@ -1,1 +0,0 @@
+-hello
+\ No newline at end of file
+diff --git a/b.txt b/a.txt
+similarity index 100%
+rename from b.txt
+rename to a.txt
+`, rev.String())
+
+	got, err = r.DiffAction("stat", rev)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "renamed"}, got.(*Table).Rows[3][:2])
+
+	// reversing twice gives back the diff, applying the reverse undoes the patch
+	got, err = r.DiffAction("reverse", rev)
+	require.NoError(t, err)
+	require.Equal(t, testGitDiff, got.(*Diff).String())
+
+	patched, err := r.DiffAction("apply", d, testMainGo)
+	require.NoError(t, err)
+	got, err = r.DiffAction("apply", rev, string(patched.([]byte)))
+	require.NoError(t, err)
+	require.Equal(t, testMainGo, string(got.([]byte)))
+
+	got, err = r.DiffAction("apply", rev, "", "old.txt")
+	require.NoError(t, err)
+	require.Equal(t, "bye\n", string(got.([]byte)))
+
+	got, err = r.DiffAction("apply", rev, "hello", "README")
+	require.NoError(t, err)
+	require.Equal(t, "", string(got.([]byte)))
+}
+
+func TestHunkLinesNoNewline(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		original string
+		patched  string
+	}{
+		{"original", "--- f\n+++ f\n@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+b\n", "a", "b\n"},
+		{"new", "--- f\n+++ f\n@@ -1,1 +1,1 @@\n-a\n+b\n\\ No newline at end of file\n", "a\n", "b"},
+		{"both", "--- f\n+++ f\n@@ -1,2 +1,2 @@\n-a\n+b\n c\n\\ No newline at end of file\n", "a\nc", "b\nc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parseDiff([]byte(tt.in))
+			require.NoError(t, err)
+			h := d.Files[0].Hunks[0]
+			setHunkLines(h, hunkLines(h))
+			require.Equal(t, tt.in, d.String())
+
+			twice := reverseFileDiff(reverseFileDiff(d.Files[0]))
+			require.Equal(t, tt.in, (&Diff{Files: []*diff.FileDiff{twice}}).String())
+
+			got, err := applyFileDiff(d.Files[0], tt.original)
+			require.NoError(t, err)
+			require.Equal(t, tt.patched, string(got))
+			got, err = applyFileDiff(reverseFileDiff(d.Files[0]), tt.patched)
+			require.NoError(t, err)
+			require.Equal(t, tt.original, string(got))
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+	// ISO 8601 years and months have no fixed duration, they are approximated out of a time
+	year  = 365 * day
+	month = 30 * day
+)
+
+var durationActions = []Action{
+	parseDurationAction, parseMillisecondsAction, numberDurationAction,
+	durationHumanAction, durationStringAction, durationISOAction, durationConvertAction,
+	timeAddAction, timeSubAction, textListBetweenAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(durationActions...)
+}
+
+var parseDurationAction = Action{
+	Doc:          "Parse a duration, Go (1h30m, 2d), ISO 8601 (P1DT2H) or seconds",
+	Names:        []string{"duration"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: durationFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseDuration(string(in.([]byte)))
+	},
+}
+
+var parseMillisecondsAction = Action{
+	Doc:          "Parse a number of milliseconds as a duration",
+	Names:        []string{"ms", "milliseconds"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: durationFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		n, err := parseNumber(string(in.([]byte)))
+		if err != nil {
+			return nil, err
+		}
+		return numberToDuration(n, time.Millisecond)
+	},
+}
+
+var numberDurationAction = Action{
+	Doc:          "Transforms a number to a duration",
+	Names:        []string{"toduration"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: durationFormat,
+	Params:       []Param{{Name: "unit", Doc: "unit of the number: ns, us, ms, s, m, h, d or w", Default: "s"}},
+	Func: func(in any, args ...string) (any, error) {
+		unit, err := durationUnit(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return numberToDuration(in.(*Number), unit)
+	},
+}
+
+var durationHumanAction = Action{
+	Doc:          "Transforms a duration to human readable text, e.g. 2 days 3 hours",
+	Names:        []string{"human"},
+	Type:         TransformAction,
+	InputFormat:  durationFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "precision", Doc: "number of units to show", Default: "2"}},
+	Func: func(in any, args ...string) (any, error) {
+		p, err := strconv.Atoi(args[0])
+		if err != nil || p < 1 {
+			return nil, fmt.Errorf("invalid precision %q", args[0])
+		}
+		return []byte(humanDuration(in.(time.Duration), p)), nil
+	},
+}
+
+var durationStringAction = Action{
+	Doc:          "Transforms a duration to Go duration text, e.g. 51h0m0s",
+	Names:        []string{"totext"},
+	Type:         TransformAction,
+	InputFormat:  durationFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(in.(time.Duration).String()), nil
+	},
+}
+
+var durationISOAction = Action{
+	Doc:          "Transforms a duration to ISO 8601 text, e.g. P2DT3H",
+	Names:        []string{"iso"},
+	Type:         TransformAction,
+	InputFormat:  durationFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(isoDuration(in.(time.Duration))), nil
+	},
+}
+
+var durationConvertAction = Action{
+	Doc:          "Transforms a duration to a number of units",
+	Names:        []string{"convert", "tonumber"},
+	Type:         TransformAction,
+	InputFormat:  durationFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "unit", Doc: "ns, us, ms, s, m, h, d or w", Default: "s"}},
+	Func: func(in any, args ...string) (any, error) {
+		unit, err := durationUnit(args[0])
+		if err != nil {
+			return nil, err
+		}
+		r := big.NewRat(int64(in.(time.Duration)), int64(unit))
+		return floatNumber(new(big.Float).SetPrec(floatPrec).SetRat(r)), nil
+	},
+}
+
+var timeAddAction = Action{
+	Doc:          "Add a duration to time, ISO 8601 years, months and days are calendar based",
+	Names:        []string{"add"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: timeFormat,
+	Params:       []Param{{Name: "duration", Doc: "Go (1h30m) or ISO 8601 (P1M) duration", Default: "1h"}},
+	Func: func(in any, args ...string) (any, error) {
+		return addDuration(in.(time.Time), args[0], 1)
+	},
+}
+
+var timeSubAction = Action{
+	Doc:          "Subtract a duration from time, ISO 8601 years, months and days are calendar based",
+	Names:        []string{"sub"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: timeFormat,
+	Params:       []Param{{Name: "duration", Doc: "Go (1h30m) or ISO 8601 (P1M) duration", Default: "1h"}},
+	Func: func(in any, args ...string) (any, error) {
+		return addDuration(in.(time.Time), args[0], -1)
+	},
+}
+
+var textListBetweenAction = Action{
+	Doc:          "Duration between the 2 times of a list",
+	Names:        []string{"between"},
+	Type:         TransformAction,
+	InputFormat:  textListFormat,
+	OutputFormat: durationFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		l := in.([]string)
+		if len(l) != 2 {
+			return nil, fmt.Errorf("expecting 2 times, got %d", len(l))
+		}
+		start, err := parseTimeText(l[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimeText(l[1])
+		if err != nil {
+			return nil, err
+		}
+		return end.Sub(start), nil
+	},
+}
+
+// isoDurationRe matches ISO 8601 durations, with an optional sign
+var isoDurationRe = regexp.MustCompile(`^([+-])?P(?:([\d.,]+)Y)?(?:([\d.,]+)M)?(?:([\d.,]+)W)?(?:([\d.,]+)D)?` +
+	`(?:T(?:([\d.,]+)H)?(?:([\d.,]+)M)?(?:([\d.,]+)S)?)?$`)
+
+// goDaysRe matches Go durations with weeks or days
+var goDaysRe = regexp.MustCompile(`^([+-])?(?:([\d.]+)w)?(?:([\d.]+)d)?(.*)$`)
+
+// parseDuration parses a Go duration, also accepting d and w units, an ISO 8601 duration or seconds
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(strings.ToUpper(s), "P") {
+		iso, err := parseISODuration(s)
+		if err != nil {
+			return 0, err
+		}
+		return iso.approximate()
+	}
+
+	if n, err := parseNumber(s); err == nil {
+		return numberToDuration(n, time.Second)
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	m := goDaysRe.FindStringSubmatch(s)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{week, day} {
+		if m[2+i] == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(m[2+i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		v, ok := floatDuration(f, unit)
+		if ok {
+			d, ok = addDurations(d, v)
+		}
+		if !ok {
+			return 0, errors.New("duration out of range")
+		}
+	}
+	if m[4] != "" {
+		rest, err := time.ParseDuration(m[4])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += rest
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// isoPeriod is an ISO 8601 duration, the date part is kept apart to be applied on a calendar
+type isoPeriod struct {
+	years, months, days int
+	clock               time.Duration
+	sign                int
+}
+
+// parseISODuration parses an ISO 8601 duration, fractions are accepted on weeks and the time part
+func parseISODuration(s string) (isoPeriod, error) {
+	m := isoDurationRe.FindStringSubmatch(strings.ToUpper(s))
+	if m == nil || strings.HasSuffix(s, "P") || strings.HasSuffix(strings.ToUpper(s), "T") {
+		return isoPeriod{}, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+
+	p := isoPeriod{sign: 1}
+	if m[1] == "-" {
+		p.sign = -1
+	}
+
+	for i, dst := range []*int{&p.years, &p.months} {
+		if m[2+i] == "" {
+			continue
+		}
+		v, err := strconv.Atoi(m[2+i])
+		if err != nil {
+			return isoPeriod{}, fmt.Errorf("invalid ISO 8601 duration %q: only weeks and time can have fractions", s)
+		}
+		*dst = v
+	}
+
+	for i, unit := range []time.Duration{week, day, time.Hour, time.Minute, time.Second} {
+		v := m[4+i]
+		if v == "" {
+			continue
+		}
+		// days are calendar days unless they have a fraction
+		if unit == day {
+			if d, err := strconv.Atoi(v); err == nil {
+				p.days = d
+				continue
+			}
+		}
+		f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+		if err != nil {
+			return isoPeriod{}, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		dv, ok := floatDuration(f, unit)
+		if ok {
+			p.clock, ok = addDurations(p.clock, dv)
+		}
+		if !ok {
+			return isoPeriod{}, fmt.Errorf("invalid ISO 8601 duration %q: out of range", s)
+		}
+	}
+	return p, nil
+}
+
+// approximate returns the duration using 365 days years and 30 days months
+func (p isoPeriod) approximate() (time.Duration, error) {
+	d, ok := p.clock, true
+	for _, part := range []struct {
+		n    int
+		unit time.Duration
+	}{{p.years, year}, {p.months, month}, {p.days, day}} {
+		var v time.Duration
+		if v, ok = mulDuration(part.n, part.unit); !ok {
+			break
+		}
+		if d, ok = addDurations(d, v); !ok {
+			break
+		}
+	}
+	if !ok {
+		return 0, errors.New("duration out of range")
+	}
+	return time.Duration(p.sign) * d, nil
+}
+
+// mulDuration returns n units, ok is false when it overflows
+func mulDuration(n int, unit time.Duration) (time.Duration, bool) {
+	if n > math.MaxInt64/int(unit) || n < math.MinInt64/int(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// floatDuration returns f units rounded to the nanosecond, ok is false when it overflows
+func floatDuration(f float64, unit time.Duration) (time.Duration, bool) {
+	v := math.Round(f * float64(unit))
+	// float64(math.MaxInt64) is 2^63, out of range
+	if math.IsNaN(v) || v >= math.MaxInt64 || v < math.MinInt64 {
+		return 0, false
+	}
+	return time.Duration(v), true
+}
+
+// addDurations returns a + b, ok is false when it overflows
+func addDurations(a, b time.Duration) (time.Duration, bool) {
+	d := a + b
+	if (b > 0 && d < a) || (b < 0 && d > a) {
+		return 0, false
+	}
+	return d, true
+}
+
+// addDuration adds sign times the duration s to t
+func addDuration(t time.Time, s string, sign int) (time.Time, error) {
+	if strings.Contains(strings.ToUpper(s), "P") {
+		p, err := parseISODuration(s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		sign *= p.sign
+		return t.AddDate(sign*p.years, sign*p.months, sign*p.days).Add(time.Duration(sign) * p.clock), nil
+	}
+
+	d, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(time.Duration(sign) * d), nil
+}
+
+// durationUnit returns the duration of a unit name
+func durationUnit(s string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "ns":
+		return time.Nanosecond, nil
+	case "us", "µs":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	case "d":
+		return day, nil
+	case "w":
+		return week, nil
+	}
+	return 0, fmt.Errorf("unknown unit %q", s)
+}
+
+// numberToDuration returns n units as a duration, rounded to the nanosecond
+func numberToDuration(n *Number, unit time.Duration) (time.Duration, error) {
+	f := new(big.Float).SetPrec(floatPrec).Mul(n.float(), new(big.Float).SetInt64(int64(unit)))
+	ns := roundNumber(floatNumber(f), 0)
+	if ns.Int == nil || !ns.Int.IsInt64() {
+		return 0, errors.New("duration out of range")
+	}
+	return time.Duration(ns.Int.Int64()), nil
+}
+
+var humanUnits = []struct {
+	d    time.Duration
+	name string
+}{
+	{day, "day"}, {time.Hour, "hour"}, {time.Minute, "minute"}, {time.Second, "second"},
+	{time.Millisecond, "millisecond"}, {time.Microsecond, "microsecond"}, {time.Nanosecond, "nanosecond"},
+}
+
+// humanDuration returns the precision largest non zero units of d, e.g. 2 days 3 hours
+func humanDuration(d time.Duration, precision int) string {
+	if d == 0 {
+		return "0 seconds"
+	}
+
+	var parts []string
+	if d < 0 {
+		parts = append(parts, "minus")
+		d = -d
+	}
+
+	count := 0
+	for _, u := range humanUnits {
+		if count == precision {
+			break
+		}
+		v := d / u.d
+		d -= v * u.d
+		if v == 0 {
+			// once started, skipped units count to keep the precision of the largest unit
+			if count > 0 {
+				count++
+			}
+			continue
+		}
+		name := u.name
+		if v > 1 {
+			name += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", v, name))
+		count++
+	}
+	return strings.Join(parts, " ")
+}
+
+// isoDuration returns d as an ISO 8601 duration using days, hours, minutes and seconds
+func isoDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+
+	if days := d / day; days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * day
+	}
+	if d == 0 {
+		return b.String()
+	}
+
+	b.WriteByte('T')
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		d -= m * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) DurationAction(action string, in time.Duration, args ...string) (any, error) {
+	a, ok := r.m[durationFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for duration input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextDurationTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(durationActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"go", "duration", "1h30m", 90 * time.Minute, false},
+		{"go days", "duration", "2d3h", 51 * time.Hour, false},
+		{"go weeks", "duration", "-1w", -7 * 24 * time.Hour, false},
+		{"iso", "duration", "P1DT2H", 26 * time.Hour, false},
+		{"iso fraction", "duration", "PT1.5S", 1500 * time.Millisecond, false},
+		{"iso weeks", "duration", "P2W", 14 * 24 * time.Hour, false},
+		{"iso month", "duration", "P1M", 30 * 24 * time.Hour, false},
+		{"iso negative", "duration", "-PT10M", -10 * time.Minute, false},
+		{"iso empty", "duration", "P", 0, true},
+		{"iso empty time", "duration", "P1DT", 0, true},
+		{"iso longest years", "duration", "-P290Y", -290 * 365 * 24 * time.Hour, false},
+		{"iso years overflow", "duration", "P300Y", 0, true},
+		{"iso hours overflow", "duration", "PT3000000H", 0, true},
+		{"iso sum overflow", "duration", "P200YT1000000H", 0, true},
+		{"go weeks overflow", "duration", "20000w", 0, true},
+		{"seconds", "duration", "90", 90 * time.Second, false},
+		{"seconds fraction", "duration", "0.25", 250 * time.Millisecond, false},
+		{"invalid", "duration", "soon", 0, true},
+		{"milliseconds", "ms", "1500", 1500 * time.Millisecond, false},
+		{"milliseconds invalid", "ms", "1s", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := r.m[textFormat.Prefix+","+tt.action]
+			require.True(t, ok)
+			got, err := a.Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAction_DurationTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(durationActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      time.Duration
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"human", "human", 51*time.Hour + 4*time.Minute, nil, "2 days 3 hours", false},
+		{"human precision", "human", 51*time.Hour + 4*time.Minute + 5*time.Second, []string{"3"}, "2 days 3 hours 4 minutes", false},
+		{"human skipped unit", "human", 24*time.Hour + 5*time.Second, nil, "1 day", false},
+		{"human short", "human", 1500 * time.Millisecond, nil, "1 second 500 milliseconds", false},
+		{"human negative", "human", -time.Minute, nil, "minus 1 minute", false},
+		{"human zero", "human", 0, nil, "0 seconds", false},
+		{"human invalid precision", "human", time.Minute, []string{"0"}, "", true},
+		{"text", "totext", 51 * time.Hour, nil, "51h0m0s", false},
+		{"iso", "iso", 51*time.Hour + 90*time.Second, nil, "P2DT3H1M30S", false},
+		{"iso days", "iso", 48 * time.Hour, nil, "P2D", false},
+		{"iso fraction", "iso", -1500 * time.Millisecond, nil, "-PT1.5S", false},
+		{"iso zero", "iso", 0, nil, "PT0S", false},
+		{"seconds", "convert", 1500 * time.Millisecond, nil, "1.5", false},
+		{"hours", "convert", 90 * time.Minute, []string{"h"}, "1.5", false},
+		{"milliseconds", "convert", 2 * time.Second, []string{"ms"}, "2000", false},
+		{"unknown unit", "convert", time.Second, []string{"fortnight"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.DurationAction(tt.action, tt.in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch v := got.(type) {
+			case []byte:
+				require.Equal(t, tt.want, string(v))
+			case *Number:
+				require.Equal(t, tt.want, v.String())
+			}
+		})
+	}
+}
+
+func TestAction_TimeDurationTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(durationActions...)
+
+	in := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		action  string
+		args    []string
+		want    time.Time
+		wantErr bool
+	}{
+		{"add default", "add", nil, time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC), false},
+		{"add go", "add", []string{"2d30m"}, time.Date(2024, 2, 2, 10, 30, 0, 0, time.UTC), false},
+		{"add iso month", "add", []string{"P1M"}, time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC), false},
+		{"add iso", "add", []string{"P1DT2H"}, time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC), false},
+		{"sub", "sub", []string{"90m"}, time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC), false},
+		{"sub iso year", "sub", []string{"P1Y"}, time.Date(2023, 1, 31, 10, 0, 0, 0, time.UTC), false},
+		{"invalid", "add", []string{"later"}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := r.m[timeFormat.Prefix+","+tt.action]
+			require.True(t, ok)
+			got, err := a.Func(in, a.WithArgs(tt.args...).args()...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAction_TextListDurationTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(durationActions...)
+
+	tests := []struct {
+		name    string
+		in      []string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"rfc3339", []string{"2024-01-01T10:00:00Z", "2024-01-02T12:30:00+01:00"}, 25*time.Hour + 30*time.Minute, false},
+		{"date", []string{"2024-01-01", "2023-12-31"}, -24 * time.Hour, false},
+		{"epoch", []string{"1704067200", "2024-01-01 00:01:00"}, time.Minute, false},
+		{"one time", []string{"2024-01-01"}, 0, true},
+		{"invalid", []string{"2024-01-01", "tomorrow"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textListFormat.Prefix+",between"].Func(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
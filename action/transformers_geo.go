@@ -31,7 +31,7 @@ var toGeoJSONAction = Action{
 	Type:         TransformAction,
 	InputFormat:  geoFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return json.Marshal(in.(geom.Geometry))
 	},
 }
@@ -42,7 +42,7 @@ var fromGeoJSONAction = Action{
 	Type:         ParseAction,
 	InputFormat:  textFormat,
 	OutputFormat: geoFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		var g geom.Geometry
 		err := json.Unmarshal(in.([]byte), &g)
 		return g, err
@@ -55,7 +55,7 @@ var fromWKTAction = Action{
 	Type:         ParseAction,
 	InputFormat:  textFormat,
 	OutputFormat: geoFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return geom.UnmarshalWKT(string(in.([]byte)))
 	},
 }
@@ -66,7 +66,7 @@ var toWKTAction = Action{
 	Type:         TransformAction,
 	InputFormat:  geoFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(in.(geom.Geometry).AsText()), nil
 	},
 }
@@ -77,7 +77,7 @@ var centroidAction = Action{
 	Type:         TransformAction,
 	InputFormat:  geoFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		return []byte(in.(geom.Geometry).Centroid().AsText()), nil
 	},
 }
@@ -88,7 +88,7 @@ var geojsonioAction = Action{
 	Type:         TransformAction,
 	InputFormat:  geoFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		geojson, err := json.Marshal(in.(geom.Geometry))
 		if err != nil {
 			return nil, err
@@ -107,7 +107,7 @@ var countryAction = Action{
 	Type:         TransformAction,
 	InputFormat:  geoFormat,
 	OutputFormat: textFormat,
-	Func: func(in any) (any, error) {
+	Func: func(in any, _ ...string) (any, error) {
 		xy, ok := in.(geom.Geometry).Centroid().XY()
 		if !ok {
 			return nil, fmt.Errorf("no coordinates for centroid")
@@ -0,0 +1,506 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTML is a parsed document or fragment, Doc is a document node holding the top level nodes
+type HTML struct {
+	Doc *html.Node
+}
+
+// htmlBlocks are the elements rendered on their own lines as text, paragraphs get a blank line
+var htmlBlocks = map[atom.Atom]bool{
+	atom.Address: false, atom.Article: false, atom.Aside: false, atom.Dd: false, atom.Div: false,
+	atom.Dl: false, atom.Dt: false, atom.Fieldset: false, atom.Figcaption: false, atom.Figure: false,
+	atom.Footer: false, atom.Form: false, atom.Header: false, atom.Hr: false, atom.Li: false,
+	atom.Main: false, atom.Nav: false, atom.Section: false, atom.Tr: false, atom.Caption: false,
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Table: true, atom.Ul: true, atom.Ol: true,
+}
+
+// htmlSkipped are the elements without readable text
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Template: true, atom.Noscript: true,
+}
+
+// htmlVoids are the elements without an end tag
+var htmlVoids = map[atom.Atom]bool{
+	atom.Area: true, atom.Base: true, atom.Br: true, atom.Col: true, atom.Embed: true, atom.Hr: true,
+	atom.Img: true, atom.Input: true, atom.Link: true, atom.Meta: true, atom.Source: true,
+	atom.Track: true, atom.Wbr: true,
+}
+
+// htmlRaw are the elements whose content is kept as is when pretty printing
+var htmlRaw = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Pre: true, atom.Textarea: true,
+}
+
+// htmlPhrasing are the inline elements kept on the line of their parent when pretty printing
+var htmlPhrasing = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Br: true, atom.Cite: true, atom.Code: true,
+	atom.Em: true, atom.I: true, atom.Img: true, atom.Kbd: true, atom.Mark: true, atom.Q: true,
+	atom.S: true, atom.Small: true, atom.Span: true, atom.Strong: true, atom.Sub: true, atom.Sup: true,
+	atom.Time: true, atom.U: true, atom.Var: true, atom.Label: true, atom.Wbr: true,
+}
+
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+var htmlActions = []Action{
+	parseHTMLAction, htmlPrettyAction, htmlTextAction, htmlLinksAction, htmlImagesAction,
+	htmlTableAction, htmlSelectAction, htmlEscapeAction, htmlUnescapeAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(htmlActions...)
+}
+
+var parseHTMLAction = Action{
+	Doc:          "Parse an HTML document or fragment",
+	Names:        []string{"html"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: htmlFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseHTML(in.([]byte))
+	},
+}
+
+var htmlPrettyAction = Action{
+	Doc:          "Pretty print with one element per line",
+	Names:        []string{"pretty", "indent"},
+	Type:         TransformAction,
+	InputFormat:  htmlFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var sb strings.Builder
+		for c := in.(*HTML).Doc.FirstChild; c != nil; c = c.NextSibling {
+			writePrettyHTML(&sb, c, 0)
+		}
+		return []byte(strings.TrimSuffix(sb.String(), "\n")), nil
+	},
+}
+
+var htmlTextAction = Action{
+	Doc:          "Strip the tags to readable text",
+	Names:        []string{"totext", "striptags", "plain"},
+	Type:         TransformAction,
+	InputFormat:  htmlFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(htmlText(in.(*HTML).Doc)), nil
+	},
+}
+
+var htmlLinksAction = Action{
+	Doc:          "Links targets, from a and area elements",
+	Names:        []string{"links", "hrefs"},
+	Type:         TransformAction,
+	InputFormat:  htmlFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return htmlAttrs(in.(*HTML).Doc, "href", atom.A, atom.Area), nil
+	},
+}
+
+var htmlImagesAction = Action{
+	Doc:          "Images sources, from img and source elements",
+	Names:        []string{"images", "imgs"},
+	Type:         TransformAction,
+	InputFormat:  htmlFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return htmlAttrs(in.(*HTML).Doc, "src", atom.Img, atom.Source), nil
+	},
+}
+
+var htmlTableAction = Action{
+	Doc:          "Convert a table element to a table, th cells of the first row are the header",
+	Names:        []string{"table"},
+	Type:         TransformAction,
+	InputFormat:  htmlFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{{Name: "index", Doc: "1-based index of the table in the document", Default: "1"}},
+	Func: func(in any, args ...string) (any, error) {
+		i, err := strconv.Atoi(args[0])
+		if err != nil || i < 1 {
+			return nil, fmt.Errorf("invalid table index %q", args[0])
+		}
+		tables := htmlElements(in.(*HTML).Doc, atom.Table)
+		if len(tables) < i {
+			return nil, fmt.Errorf("found %d tables", len(tables))
+		}
+		return htmlTable(tables[i-1]), nil
+	},
+}
+
+var htmlSelectAction = Action{
+	Doc:          "Keep the elements matching a CSS selector",
+	Names:        []string{"select", "css", "query"},
+	Type:         TransformAction,
+	InputFormat:  htmlFormat,
+	OutputFormat: htmlFormat,
+	Params:       []Param{{Name: "selector", Doc: "CSS selector, e.g. div.content > a[href]"}},
+	Func: func(in any, args ...string) (any, error) {
+		sel, err := cascadia.Parse(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+		doc := &html.Node{Type: html.DocumentNode}
+		for _, n := range cascadia.QueryAll(in.(*HTML).Doc, sel) {
+			doc.AppendChild(cloneHTML(n))
+		}
+		return &HTML{Doc: doc}, nil
+	},
+}
+
+var htmlEscapeAction = Action{
+	Doc:          "Encode <, >, &, ' and \" as HTML entities",
+	Names:        []string{"htmlescape", "htmlencode"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(html.EscapeString(string(in.([]byte)))), nil
+	},
+}
+
+var htmlUnescapeAction = Action{
+	Doc:          "Decode HTML entities, named and numeric",
+	Names:        []string{"htmlunescape", "htmldecode"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(html.UnescapeString(string(in.([]byte)))), nil
+	},
+}
+
+func (h *HTML) String() string {
+	var buf bytes.Buffer
+	for c := h.Doc.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return err.Error()
+		}
+	}
+	return buf.String()
+}
+
+// parseHTML parses a full document, or a fragment in a body when there is no html or body tag
+func parseHTML(b []byte) (*HTML, error) {
+	lower := bytes.ToLower(b)
+	if bytes.Contains(lower, []byte("<html")) || bytes.Contains(lower, []byte("<body")) ||
+		bytes.Contains(lower, []byte("<!doctype")) {
+		doc, err := html.Parse(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return &HTML{Doc: doc}, nil
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(bytes.NewReader(b), body)
+	if err != nil {
+		return nil, err
+	}
+	doc := &html.Node{Type: html.DocumentNode}
+	hasElement := false
+	for _, n := range nodes {
+		hasElement = hasElement || n.Type == html.ElementNode
+		doc.AppendChild(n)
+	}
+	if !hasElement {
+		return nil, errors.New("no HTML element found")
+	}
+	return &HTML{Doc: doc}, nil
+}
+
+// cloneHTML deep copies n, detached from its parent
+func cloneHTML(n *html.Node) *html.Node {
+	c := &html.Node{
+		Type: n.Type, DataAtom: n.DataAtom, Data: n.Data, Namespace: n.Namespace,
+		Attr: append([]html.Attribute(nil), n.Attr...),
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.AppendChild(cloneHTML(child))
+	}
+	return c
+}
+
+// htmlElements returns the elements of type a under n, in document order
+func htmlElements(n *html.Node, a ...atom.Atom) []*html.Node {
+	var l []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && slices.Contains(a, c.DataAtom) {
+				l = append(l, c)
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+	return l
+}
+
+// htmlAttrs returns the non empty values of the attribute key of the elements a
+func htmlAttrs(n *html.Node, key string, a ...atom.Atom) []string {
+	var l []string
+	for _, e := range htmlElements(n, a...) {
+		if v := htmlAttr(e, key); v != "" {
+			l = append(l, v)
+		}
+	}
+	return l
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// htmlText returns the readable text of n, blocks on their own lines and list items prefixed by a dash
+func htmlText(n *html.Node) string {
+	var buf bytes.Buffer
+	// space separates words, never at the start of a line
+	space := func() {
+		if b := buf.Bytes(); len(b) > 0 && b[len(b)-1] != ' ' && b[len(b)-1] != '\n' {
+			buf.WriteByte(' ')
+		}
+	}
+	// breakLines ends the current text with count line breaks, blocks in blocks do not add up
+	breakLines := func(count int) {
+		b := bytes.TrimRight(buf.Bytes(), " ")
+		buf.Truncate(len(b))
+		if len(b) == 0 {
+			return
+		}
+		for i := len(b) - 1; i >= 0 && b[i] == '\n' && count > 0; i-- {
+			count--
+		}
+		buf.WriteString(strings.Repeat("\n", count))
+	}
+
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			if pre {
+				buf.WriteString(n.Data)
+				return
+			}
+			words := strings.Fields(n.Data)
+			if len(words) == 0 {
+				if n.Data != "" {
+					space()
+				}
+				return
+			}
+			if strings.TrimLeft(n.Data, " \t\r\n") != n.Data {
+				space()
+			}
+			buf.WriteString(strings.Join(words, " "))
+			if strings.TrimRight(n.Data, " \t\r\n") != n.Data {
+				space()
+			}
+			return
+		case html.ElementNode:
+			if htmlSkipped[n.DataAtom] {
+				return
+			}
+			switch n.DataAtom {
+			case atom.Br:
+				buf.WriteString("\n")
+				return
+			case atom.Td, atom.Th:
+				space()
+			case atom.Img:
+				buf.WriteString(htmlAttr(n, "alt"))
+			}
+		}
+
+		paragraph, block := htmlBlocks[n.DataAtom]
+		block = block && n.Type == html.ElementNode
+		lines := 1
+		if paragraph {
+			lines = 2
+		}
+		if block {
+			breakLines(lines)
+			if n.DataAtom == atom.Li {
+				buf.WriteString("- ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, pre || n.DataAtom == atom.Pre)
+		}
+		if block {
+			breakLines(lines)
+		}
+	}
+	walk(n, false)
+
+	lines := strings.Split(buf.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// maxColspan is the largest colspan honored, as browsers do
+const maxColspan = 1000
+
+// htmlTable converts a table element, cells spanning columns are repeated
+func htmlTable(table *html.Node) *Table {
+	t := &Table{Delimiter: ','}
+	for i, tr := range htmlTableRows(table) {
+		var row []string
+		header := true
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+				continue
+			}
+			header = header && c.DataAtom == atom.Th
+			text := strings.Join(strings.Fields(htmlText(c)), " ")
+			span, err := strconv.Atoi(htmlAttr(c, "colspan"))
+			if err != nil || span < 1 {
+				span = 1
+			}
+			span = min(span, maxColspan)
+			for j := 0; j < span; j++ {
+				row = append(row, text)
+			}
+		}
+		if len(row) == 0 {
+			continue
+		}
+		if i == 0 && header {
+			t.Header = row
+			continue
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// htmlTableRows returns the rows of table, the rows of nested tables are not included
+func htmlTableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	for c := table.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Tr:
+			rows = append(rows, c)
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			for r := c.FirstChild; r != nil; r = r.NextSibling {
+				if r.Type == html.ElementNode && r.DataAtom == atom.Tr {
+					rows = append(rows, r)
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// isInlineHTML returns true if the children of n are only text and phrasing elements
+func isInlineHTML(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			if !htmlPhrasing[c.DataAtom] || !isInlineHTML(c) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// writePrettyHTML writes n indented by depth, elements with inline content are kept on one line
+func writePrettyHTML(sb *strings.Builder, n *html.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch n.Type {
+	case html.TextNode:
+		if t := strings.Join(strings.Fields(n.Data), " "); t != "" {
+			sb.WriteString(indent + html.EscapeString(t) + "\n")
+		}
+		return
+	case html.CommentNode:
+		sb.WriteString(indent + "<!--" + n.Data + "-->\n")
+		return
+	case html.DoctypeNode:
+		sb.WriteString("<!DOCTYPE " + n.Data + ">\n")
+		return
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writePrettyHTML(sb, c, depth)
+		}
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if htmlRaw[n.DataAtom] {
+		var buf bytes.Buffer
+		_ = html.Render(&buf, n)
+		sb.WriteString(indent + buf.String() + "\n")
+		return
+	}
+
+	var open strings.Builder
+	open.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		key := a.Key
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + key
+		}
+		open.WriteString(" " + key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	open.WriteString(">")
+
+	if htmlVoids[n.DataAtom] {
+		sb.WriteString(indent + open.String() + "\n")
+		return
+	}
+	closing := "</" + n.Data + ">"
+
+	if n.FirstChild == nil {
+		sb.WriteString(indent + open.String() + closing + "\n")
+		return
+	}
+	if isInlineHTML(n) {
+		var buf bytes.Buffer
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			_ = html.Render(&buf, c)
+		}
+		sb.WriteString(indent + open.String() + strings.Join(strings.Fields(buf.String()), " ") + closing + "\n")
+		return
+	}
+
+	sb.WriteString(indent + open.String() + "\n")
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writePrettyHTML(sb, c, depth+1)
+	}
+	sb.WriteString(indent + closing + "\n")
+}
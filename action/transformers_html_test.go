@@ -0,0 +1,188 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) HTMLAction(action string, in *HTML, args ...string) (any, error) {
+	a, ok := r.m[htmlFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for html input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+const testHTML = `<div class="post"><h1>Title &amp; more</h1>
+<p>Hello <b>world</b>,<br>see <a href="https://example.com/a">this</a> and <a href="/b">that</a>.</p>
+<ul><li>one</li><li>two <i>2</i></li></ul>
+<img src="/img.png" alt="logo"><picture><source src="/img.webp"></picture>
+<table><tr><th>name</th><th>age</th></tr><tr><td>bob</td><td>42</td></tr><tr><td colspan="2">n/a</td></tr></table>
+<pre>  code
+    indented</pre><script>var x = 1 < 2;</script><!-- note --></div>
+<table><tr><td>a</td><td>b</td></tr></table>`
+
+func TestAction_TextHTMLTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(htmlActions...)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"fragment", `<p>a <b>b</b></p><p>c</p>`, `<p>a <b>b</b></p><p>c</p>`, false},
+		{"document", "<!DOCTYPE html><html><head><title>x</title></head><body><p>hi</p></body></html>",
+			"<!DOCTYPE html><html><head><title>x</title></head><body><p>hi</p></body></html>", false},
+		{"body", "<body><p>hi", "<html><head></head><body><p>hi</p></body></html>", false},
+		{"text", "just some text", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",html"].Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*HTML).String())
+		})
+	}
+
+	got, err := r.m[textFormat.Prefix+",htmlescape"].Func([]byte(`<a href="x">Tom & "Jerry"</a>`))
+	require.NoError(t, err)
+	require.Equal(t, `&lt;a href=&#34;x&#34;&gt;Tom &amp; &#34;Jerry&#34;&lt;/a&gt;`, string(got.([]byte)))
+
+	got, err = r.m[textFormat.Prefix+",htmlunescape"].Func([]byte(`&lt;b&gt; caf&eacute; &#233; &#x1F600; &nbsp;&copy;`))
+	require.NoError(t, err)
+	require.Equal(t, "<b> café é 😀  ©", string(got.([]byte)))
+}
+
+func TestAction_HTMLTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(htmlActions...)
+
+	in, err := parseHTML([]byte(testHTML))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		action  string
+		args    []string
+		want    any
+		wantErr bool
+	}{
+		{
+			"text", "totext", nil,
+			[]byte(`Title & more
+
+Hello world,
+see this and that.
+
+- one
+- two 2
+
+logo
+
+name age
+bob 42
+n/a
+
+  code
+    indented
+
+a b`), false,
+		},
+		{
+			"pretty", "pretty", nil,
+			[]byte(`<div class="post">
+  <h1>Title &amp; more</h1>
+  <p>Hello <b>world</b>,<br/>see <a href="https://example.com/a">this</a> and <a href="/b">that</a>.</p>
+  <ul>
+    <li>one</li>
+    <li>two <i>2</i></li>
+  </ul>
+  <img src="/img.png" alt="logo">
+  <picture>
+    <source src="/img.webp">
+  </picture>
+  <table>
+    <tbody>
+      <tr>
+        <th>name</th>
+        <th>age</th>
+      </tr>
+      <tr>
+        <td>bob</td>
+        <td>42</td>
+      </tr>
+      <tr>
+        <td colspan="2">n/a</td>
+      </tr>
+    </tbody>
+  </table>
+  <pre>  code
+    indented</pre>
+  <script>var x = 1 < 2;</script>
+  <!-- note -->
+</div>
+<table>
+  <tbody>
+    <tr>
+      <td>a</td>
+      <td>b</td>
+    </tr>
+  </tbody>
+</table>`), false,
+		},
+		{"links", "links", nil, []string{"https://example.com/a", "/b"}, false},
+		{"images", "images", nil, []string{"/img.png", "/img.webp"}, false},
+		{
+			"table", "table", nil,
+			&Table{Header: []string{"name", "age"}, Rows: [][]string{{"bob", "42"}, {"n/a", "n/a"}}, Delimiter: ','}, false,
+		},
+		{"table without header", "table", []string{"2"}, &Table{Rows: [][]string{{"a", "b"}}, Delimiter: ','}, false},
+		{"table out of range", "table", []string{"3"}, nil, true},
+		{"table invalid", "table", []string{"first"}, nil, true},
+		{"invalid selector", "select", []string{"div["}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.HTMLAction(tt.action, in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := r.HTMLAction("select", in, "ul > li")
+	require.NoError(t, err)
+	require.Equal(t, "<li>one</li><li>two <i>2</i></li>", got.(*HTML).String())
+	// the selection is a copy
+	require.Contains(t, in.String(), "<ul><li>one</li>")
+
+	got, err = r.HTMLAction("select", in, "p a[href^=http]")
+	require.NoError(t, err)
+	links, err := r.HTMLAction("links", got.(*HTML))
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/a"}, links)
+
+	// colspan is capped as browsers do, nested tables keep their rows
+	nested, err := parseHTML([]byte(`<table><tr><td colspan="2000000000">x</td></tr>` +
+		`<tr><td><table><tr><td>inner</td></tr></table></td><td>b</td></tr></table>`))
+	require.NoError(t, err)
+	got, err = r.HTMLAction("table", nested)
+	require.NoError(t, err)
+	rows := got.(*Table).Rows
+	require.Len(t, rows, 2)
+	require.Len(t, rows[0], maxColspan)
+	require.Equal(t, []string{"inner", "b"}, rows[1])
+}
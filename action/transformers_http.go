@@ -0,0 +1,847 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HTTPMessage is an HTTP/1.x request or response, Method is empty for a response.
+// Headers are kept in order as received, Body is decoded from chunked and compressed encodings.
+type HTTPMessage struct {
+	Method     string
+	URL        string
+	Proto      string
+	StatusCode int
+	Status     string
+	Header     []HTTPHeader
+	Body       []byte
+}
+
+// HTTPHeader is a header line
+type HTTPHeader struct {
+	Name  string
+	Value string
+}
+
+// maxHTTPBody caps a decompressed body, a small compressed body can expand a thousand fold
+const maxHTTPBody = 64 << 20
+
+// curlArgFlags are the curl options taking a value ignored when parsing
+var curlArgFlags = map[string]bool{
+	"-o": true, "--output": true, "-m": true, "--max-time": true, "--connect-timeout": true,
+	"--retry": true, "-x": true, "--proxy": true, "--resolve": true, "-w": true, "--write-out": true,
+	"--cacert": true, "--cert": true, "-E": true, "--key": true, "-c": true, "--cookie-jar": true,
+	"-r": true, "--range": true, "--limit-rate": true, "-y": true, "-Y": true,
+}
+
+var httpActions = []Action{
+	parseHTTPAction, parseCurlAction, httpCurlAction, httpGoAction, httpBodyAction,
+	httpHeadersAction, httpCookiesAction, httpURLAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(httpActions...)
+}
+
+var parseHTTPAction = Action{
+	Doc:          "Parse a raw HTTP/1.x request or response, decoding chunked, gzip and deflate bodies",
+	Names:        []string{"http", "rawhttp"},
+	Type:         ParseAction,
+	InputFormat:  binFormat,
+	OutputFormat: httpFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseHTTP(in.([]byte))
+	},
+}
+
+var parseCurlAction = Action{
+	Doc:          "Parse a curl command line, as copied from a browser devtools, to an HTTP request",
+	Names:        []string{"curl"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: httpFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseCurl(string(in.([]byte)))
+	},
+}
+
+var httpCurlAction = Action{
+	Doc:          "Convert a request to a curl command, relative targets use https and the Host header",
+	Names:        []string{"tocurl"},
+	Type:         TransformAction,
+	InputFormat:  httpFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m := in.(*HTTPMessage)
+		if m.Method == "" {
+			return nil, errors.New("only requests can be converted")
+		}
+		return []byte(m.Curl()), nil
+	},
+}
+
+var httpGoAction = Action{
+	Doc:          "Convert a request to a Go net/http snippet",
+	Names:        []string{"togo", "gohttp"},
+	Type:         TransformAction,
+	InputFormat:  httpFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m := in.(*HTTPMessage)
+		if m.Method == "" {
+			return nil, errors.New("only requests can be converted")
+		}
+		return []byte(m.Go()), nil
+	},
+}
+
+var httpBodyAction = Action{
+	Doc:          "Decoded body",
+	Names:        []string{"body"},
+	Type:         TransformAction,
+	InputFormat:  httpFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return in.(*HTTPMessage).Body, nil
+	},
+}
+
+var httpHeadersAction = Action{
+	Doc:          "Headers as a table",
+	Names:        []string{"headers"},
+	Type:         TransformAction,
+	InputFormat:  httpFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := &Table{Header: []string{"name", "value"}, Delimiter: ','}
+		for _, h := range in.(*HTTPMessage).Header {
+			t.Rows = append(t.Rows, []string{h.Name, h.Value})
+		}
+		return t, nil
+	},
+}
+
+var httpCookiesAction = Action{
+	Doc:          "Cookies of a request, or cookies set by a response with their attributes",
+	Names:        []string{"cookies"},
+	Type:         TransformAction,
+	InputFormat:  httpFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m := in.(*HTTPMessage)
+		t := &Table{Header: []string{"name", "value", "attributes"}, Delimiter: ','}
+		if m.Method != "" {
+			for _, c := range (&http.Request{Header: m.HTTPHeader()}).Cookies() {
+				t.Rows = append(t.Rows, []string{c.Name, c.Value, ""})
+			}
+			return t, nil
+		}
+		for _, h := range m.Header {
+			if !strings.EqualFold(h.Name, "Set-Cookie") {
+				continue
+			}
+			name, attrs, _ := strings.Cut(h.Value, ";")
+			name, value, _ := strings.Cut(name, "=")
+			t.Rows = append(t.Rows, []string{strings.TrimSpace(name), strings.TrimSpace(value), strings.TrimSpace(attrs)})
+		}
+		return t, nil
+	},
+}
+
+var httpURLAction = Action{
+	Doc:          "URL of a request",
+	Names:        []string{"url"},
+	Type:         TransformAction,
+	InputFormat:  httpFormat,
+	OutputFormat: urlFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m := in.(*HTTPMessage)
+		if m.Method == "" {
+			return nil, errors.New("a response has no URL")
+		}
+		return url.Parse(m.FullURL())
+	},
+}
+
+// Get returns the first value of the header name
+func (m *HTTPMessage) Get(name string) string {
+	for _, h := range m.Header {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// Set replaces the value of the first header name, the other ones are removed, it is added if missing
+func (m *HTTPMessage) Set(name, value string) {
+	for i, h := range m.Header {
+		if strings.EqualFold(h.Name, name) {
+			m.Header[i].Value = value
+			m.Header = append(m.Header[:i+1], slices.DeleteFunc(m.Header[i+1:], func(h HTTPHeader) bool {
+				return strings.EqualFold(h.Name, name)
+			})...)
+			return
+		}
+	}
+	m.Header = append(m.Header, HTTPHeader{Name: name, Value: value})
+}
+
+// Del removes the headers name
+func (m *HTTPMessage) Del(name string) {
+	m.Header = slices.DeleteFunc(m.Header, func(h HTTPHeader) bool {
+		return strings.EqualFold(h.Name, name)
+	})
+}
+
+// HTTPHeader returns the headers as a net/http header
+func (m *HTTPMessage) HTTPHeader() http.Header {
+	hdr := make(http.Header, len(m.Header))
+	for _, h := range m.Header {
+		hdr.Add(h.Name, h.Value)
+	}
+	return hdr
+}
+
+// FullURL returns the absolute URL of a request, from the Host header when the target is relative
+func (m *HTTPMessage) FullURL() string {
+	if strings.HasPrefix(m.URL, "/") && m.Get("Host") != "" {
+		return "https://" + m.Get("Host") + m.URL
+	}
+	return m.URL
+}
+
+func (m *HTTPMessage) String() string {
+	var sb strings.Builder
+	if m.Method != "" {
+		target := m.URL
+		if u, err := url.Parse(m.URL); err == nil && u.IsAbs() && strings.EqualFold(u.Host, m.Get("Host")) {
+			target = u.RequestURI()
+		}
+		fmt.Fprintf(&sb, "%s %s %s\n", m.Method, target, m.Proto)
+	} else {
+		fmt.Fprintf(&sb, "%s %d %s\n", m.Proto, m.StatusCode, m.Status)
+	}
+	for _, h := range m.Header {
+		sb.WriteString(h.Name + ": " + h.Value + "\n")
+	}
+	if len(m.Body) > 0 {
+		sb.WriteString("\n")
+		sb.Write(m.Body)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Curl returns the request as a curl command line
+func (m *HTTPMessage) Curl() string {
+	parts := []string{"curl " + shellQuote(m.FullURL())}
+	switch {
+	case m.Method == http.MethodHead:
+		parts = append(parts, "-I")
+	case m.Method == http.MethodGet && len(m.Body) == 0, m.Method == http.MethodPost && len(m.Body) > 0:
+	default:
+		parts = append(parts, "-X "+m.Method)
+	}
+	for _, h := range m.skipTransportHeaders() {
+		parts = append(parts, "-H "+shellQuote(h.Name+": "+h.Value))
+	}
+	if len(m.Body) > 0 {
+		parts = append(parts, "--data-raw "+shellQuote(string(m.Body)))
+	}
+	return strings.Join(parts, " \\\n  ")
+}
+
+// Go returns a Go snippet sending the request with net/http
+func (m *HTTPMessage) Go() string {
+	var sb strings.Builder
+	body := "nil"
+	if len(m.Body) > 0 {
+		sb.WriteString("body := strings.NewReader(" + goQuote(m.Body) + ")\n")
+		body = "body"
+	}
+	fmt.Fprintf(&sb, "req, err := http.NewRequest(%q, %q, %s)\n", m.Method, m.FullURL(), body)
+	sb.WriteString("if err != nil {\n\tlog.Fatal(err)\n}\n")
+	seen := map[string]bool{}
+	for _, h := range m.skipTransportHeaders() {
+		key := http.CanonicalHeaderKey(h.Name)
+		f := "Set"
+		if seen[key] {
+			f = "Add"
+		}
+		seen[key] = true
+		fmt.Fprintf(&sb, "req.Header.%s(%q, %q)\n", f, h.Name, h.Value)
+	}
+	sb.WriteString("resp, err := http.DefaultClient.Do(req)\n")
+	sb.WriteString("if err != nil {\n\tlog.Fatal(err)\n}\n")
+	sb.WriteString("defer resp.Body.Close()")
+	return sb.String()
+}
+
+// skipTransportHeaders returns the headers without the ones computed by clients
+func (m *HTTPMessage) skipTransportHeaders() []HTTPHeader {
+	host := ""
+	if u, err := url.Parse(m.FullURL()); err == nil {
+		host = u.Host
+	}
+	var l []HTTPHeader
+	for _, h := range m.Header {
+		switch strings.ToLower(h.Name) {
+		case "content-length", "transfer-encoding", "connection":
+			continue
+		case "host":
+			if strings.EqualFold(h.Value, host) {
+				continue
+			}
+		}
+		l = append(l, h)
+	}
+	return l
+}
+
+// parseHTTP parses a raw message, line endings can be \r\n or \n
+func parseHTTP(b []byte) (*HTTPMessage, error) {
+	r := bufio.NewReader(bytes.NewReader(bytes.TrimLeft(b, " \t\r\n")))
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return nil, errors.New("empty HTTP message")
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	m := &HTTPMessage{}
+	fields := strings.SplitN(line, " ", 3)
+	switch {
+	case strings.HasPrefix(line, "HTTP/") && len(fields) >= 2:
+		m.Proto = fields[0]
+		m.StatusCode, err = strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %q", fields[1])
+		}
+		if len(fields) == 3 {
+			m.Status = fields[2]
+		}
+	case len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/") && isHTTPToken(fields[0]):
+		m.Method, m.URL, m.Proto = fields[0], fields[1], fields[2]
+	default:
+		return nil, fmt.Errorf("not an HTTP request or status line: %q", line)
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		// obsolete line folding continues the previous header
+		if (line[0] == ' ' || line[0] == '\t') && len(m.Header) > 0 {
+			m.Header[len(m.Header)-1].Value += " " + strings.TrimSpace(line)
+		} else {
+			name, value, ok := strings.Cut(line, ":")
+			if !ok || !isHTTPToken(name) {
+				return nil, fmt.Errorf("invalid header line %q", line)
+			}
+			m.Header = append(m.Header, HTTPHeader{Name: name, Value: strings.TrimSpace(value)})
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	body, _ := io.ReadAll(r)
+	decoded := false
+	if te := httpCodings(m.Get("Transfer-Encoding")); len(te) > 0 && te[len(te)-1] == "chunked" {
+		body, err = decodeChunked(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunked body: %w", err)
+		}
+		// chunked is the last transfer coding, the others were applied before
+		body, te = decodeHTTPCodings(body, te[:len(te)-1])
+		setHTTPCodings(m, "Transfer-Encoding", te)
+		decoded = true
+	} else if n, err := strconv.Atoi(m.Get("Content-Length")); err == nil && n >= 0 && n < len(body) {
+		body = body[:n]
+	}
+	if ce := httpCodings(m.Get("Content-Encoding")); len(ce) > 0 {
+		var rest []string
+		body, rest = decodeHTTPCodings(body, ce)
+		if len(rest) < len(ce) {
+			setHTTPCodings(m, "Content-Encoding", rest)
+			decoded = true
+		}
+	}
+	m.Body = body
+
+	// the headers describe the decoded body, a request sent again must not be decoded twice
+	if decoded {
+		m.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	return m, nil
+}
+
+// decodeChunked decodes a chunked body, the chunk size lines can end with \r\n or \n,
+// the chunk data is kept as is, trailers are ignored
+func decodeChunked(b []byte) ([]byte, error) {
+	var out []byte
+	for {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return nil, errors.New("missing chunk size")
+		}
+		size, _, _ := strings.Cut(strings.TrimRight(string(b[:i]), "\r"), ";")
+		n, err := strconv.ParseUint(strings.TrimSpace(size), 16, 63)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk size %q", size)
+		}
+		b = b[i+1:]
+		if n == 0 {
+			return out, nil
+		}
+		if uint64(len(b)) < n {
+			return nil, errors.New("truncated chunk")
+		}
+		out = append(out, b[:n]...)
+		b = b[n:]
+
+		switch {
+		case bytes.HasPrefix(b, []byte("\r\n")):
+			b = b[2:]
+		case bytes.HasPrefix(b, []byte("\n")):
+			b = b[1:]
+		default:
+			return nil, errors.New("missing line ending after chunk data")
+		}
+	}
+}
+
+// httpCodings splits a Transfer-Encoding or Content-Encoding value in lower case codings
+func httpCodings(v string) []string {
+	var codings []string
+	for _, c := range strings.Split(v, ",") {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			codings = append(codings, c)
+		}
+	}
+	return codings
+}
+
+// decodeHTTPCodings undoes the codings, the last applied first,
+// rest are the codings left when one can't be decoded
+func decodeHTTPCodings(b []byte, codings []string) (_ []byte, rest []string) {
+	for i := len(codings) - 1; i >= 0; i-- {
+		d, ok := decodeHTTPBody(b, codings[i])
+		if !ok {
+			return b, codings[:i+1]
+		}
+		b = d
+	}
+	return b, nil
+}
+
+// setHTTPCodings rewrites the header name with the codings left, removing it when empty
+func setHTTPCodings(m *HTTPMessage, name string, codings []string) {
+	if len(codings) == 0 {
+		m.Del(name)
+		return
+	}
+	m.Set(name, strings.Join(codings, ", "))
+}
+
+// decodeHTTPBody decompresses a gzip or deflate coding, ok is false when b is returned as is
+func decodeHTTPBody(b []byte, coding string) (_ []byte, ok bool) {
+	var r io.Reader
+	var err error
+	switch coding {
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(bytes.NewReader(b))
+	case "deflate":
+		// deflate is zlib wrapped, some servers send raw deflate
+		r, err = zlib.NewReader(bytes.NewReader(b))
+		if err != nil {
+			r, err = flate.NewReader(bytes.NewReader(b)), nil
+		}
+	default:
+		return b, false
+	}
+	if err != nil {
+		return b, false
+	}
+	// one byte more than the cap tells a body too large from one of the exact size
+	d, err := io.ReadAll(io.LimitReader(r, maxHTTPBody+1))
+	if err != nil || len(d) > maxHTTPBody {
+		return b, false
+	}
+	return d, true
+}
+
+// parseCurl converts a curl command line to a request
+func parseCurl(s string) (*HTTPMessage, error) {
+	args, err := shellWords(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("not a curl command")
+	}
+
+	m := &HTTPMessage{Proto: "HTTP/1.1"}
+	var data []string
+	get, head := false, false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		value := func() (string, error) {
+			// short options can be followed by their value, like -XPOST
+			if len(arg) > 2 && arg[0] == '-' && arg[1] != '-' {
+				v := arg[2:]
+				arg = arg[:2]
+				return v, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if m.URL != "" {
+				return nil, fmt.Errorf("several URLs: %s and %s", m.URL, arg)
+			}
+			m.URL = arg
+			continue
+		}
+
+		short := arg
+		if len(arg) > 2 && arg[0] == '-' && arg[1] != '-' {
+			short = arg[:2]
+		}
+		switch short {
+		case "-X", "--request":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			m.Method = strings.ToUpper(v)
+		case "-H", "--header":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			name, hv, ok := strings.Cut(v, ":")
+			if !ok {
+				// "Name;" sends an empty header
+				if strings.HasSuffix(v, ";") {
+					m.Header = append(m.Header, HTTPHeader{Name: strings.TrimSuffix(v, ";")})
+				}
+				continue
+			}
+			// "Name:" removes a header curl would send
+			if hv = strings.TrimSpace(hv); hv != "" {
+				m.Header = append(m.Header, HTTPHeader{Name: strings.TrimSpace(name), Value: hv})
+			}
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			if short == "-d" || arg == "--data" || arg == "--data-ascii" {
+				v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+			}
+			data = append(data, v)
+		case "--data-urlencode":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			if name, content, ok := strings.Cut(v, "="); ok {
+				if name == "" {
+					v = url.QueryEscape(content)
+				} else {
+					v = name + "=" + url.QueryEscape(content)
+				}
+			} else {
+				v = url.QueryEscape(v)
+			}
+			data = append(data, v)
+		case "--json":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, v)
+			m.Header = append(m.Header,
+				HTTPHeader{Name: "Content-Type", Value: "application/json"},
+				HTTPHeader{Name: "Accept", Value: "application/json"})
+		case "-b", "--cookie":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			// without = it is a cookie file
+			if strings.Contains(v, "=") {
+				m.Header = append(m.Header, HTTPHeader{Name: "Cookie", Value: v})
+			}
+		case "-u", "--user":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			m.Header = append(m.Header, HTTPHeader{Name: "Authorization", Value: "Basic " + base64.StdEncoding.EncodeToString([]byte(v))})
+		case "-A", "--user-agent":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			m.Header = append(m.Header, HTTPHeader{Name: "User-Agent", Value: v})
+		case "-e", "--referer":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			m.Header = append(m.Header, HTTPHeader{Name: "Referer", Value: v})
+		case "--url":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			m.URL = v
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			head = true
+		case "-F", "--form", "--form-string", "-T", "--upload-file":
+			return nil, fmt.Errorf("%s is not supported", arg)
+		default:
+			if curlArgFlags[short] {
+				if _, err := value(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if m.URL == "" {
+		return nil, errors.New("no URL")
+	}
+	if !strings.Contains(m.URL, "://") {
+		m.URL = "http://" + m.URL
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil {
+		return nil, err
+	}
+	m.Header = append([]HTTPHeader{{Name: "Host", Value: u.Host}}, m.Header...)
+
+	body := strings.Join(data, "&")
+	switch {
+	case get && body != "":
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += body
+		m.URL = u.String()
+	case body != "":
+		m.Body = []byte(body)
+		if m.Get("Content-Type") == "" {
+			m.Header = append(m.Header, HTTPHeader{Name: "Content-Type", Value: "application/x-www-form-urlencoded"})
+		}
+	}
+
+	if m.Method == "" {
+		switch {
+		case head:
+			m.Method = http.MethodHead
+		case len(m.Body) > 0:
+			m.Method = http.MethodPost
+		default:
+			m.Method = http.MethodGet
+		}
+	}
+	return m, nil
+}
+
+// shellWords splits a POSIX shell command line, with quotes, escapes, line continuations and ANSI-C $'...' strings
+func shellWords(s string) ([]string, error) {
+	var words []string
+	var w strings.Builder
+	inWord := false
+	end := func() {
+		if inWord {
+			words = append(words, w.String())
+			w.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			end()
+		case c == '\\':
+			if i+1 < len(s) && s[i+1] == '\r' {
+				i++
+			}
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+				continue
+			}
+			if i+1 < len(s) {
+				i++
+				w.WriteByte(s[i])
+			}
+			inWord = true
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			w.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+			inWord = true
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := ansiCString(&w, s[i+2:])
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				w.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		default:
+			w.WriteByte(c)
+			inWord = true
+		}
+	}
+	end()
+	return words, nil
+}
+
+// ansiCString decodes the content of an ANSI-C $'...' string up to its closing quote, returning the bytes read
+func ansiCString(w *strings.Builder, s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			return i, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return 0, errors.New("unterminated $' string")
+			}
+			i++
+			switch c := s[i]; c {
+			case 'n':
+				w.WriteByte('\n')
+			case 't':
+				w.WriteByte('\t')
+			case 'r':
+				w.WriteByte('\r')
+			case 'a':
+				w.WriteByte('\a')
+			case 'b':
+				w.WriteByte('\b')
+			case 'e', 'E':
+				w.WriteByte(0x1b)
+			case 'f':
+				w.WriteByte('\f')
+			case 'v':
+				w.WriteByte('\v')
+			case 'x', 'u', 'U':
+				size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+				j := i + 1
+				for j < len(s) && j < i+1+size && isHexDigit(s[j]) {
+					j++
+				}
+				v, err := strconv.ParseUint(s[i+1:j], 16, 32)
+				if err != nil {
+					return 0, fmt.Errorf("invalid \\%c escape", c)
+				}
+				if c == 'x' {
+					w.WriteByte(byte(v))
+				} else {
+					w.WriteRune(rune(v))
+				}
+				i = j - 1
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				j := i
+				for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+					j++
+				}
+				v, _ := strconv.ParseUint(s[i:j], 8, 8)
+				w.WriteByte(byte(v))
+				i = j - 1
+			default:
+				// \\, \', \" and unknown escapes are the character itself
+				w.WriteByte(c)
+			}
+		default:
+			w.WriteByte(s[i])
+		}
+	}
+	return 0, errors.New("unterminated $' string")
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// isHTTPToken returns true for a non empty method or header name
+func isHTTPToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// shellQuote quotes s for a POSIX shell, using ANSI-C quoting when s has control characters
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	if strings.ContainsAny(s, "\n\r\t") {
+		r := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+		return "$'" + r.Replace(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// goQuote quotes b as a Go string, a raw string when it is readable
+func goQuote(b []byte) string {
+	s := string(b)
+	if utf8.ValidString(s) && !strings.ContainsAny(s, "`\r") && strings.Contains(s, `"`) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,270 @@
+package action
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) HTTPAction(action string, in *HTTPMessage, args ...string) (any, error) {
+	a, ok := r.m[httpFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for http input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestAction_BinHTTPTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(httpActions...)
+
+	gz := gzipBytes(t, `{"ok":true}`)
+	gzipResponse := append([]byte(fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Encoding: gzip\r\nContent-Length: %d\r\n\r\n", len(gz))), gz...)
+	gzipChunked := append([]byte(fmt.Sprintf("HTTP/1.1 200 OK\r\nTransfer-Encoding: gzip, chunked\r\n\r\n%x\r\n", len(gz))), gz...)
+	gzipChunked = append(gzipChunked, "\r\n0\r\n\r\n"...)
+	// the decompressed body exceeds the cap, it is kept compressed
+	bomb := gzipBytes(t, strings.Repeat("a", maxHTTPBody+1))
+	bombResponse := append([]byte(fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Encoding: gzip\r\nContent-Length: %d\r\n\r\n", len(bomb))), bomb...)
+
+	tests := []struct {
+		name       string
+		in         []byte
+		wantMethod string
+		wantURL    string
+		wantStatus int
+		wantHeader []HTTPHeader
+		wantBody   string
+		wantErr    bool
+	}{
+		{
+			"request", []byte("POST /login?next=%2F HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 13\r\n\r\nuser=bob&pw=x\r\n"),
+			"POST", "/login?next=%2F", 0,
+			[]HTTPHeader{{"Host", "example.com"}, {"Content-Type", "application/x-www-form-urlencoded"}, {"Content-Length", "13"}},
+			"user=bob&pw=x", false,
+		},
+		{
+			"bare newlines and folding", []byte("\nGET / HTTP/1.0\nX-Long: a\n  b\n"),
+			"GET", "/", 0, []HTTPHeader{{"X-Long", "a b"}}, "", false,
+		},
+		{
+			"chunked", []byte("HTTP/1.1 404 Not Found\nTransfer-Encoding: chunked\n\n5\nhello\n7\n, world\n0\n\n"),
+			"", "", 404, []HTTPHeader{{"Content-Length", "12"}}, "hello, world", false,
+		},
+		{
+			// the chunk data holds newlines, only the size lines are framing
+			"chunked newlines", []byte("HTTP/1.1 200 OK\nTransfer-Encoding: chunked\nX-A: 1\n\n6\na\nb\nc\n\n3;ext=1\r\nd\r\n\r\n0\n\n"),
+			"", "", 200, []HTTPHeader{{"X-A", "1"}, {"Content-Length", "9"}}, "a\nb\nc\nd\r\n", false,
+		},
+		{
+			"gzip", gzipResponse, "", "", 200,
+			[]HTTPHeader{{"Content-Length", "11"}}, `{"ok":true}`, false,
+		},
+		{
+			"gzip transfer coding", gzipChunked, "", "", 200,
+			[]HTTPHeader{{"Content-Length", "11"}}, `{"ok":true}`, false,
+		},
+		{
+			"gzip bomb", bombResponse, "", "", 200,
+			[]HTTPHeader{{"Content-Encoding", "gzip"}, {"Content-Length", fmt.Sprint(len(bomb))}}, string(bomb), false,
+		},
+		{"not http", []byte("hello world\n"), "", "", 0, nil, "", true},
+		{"invalid status", []byte("HTTP/1.1 OK\n"), "", "", 0, nil, "", true},
+		{"invalid header", []byte("GET / HTTP/1.1\nno colon\n"), "", "", 0, nil, "", true},
+		{"invalid chunk", []byte("HTTP/1.1 200 OK\nTransfer-Encoding: chunked\n\nzz\n"), "", "", 0, nil, "", true},
+		{"truncated chunk", []byte("HTTP/1.1 200 OK\nTransfer-Encoding: chunked\n\n9\nabc\n"), "", "", 0, nil, "", true},
+		{"empty", []byte("\n"), "", "", 0, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[binFormat.Prefix+",http"].Func(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			m := got.(*HTTPMessage)
+			require.Equal(t, tt.wantMethod, m.Method)
+			require.Equal(t, tt.wantURL, m.URL)
+			require.Equal(t, tt.wantStatus, m.StatusCode)
+			require.Equal(t, tt.wantHeader, m.Header)
+			require.Equal(t, tt.wantBody, string(m.Body))
+		})
+	}
+
+	// a decompressed request is sent again without its content encoding
+	req := append([]byte(fmt.Sprintf("POST /api HTTP/1.1\r\nHost: example.com\r\nContent-Encoding: gzip\r\nContent-Length: %d\r\n\r\n", len(gz))), gz...)
+	got, err := r.m[binFormat.Prefix+",http"].Func(req)
+	require.NoError(t, err)
+	m := got.(*HTTPMessage)
+	require.Equal(t, "POST /api HTTP/1.1\nHost: example.com\nContent-Length: 11\n\n{\"ok\":true}", m.String())
+	for _, action := range []string{"tocurl", "togo"} {
+		code, err := r.HTTPAction(action, m)
+		require.NoError(t, err)
+		require.NotContains(t, string(code.([]byte)), "Content-Encoding")
+		require.Contains(t, string(code.([]byte)), ":true}")
+	}
+}
+
+const testCurl = `curl 'https://api.example.com/v1/items?q=a%20b' \
+  -H 'accept: application/json' \
+  -H 'cookie: sid=abc; theme=dark' \
+  -H $'x-note: it\'s\t\x41é' \
+  --data-raw $'{"name":"bob\\n"}' \
+  --compressed`
+
+func TestAction_TextCurlTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(httpActions...)
+
+	tests := []struct {
+		name       string
+		in         string
+		wantMethod string
+		wantURL    string
+		wantHeader []HTTPHeader
+		wantBody   string
+		wantErr    bool
+	}{
+		{
+			"devtools", testCurl, "POST", "https://api.example.com/v1/items?q=a%20b",
+			[]HTTPHeader{
+				{"Host", "api.example.com"}, {"accept", "application/json"}, {"cookie", "sid=abc; theme=dark"},
+				{"x-note", "it's\tAé"}, {"Content-Type", "application/x-www-form-urlencoded"},
+			},
+			`{"name":"bob\n"}`, false,
+		},
+		{
+			"options", `curl -XPUT -sSL --max-time 3 -u bob:pw -A "agent \"1\"" -b a=1 --json '{}' example.com:8080/x`,
+			"PUT", "http://example.com:8080/x",
+			[]HTTPHeader{
+				{"Host", "example.com:8080"}, {"Authorization", "Basic Ym9iOnB3"}, {"User-Agent", `agent "1"`},
+				{"Cookie", "a=1"}, {"Content-Type", "application/json"}, {"Accept", "application/json"},
+			},
+			"{}", false,
+		},
+		{
+			"get data", `curl -G -d a=1 --data-urlencode 'q=x y' --url https://e.com/s?z=0`, "GET", "https://e.com/s?z=0&a=1&q=x+y",
+			[]HTTPHeader{{"Host", "e.com"}}, "", false,
+		},
+		{"head", "curl -I https://e.com -H 'X-Empty;' -H 'Accept:'", "HEAD", "https://e.com", []HTTPHeader{{"Host", "e.com"}, {"X-Empty", ""}}, "", false},
+		{"form", "curl -F file=@x https://e.com", "", "", nil, "", true},
+		{"no url", "curl -v", "", "", nil, "", true},
+		{"missing value", "curl https://e.com -H", "", "", nil, "", true},
+		{"unterminated", "curl 'https://e.com", "", "", nil, "", true},
+		{"not curl", "wget https://e.com", "", "", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",curl"].Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			m := got.(*HTTPMessage)
+			require.Equal(t, tt.wantMethod, m.Method)
+			require.Equal(t, tt.wantURL, m.URL)
+			require.Equal(t, tt.wantHeader, m.Header)
+			require.Equal(t, tt.wantBody, string(m.Body))
+		})
+	}
+}
+
+func TestAction_HTTPTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(httpActions...)
+
+	req, err := parseCurl(testCurl)
+	require.NoError(t, err)
+	require.Equal(t, `POST /v1/items?q=a%20b HTTP/1.1
+Host: api.example.com
+accept: application/json
+cookie: sid=abc; theme=dark
+x-note: it's	Aé
+Content-Type: application/x-www-form-urlencoded
+
+{"name":"bob\n"}`, req.String())
+
+	got, err := r.HTTPAction("tocurl", req)
+	require.NoError(t, err)
+	require.Equal(t, `curl 'https://api.example.com/v1/items?q=a%20b' \
+  -H 'accept: application/json' \
+  -H 'cookie: sid=abc; theme=dark' \
+  -H $'x-note: it\'s\tAé' \
+  -H 'Content-Type: application/x-www-form-urlencoded' \
+  --data-raw '{"name":"bob\n"}'`, string(got.([]byte)))
+
+	// the command parses back to the same request
+	back, err := parseCurl(string(got.([]byte)))
+	require.NoError(t, err)
+	require.Equal(t, req, back)
+
+	got, err = r.HTTPAction("togo", req)
+	require.NoError(t, err)
+	require.Equal(t, "body := strings.NewReader(`{\"name\":\"bob\\n\"}`)"+`
+req, err := http.NewRequest("POST", "https://api.example.com/v1/items?q=a%20b", body)
+if err != nil {
+	log.Fatal(err)
+}
+req.Header.Set("accept", "application/json")
+req.Header.Set("cookie", "sid=abc; theme=dark")
+req.Header.Set("x-note", "it's\tAé")
+req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+resp, err := http.DefaultClient.Do(req)
+if err != nil {
+	log.Fatal(err)
+}
+defer resp.Body.Close()`, string(got.([]byte)))
+
+	got, err = r.HTTPAction("cookies", req)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"sid", "abc", ""}, {"theme", "dark", ""}}, got.(*Table).Rows)
+
+	got, err = r.HTTPAction("url", req)
+	require.NoError(t, err)
+	require.Equal(t, "a b", got.(*url.URL).Query().Get("q"))
+
+	got, err = r.HTTPAction("body", req)
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{"name":"bob\n"}`), got)
+
+	raw, err := parseHTTP([]byte("DELETE /items/1 HTTP/1.1\nHost: example.com\nConnection: close\nContent-Length: 0\n\n"))
+	require.NoError(t, err)
+	got, err = r.HTTPAction("tocurl", raw)
+	require.NoError(t, err)
+	require.Equal(t, "curl https://example.com/items/1 \\\n  -X DELETE", string(got.([]byte)))
+
+	resp, err := parseHTTP([]byte("HTTP/1.1 302 Found\nLocation: /\nSet-Cookie: sid=xyz; Path=/; HttpOnly\nset-cookie: lang=fr\n\n"))
+	require.NoError(t, err)
+	got, err = r.HTTPAction("cookies", resp)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"sid", "xyz", "Path=/; HttpOnly"}, {"lang", "fr", ""}}, got.(*Table).Rows)
+
+	got, err = r.HTTPAction("headers", resp)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Location", "/"}, got.(*Table).Rows[0])
+	require.Equal(t, "HTTP/1.1 302 Found\nLocation: /\nSet-Cookie: sid=xyz; Path=/; HttpOnly\nset-cookie: lang=fr", resp.String())
+
+	for _, action := range []string{"tocurl", "togo", "url"} {
+		_, err = r.HTTPAction(action, resp)
+		require.Error(t, err)
+	}
+}
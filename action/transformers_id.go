@@ -0,0 +1,481 @@
+package action
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ID is an identifier, Bytes is its binary value
+type ID struct {
+	// Kind is one of uuid, ulid, ksuid, objectid or snowflake
+	Kind  string
+	Bytes []byte
+	// Epoch is the snowflake epoch, a name or milliseconds since 1970
+	Epoch string
+}
+
+const (
+	// ksuidEpoch is the KSUID timestamp origin in seconds
+	ksuidEpoch = 1400000000
+	// ksuidLength is the length of a base62 KSUID
+	ksuidLength = 27
+	// gregorianOffset is the number of 100ns between 1582-10-15 and 1970-01-01
+	gregorianOffset = 0x01B21DD213814000
+)
+
+// snowflakeEpochs are the known snowflake origins, sony counts in 10ms
+var snowflakeEpochs = map[string]time.Time{
+	"twitter": time.UnixMilli(1288834974657).UTC(),
+	"discord": time.UnixMilli(1420070400000).UTC(),
+	"sony":    time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// base32ID is the unpadded lowercase RFC 4648 base32 used for ids
+var base32ID = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var idActions = []Action{
+	parseIDAction, parseUUIDAction, parseSnowflakeAction, newIDAction,
+	idInfoAction, idTimeAction, idToTextAction, idHexAction, idBase64Action, idBase32Action,
+	idToUUIDAction, idToULIDAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(idActions...)
+}
+
+// snowflakeEpochNames are the choices for snowflake epochs, milliseconds are accepted too
+func snowflakeEpochNames() []string {
+	return []string{"twitter", "discord", "sony"}
+}
+
+// idKinds are the choices for generated ids
+func idKinds() []string {
+	return []string{"uuid", "uuidv7", "uuidv1", "ulid", "ksuid", "objectid", "snowflake"}
+}
+
+var parseIDAction = Action{
+	Doc:          "Parse an identifier: UUID, ULID, KSUID, ObjectID or Twitter snowflake",
+	Names:        []string{"id", "identifier"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: idFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseID(string(in.([]byte)))
+	},
+}
+
+var parseUUIDAction = Action{
+	Doc:          "Parse an UUID written as text, hex, base64 or base32",
+	Names:        []string{"uuid"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: idFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseUUID(string(in.([]byte)))
+	},
+}
+
+var parseSnowflakeAction = Action{
+	Doc:          "Parse a snowflake with a Twitter, Discord, Sony or custom epoch",
+	Names:        []string{"snowflake"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: idFormat,
+	Params: []Param{{
+		Name: "epoch", Doc: "twitter, discord, sony or milliseconds since 1970",
+		Default: "twitter", Choices: snowflakeEpochNames,
+	}},
+	Func: func(in any, args ...string) (any, error) {
+		return parseSnowflake(string(in.([]byte)), args[0])
+	},
+}
+
+var newIDAction = Action{
+	Doc:          "Generate a new identifier, ignoring the input",
+	Names:        []string{"newid", "generate"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: idFormat,
+	Params:       []Param{{Name: "kind", Doc: "kind of identifier", Default: "uuid", Choices: idKinds}},
+	Func: func(_ any, args ...string) (any, error) {
+		return newID(args[0], time.Now())
+	},
+}
+
+var idInfoAction = Action{
+	Doc:          "Show the kind, version, timestamp and fields of the identifier",
+	Names:        []string{"info"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return idInfo(in.(*ID))
+	},
+}
+
+var idTimeAction = Action{
+	Doc:          "Extract the timestamp embedded in the identifier",
+	Names:        []string{"time", "timestamp"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t, ok, err := in.(*ID).Time()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("identifier has no timestamp")
+		}
+		return t, nil
+	},
+}
+
+var idToTextAction = Action{
+	Doc:          "Transforms the identifier to its canonical text",
+	Names:        []string{"totext", "string"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(in.(*ID).String()), nil
+	},
+}
+
+var idHexAction = Action{
+	Doc:          "Transforms the identifier bytes to hex",
+	Names:        []string{"tohex", "hex"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(hex.EncodeToString(in.(*ID).Bytes)), nil
+	},
+}
+
+var idBase64Action = Action{
+	Doc:          "Transforms the identifier bytes to unpadded URL base64",
+	Names:        []string{"tobase64", "base64"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(base64.RawURLEncoding.EncodeToString(in.(*ID).Bytes)), nil
+	},
+}
+
+var idBase32Action = Action{
+	Doc:          "Transforms the identifier bytes to unpadded lowercase base32",
+	Names:        []string{"tobase32", "base32"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(strings.ToLower(base32ID.EncodeToString(in.(*ID).Bytes))), nil
+	},
+}
+
+var idToUUIDAction = Action{
+	Doc:          "Reinterpret a 128 bits identifier (ULID) as an UUID",
+	Names:        []string{"touuid"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: idFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return convertID(in.(*ID), "uuid")
+	},
+}
+
+var idToULIDAction = Action{
+	Doc:          "Reinterpret a 128 bits identifier (UUID) as an ULID",
+	Names:        []string{"toulid"},
+	Type:         TransformAction,
+	InputFormat:  idFormat,
+	OutputFormat: idFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return convertID(in.(*ID), "ulid")
+	},
+}
+
+func (id *ID) String() string {
+	switch id.Kind {
+	case "uuid":
+		return uuid.UUID(id.Bytes).String()
+	case "ulid":
+		return ulid.ULID(id.Bytes).String()
+	case "ksuid":
+		return encodeBase62(id.Bytes, ksuidLength)
+	case "snowflake":
+		return strconv.FormatUint(binary.BigEndian.Uint64(id.Bytes), 10)
+	default:
+		return hex.EncodeToString(id.Bytes)
+	}
+}
+
+// Time returns the embedded timestamp, ok is false if the identifier has none
+func (id *ID) Time() (t time.Time, ok bool, err error) {
+	switch id.Kind {
+	case "uuid":
+		return uuidTime(id.Bytes)
+	case "ulid":
+		return ulid.Time(ulid.ULID(id.Bytes).Time()).UTC(), true, nil
+	case "ksuid":
+		return time.Unix(int64(binary.BigEndian.Uint32(id.Bytes))+ksuidEpoch, 0).UTC(), true, nil
+	case "objectid":
+		return time.Unix(int64(binary.BigEndian.Uint32(id.Bytes)), 0).UTC(), true, nil
+	case "snowflake":
+		epoch, err := snowflakeEpoch(id.Epoch)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		v := binary.BigEndian.Uint64(id.Bytes)
+		if id.Epoch == "sony" {
+			return epoch.Add(time.Duration(v>>24) * 10 * time.Millisecond), true, nil
+		}
+		return epoch.Add(time.Duration(v>>22) * time.Millisecond), true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+func parseID(s string) (*ID, error) {
+	s = strings.TrimSpace(s)
+
+	if u, err := uuid.Parse(s); err == nil {
+		return &ID{Kind: "uuid", Bytes: u[:]}, nil
+	}
+	if len(s) == 24 {
+		if oid, err := primitive.ObjectIDFromHex(s); err == nil {
+			return &ID{Kind: "objectid", Bytes: oid[:]}, nil
+		}
+	}
+	if len(s) == ulid.EncodedSize {
+		if u, err := ulid.ParseStrict(s); err == nil {
+			return &ID{Kind: "ulid", Bytes: u[:]}, nil
+		}
+	}
+	if len(s) == ksuidLength {
+		if b, err := decodeBase62(s, 20); err == nil {
+			return &ID{Kind: "ksuid", Bytes: b}, nil
+		}
+	}
+	if id, err := parseSnowflake(s, "twitter"); err == nil {
+		return id, nil
+	}
+	if id, err := parseUUID(s); err == nil {
+		return id, nil
+	}
+	return nil, fmt.Errorf("unknown identifier %q", s)
+}
+
+// parseUUID parses an UUID as text or as base64 or base32 of its 16 bytes
+func parseUUID(s string) (*ID, error) {
+	s = strings.TrimSpace(s)
+	if u, err := uuid.Parse(s); err == nil {
+		return &ID{Kind: "uuid", Bytes: u[:]}, nil
+	}
+
+	var b []byte
+	var err error
+	switch trimmed := strings.TrimRight(s, "="); len(trimmed) {
+	case 22:
+		b, err = base64.RawURLEncoding.DecodeString(strings.NewReplacer("+", "-", "/", "_").Replace(trimmed))
+	case 26:
+		b, err = base32ID.DecodeString(strings.ToUpper(trimmed))
+	default:
+		return nil, fmt.Errorf("invalid UUID %q", s)
+	}
+	if err != nil || len(b) != 16 {
+		return nil, fmt.Errorf("invalid UUID %q", s)
+	}
+	return &ID{Kind: "uuid", Bytes: b}, nil
+}
+
+func parseSnowflake(s, epoch string) (*ID, error) {
+	if _, err := snowflakeEpoch(epoch); err != nil {
+		return nil, err
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snowflake %q", s)
+	}
+	return &ID{Kind: "snowflake", Bytes: binary.BigEndian.AppendUint64(nil, v), Epoch: strings.ToLower(epoch)}, nil
+}
+
+// snowflakeEpoch returns the origin of a named epoch or of milliseconds since 1970
+func snowflakeEpoch(s string) (time.Time, error) {
+	if t, ok := snowflakeEpochs[strings.ToLower(s)]; ok {
+		return t, nil
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown snowflake epoch %q", s)
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+func newID(kind string, now time.Time) (*ID, error) {
+	switch kind {
+	case "uuid", "uuidv4":
+		u, err := uuid.NewRandom()
+		return &ID{Kind: "uuid", Bytes: u[:]}, err
+	case "uuidv7":
+		u, err := uuid.NewV7()
+		return &ID{Kind: "uuid", Bytes: u[:]}, err
+	case "uuidv1":
+		u, err := uuid.NewUUID()
+		return &ID{Kind: "uuid", Bytes: u[:]}, err
+	case "ulid":
+		u, err := ulid.New(ulid.Timestamp(now), rand.Reader)
+		return &ID{Kind: "ulid", Bytes: u[:]}, err
+	case "ksuid":
+		b := make([]byte, 20)
+		binary.BigEndian.PutUint32(b, uint32(now.Unix()-ksuidEpoch))
+		_, err := rand.Read(b[4:])
+		return &ID{Kind: "ksuid", Bytes: b}, err
+	case "objectid":
+		oid := primitive.NewObjectIDFromTimestamp(now)
+		return &ID{Kind: "objectid", Bytes: oid[:]}, nil
+	case "snowflake":
+		ms := uint64(now.Sub(snowflakeEpochs["twitter"]).Milliseconds())
+		v := ms<<22 | uint64(mrand.Intn(1<<22))
+		return &ID{Kind: "snowflake", Bytes: binary.BigEndian.AppendUint64(nil, v), Epoch: "twitter"}, nil
+	}
+	return nil, fmt.Errorf("unknown identifier kind %q", kind)
+}
+
+func convertID(id *ID, kind string) (*ID, error) {
+	if len(id.Bytes) != 16 {
+		return nil, fmt.Errorf("%s is not 128 bits", id.Kind)
+	}
+	return &ID{Kind: kind, Bytes: id.Bytes}, nil
+}
+
+func idInfo(id *ID) (*Table, error) {
+	t := &Table{Header: []string{"property", "value"}, Delimiter: ','}
+	add := func(k, v string) { t.Rows = append(t.Rows, []string{k, v}) }
+
+	add("kind", id.Kind)
+	b := id.Bytes
+	switch id.Kind {
+	case "uuid":
+		u := uuid.UUID(b)
+		add("version", strconv.Itoa(int(u.Version())))
+		add("variant", u.Variant().String())
+	case "snowflake":
+		add("epoch", id.Epoch)
+	}
+
+	ts, ok, err := id.Time()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		add("time", ts.Format(time.RFC3339Nano))
+	}
+
+	switch id.Kind {
+	case "uuid":
+		if v := uuid.UUID(b).Version(); v == 1 || v == 6 {
+			add("clock sequence", strconv.Itoa(int(binary.BigEndian.Uint16(b[8:10])&0x3fff)))
+			add("node", macAddress(b[10:]))
+		}
+	case "ulid":
+		add("random", hex.EncodeToString(b[6:]))
+	case "ksuid":
+		add("payload", hex.EncodeToString(b[4:]))
+	case "objectid":
+		add("random", hex.EncodeToString(b[4:9]))
+		add("counter", strconv.Itoa(int(b[9])<<16|int(b[10])<<8|int(b[11])))
+	case "snowflake":
+		v := binary.BigEndian.Uint64(b)
+		if id.Epoch == "sony" {
+			add("sequence", strconv.FormatUint(v>>16&0xff, 10))
+			add("machine", strconv.FormatUint(v&0xffff, 10))
+			break
+		}
+		add("worker", strconv.FormatUint(v>>12&0x3ff, 10))
+		add("sequence", strconv.FormatUint(v&0xfff, 10))
+	}
+	return t, nil
+}
+
+// uuidTime returns the timestamp of version 1, 6 and 7 UUIDs
+func uuidTime(b []byte) (time.Time, bool, error) {
+	var ts uint64
+	switch uuid.UUID(b).Version() {
+	case 1:
+		ts = uint64(binary.BigEndian.Uint16(b[6:8])&0xfff)<<48 |
+			uint64(binary.BigEndian.Uint16(b[4:6]))<<32 | uint64(binary.BigEndian.Uint32(b[0:4]))
+	case 6:
+		ts = uint64(binary.BigEndian.Uint32(b[0:4]))<<28 |
+			uint64(binary.BigEndian.Uint16(b[4:6]))<<12 | uint64(binary.BigEndian.Uint16(b[6:8])&0xfff)
+	case 7:
+		return time.UnixMilli(int64(binary.BigEndian.Uint64(b[0:8]) >> 16)).UTC(), true, nil
+	default:
+		return time.Time{}, false, nil
+	}
+	// 100ns since the gregorian reform
+	unix := int64(ts) - gregorianOffset
+	return time.Unix(unix/1e7, unix%1e7*100).UTC(), true, nil
+}
+
+// macAddress formats a 48 bits node as a MAC address
+func macAddress(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02x", c)
+	}
+	return strings.Join(parts, ":")
+}
+
+func encodeBase62(b []byte, width int) string {
+	n := new(big.Int).SetBytes(b)
+	base, r := big.NewInt(62), new(big.Int)
+	out := make([]byte, 0, width)
+	for n.Sign() > 0 {
+		n.DivMod(n, base, r)
+		out = append(out, base62Alphabet[r.Int64()])
+	}
+	for len(out) < width {
+		out = append(out, '0')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func decodeBase62(s string, size int) ([]byte, error) {
+	n := new(big.Int)
+	base := big.NewInt(62)
+	for _, r := range s {
+		i := strings.IndexRune(base62Alphabet, r)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base62 character %q", r)
+		}
+		n.Mul(n, base)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	if n.BitLen() > size*8 {
+		return nil, errors.New("base62 value overflows")
+	}
+	return n.FillBytes(make([]byte, size)), nil
+}
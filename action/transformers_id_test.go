@@ -0,0 +1,198 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) IDAction(action string, in *ID, args ...string) (any, error) {
+	a, ok := r.m[idFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for id input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextIDTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(idActions...)
+
+	// RFC 9562 examples
+	rfcTime := time.Date(2022, 2, 22, 19, 22, 22, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		action   string
+		in       string
+		args     []string
+		wantKind string
+		want     string
+		wantTime time.Time
+		wantErr  bool
+	}{
+		{"uuid v1", "id", "C232AB00-9414-11EC-B3C8-9F6BDECED846", nil, "uuid", "c232ab00-9414-11ec-b3c8-9f6bdeced846", rfcTime, false},
+		{"uuid v6", "id", "1EC9414C-232A-6B00-B3C8-9F6BDECED846", nil, "uuid", "1ec9414c-232a-6b00-b3c8-9f6bdeced846", rfcTime, false},
+		{"uuid v7", "id", "017F22E2-79B0-7CC3-98C4-DC0C0C07398F", nil, "uuid", "017f22e2-79b0-7cc3-98c4-dc0c0c07398f", rfcTime, false},
+		{"uuid v4", "id", "{919108f7-52d1-4320-9bac-f847db4148a8}", nil, "uuid", "919108f7-52d1-4320-9bac-f847db4148a8", time.Time{}, false},
+		{"uuid urn", "id", "urn:uuid:919108f7-52d1-4320-9bac-f847db4148a8", nil, "uuid", "919108f7-52d1-4320-9bac-f847db4148a8", time.Time{}, false},
+		{"uuid hex", "id", "919108f752d143209bacf847db4148a8", nil, "uuid", "919108f7-52d1-4320-9bac-f847db4148a8", time.Time{}, false},
+		{"ulid", "id", "01ARZ3NDEKTSV4RRFFQ69G5FAV\n", nil, "ulid", "01ARZ3NDEKTSV4RRFFQ69G5FAV", time.UnixMilli(1469922850259), false},
+		{"ksuid", "id", "0ujtsYcgvSTl8PAuAdqWYSMnLOv", nil, "ksuid", "0ujtsYcgvSTl8PAuAdqWYSMnLOv", time.Date(2017, 10, 10, 4, 0, 47, 0, time.UTC), false},
+		{"objectid", "id", "507f1f77bcf86cd799439011", nil, "objectid", "507f1f77bcf86cd799439011", time.Date(2012, 10, 17, 21, 13, 27, 0, time.UTC), false},
+		{"twitter snowflake", "id", "1212092628029698048", nil, "snowflake", "1212092628029698048", time.UnixMilli(1577820376771), false},
+		{"base64 uuid", "id", "kZEI91LRQyCbrPhH20FIqA", nil, "uuid", "919108f7-52d1-4320-9bac-f847db4148a8", time.Time{}, false},
+		{"unknown", "id", "not an id", nil, "", "", time.Time{}, true},
+		{"uuid base64", "uuid", "kZEI91LRQyCbrPhH20FIqA==", nil, "uuid", "919108f7-52d1-4320-9bac-f847db4148a8", time.Time{}, false},
+		{"uuid base64 std", "uuid", "kZEI91LRQyCbrPhH20FIqA", nil, "uuid", "919108f7-52d1-4320-9bac-f847db4148a8", time.Time{}, false},
+		{"uuid base32", "uuid", "sgiqr52s2fbsbg5m7bd5wqkiva", nil, "uuid", "919108f7-52d1-4320-9bac-f847db4148a8", time.Time{}, false},
+		{"uuid invalid", "uuid", "01ARZ3NDEKTSV4RRFFQ69G5FA", nil, "", "", time.Time{}, true},
+		{"discord", "snowflake", "175928847299117063", []string{"discord"}, "snowflake", "175928847299117063", time.Date(2016, 4, 30, 11, 18, 25, 796000000, time.UTC), false},
+		{"sony", "snowflake", "300079637487550465", []string{"Sony"}, "snowflake", "300079637487550465", time.Date(2020, 5, 2, 3, 43, 21, 960000000, time.UTC), false},
+		{"custom epoch", "snowflake", "4194304", []string{"1000"}, "snowflake", "4194304", time.UnixMilli(1001), false},
+		{"unknown epoch", "snowflake", "4194304", []string{"mastodon"}, "", "", time.Time{}, true},
+		{"invalid snowflake", "snowflake", "-1", nil, "", "", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := r.m[textFormat.Prefix+","+tt.action]
+			require.True(t, ok)
+			got, err := a.Func([]byte(tt.in), a.WithArgs(tt.args...).args()...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			id := got.(*ID)
+			require.Equal(t, tt.wantKind, id.Kind)
+			require.Equal(t, tt.want, id.String())
+
+			ts, err := r.IDAction("time", id)
+			if tt.wantTime.IsZero() {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tt.wantTime.Equal(ts.(time.Time)), "got %v", ts)
+		})
+	}
+}
+
+func TestAction_IDTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(idActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"uuid to ulid", "toulid", "01563e3a-b5d3-d676-4c61-efb99302bd5b", "01ARZ3NDEKTSV4RRFFQ69G5FAV", false},
+		{"ulid to uuid", "touuid", "01ARZ3NDEKTSV4RRFFQ69G5FAV", "01563e3a-b5d3-d676-4c61-efb99302bd5b", false},
+		{"objectid to uuid", "touuid", "507f1f77bcf86cd799439011", "", true},
+		{"base64", "tobase64", "919108f7-52d1-4320-9bac-f847db4148a8", "kZEI91LRQyCbrPhH20FIqA", false},
+		{"base32", "tobase32", "919108f7-52d1-4320-9bac-f847db4148a8", "sgiqr52s2fbsbg5m7bd5wqkiva", false},
+		{"hex", "tohex", "0ujtsYcgvSTl8PAuAdqWYSMnLOv", "0669f7efb5a1cd34b5f99d1154fb6853345c9735", false},
+		{"totext", "totext", "urn:uuid:919108f7-52d1-4320-9bac-f847db4148a8", "919108f7-52d1-4320-9bac-f847db4148a8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := parseID(tt.in)
+			require.NoError(t, err)
+
+			got, err := r.IDAction(tt.action, in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch v := got.(type) {
+			case []byte:
+				require.Equal(t, tt.want, string(v))
+			case *ID:
+				require.Equal(t, tt.want, v.String())
+			}
+		})
+	}
+}
+
+func TestAction_IDInfoTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(idActions...)
+
+	tests := []struct {
+		name string
+		in   *ID
+		want [][]string
+	}{
+		{
+			"uuid v1",
+			&ID{Kind: "uuid", Bytes: []byte{0xc2, 0x32, 0xab, 0x00, 0x94, 0x14, 0x11, 0xec, 0xb3, 0xc8, 0x9f, 0x6b, 0xde, 0xce, 0xd8, 0x46}},
+			[][]string{
+				{"kind", "uuid"}, {"version", "1"}, {"variant", "RFC4122"}, {"time", "2022-02-22T19:22:22Z"},
+				{"clock sequence", "13256"}, {"node", "9f:6b:de:ce:d8:46"},
+			},
+		},
+		{
+			"discord",
+			&ID{Kind: "snowflake", Bytes: []byte{0x02, 0x71, 0x06, 0x5a, 0xc1, 0x02, 0x00, 0x07}, Epoch: "discord"},
+			[][]string{
+				{"kind", "snowflake"}, {"epoch", "discord"}, {"time", "2016-04-30T11:18:25.796Z"},
+				{"worker", "32"}, {"sequence", "7"},
+			},
+		},
+		{
+			"objectid",
+			&ID{Kind: "objectid", Bytes: []byte{0x50, 0x7f, 0x1f, 0x77, 0xbc, 0xf8, 0x6c, 0xd7, 0x99, 0x43, 0x90, 0x11}},
+			[][]string{
+				{"kind", "objectid"}, {"time", "2012-10-17T21:13:27Z"}, {"random", "bcf86cd799"}, {"counter", "4427793"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.IDAction("info", tt.in)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*Table).Rows)
+		})
+	}
+}
+
+func TestAction_NewIDTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(idActions...)
+
+	a := r.m[textFormat.Prefix+",newid"]
+	for _, kind := range idKinds() {
+		t.Run(kind, func(t *testing.T) {
+			got, err := a.Func(nil, kind)
+			require.NoError(t, err)
+			id := got.(*ID)
+
+			// a generated id parses back to itself
+			parsed, err := parseID(id.String())
+			require.NoError(t, err)
+			require.Equal(t, id.Kind, parsed.Kind)
+			require.Equal(t, id.Bytes, parsed.Bytes)
+
+			if kind == "uuid" {
+				return
+			}
+			ts, ok, err := id.Time()
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.WithinDuration(t, time.Now(), ts, 2*time.Second)
+		})
+	}
+
+	_, err := a.Func(nil, "guid")
+	require.Error(t, err)
+}
@@ -0,0 +1,458 @@
+package action
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Image is a decoded image, Image is the first frame of an animation
+type Image struct {
+	Image image.Image
+	// Format is the encoding the image was decoded from: png, jpeg, gif, webp or bmp
+	Format string
+	Frames int
+}
+
+var (
+	// geometryRe matches WxH+X+Y, the offsets are optional
+	geometryRe = regexp.MustCompile(`^(\d+)x(\d+)(?:\+(\d+)\+(\d+))?$`)
+	// sizeRe matches W, WxH, xH or a percentage
+	sizeRe = regexp.MustCompile(`^(?:(\d+)%|(\d*)(?:x(\d*))?)$`)
+)
+
+// maxImagePixels caps the size of the created images, 64 megapixels use 256MB as NRGBA
+const maxImagePixels = 1 << 26
+
+var imageActions = []Action{
+	parseImageAction, imageInfoAction, imageResizeAction, imageCropAction, imageRotateAction,
+	imageEncodeAction, imageDataURIAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(imageActions...)
+}
+
+// imageEncodings are the choices for encoding, webp is decode only
+func imageEncodings() []string {
+	return []string{"png", "jpeg", "gif", "bmp"}
+}
+
+// imageAngles are the choices for rotate, clockwise
+func imageAngles() []string {
+	return []string{"90", "180", "270"}
+}
+
+var parseImageAction = Action{
+	Doc:          "Decode a PNG, JPEG, GIF, WebP or BMP image, raw or as a data URI",
+	Names:        []string{"image", "img"},
+	Type:         ParseAction,
+	InputFormat:  binFormat,
+	OutputFormat: imageFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return decodeImage(in.([]byte))
+	},
+}
+
+var imageInfoAction = Action{
+	Doc:          "Show the format, dimensions, color model and frame count",
+	Names:        []string{"info"},
+	Type:         TransformAction,
+	InputFormat:  imageFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		img := in.(*Image)
+		b := img.Image.Bounds()
+		return &Table{
+			Header: []string{"property", "value"},
+			Rows: [][]string{
+				{"format", img.Format},
+				{"width", strconv.Itoa(b.Dx())},
+				{"height", strconv.Itoa(b.Dy())},
+				{"color model", colorModelName(img.Image)},
+				{"frames", strconv.Itoa(img.Frames)},
+			},
+			Delimiter: ',',
+		}, nil
+	},
+}
+
+var imageResizeAction = Action{
+	Doc:          "Resize the image, the aspect ratio is kept if only one dimension is set",
+	Names:        []string{"resize", "scale"},
+	Type:         TransformAction,
+	InputFormat:  imageFormat,
+	OutputFormat: imageFormat,
+	Params:       []Param{{Name: "size", Doc: "WxH, W, xH or a percentage", Default: "50%"}},
+	Func: func(in any, args ...string) (any, error) {
+		img := in.(*Image)
+		w, h, err := parseImageSize(args[0], img.Image.Bounds())
+		if err != nil {
+			return nil, err
+		}
+		dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img.Image, img.Image.Bounds(), draw.Src, nil)
+		return &Image{Image: dst, Format: img.Format, Frames: 1}, nil
+	},
+}
+
+var imageCropAction = Action{
+	Doc:          "Crop the image to a rectangle",
+	Names:        []string{"crop"},
+	Type:         TransformAction,
+	InputFormat:  imageFormat,
+	OutputFormat: imageFormat,
+	Params:       []Param{{Name: "geometry", Doc: "WxH+X+Y, offsets from the top left corner"}},
+	Func: func(in any, args ...string) (any, error) {
+		img := in.(*Image)
+		m := geometryRe.FindStringSubmatch(strings.TrimSpace(args[0]))
+		if m == nil {
+			return nil, fmt.Errorf("invalid geometry %q, expected WxH+X+Y", args[0])
+		}
+		w, _ := strconv.Atoi(m[1])
+		h, _ := strconv.Atoi(m[2])
+		x, _ := strconv.Atoi(m[3])
+		y, _ := strconv.Atoi(m[4])
+
+		b := img.Image.Bounds()
+		r := image.Rect(x, y, x+w, y+h).Add(b.Min).Intersect(b)
+		if r.Empty() {
+			return nil, errors.New("crop is outside of the image")
+		}
+		dst := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		draw.Draw(dst, dst.Bounds(), img.Image, r.Min, draw.Src)
+		return &Image{Image: dst, Format: img.Format, Frames: 1}, nil
+	},
+}
+
+var imageRotateAction = Action{
+	Doc:          "Rotate the image clockwise",
+	Names:        []string{"rotate"},
+	Type:         TransformAction,
+	InputFormat:  imageFormat,
+	OutputFormat: imageFormat,
+	Params:       []Param{{Name: "angle", Doc: "90, 180 or 270 degrees", Default: "90", Choices: imageAngles}},
+	Func: func(in any, args ...string) (any, error) {
+		img := in.(*Image)
+		dst, err := rotateImage(img.Image, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return &Image{Image: dst, Format: img.Format, Frames: 1}, nil
+	},
+}
+
+var imageEncodeAction = Action{
+	Doc:          "Encode the image to PNG, JPEG, GIF or BMP",
+	Names:        []string{"encode", "convert"},
+	Type:         TransformAction,
+	InputFormat:  imageFormat,
+	OutputFormat: binFormat,
+	Params:       []Param{{Name: "format", Doc: "png, jpeg, gif or bmp", Default: "png", Choices: imageEncodings}},
+	Func: func(in any, args ...string) (any, error) {
+		return encodeImage(in.(*Image).Image, args[0])
+	},
+}
+
+var imageDataURIAction = Action{
+	Doc:          "Encode the image to a base64 data URI",
+	Names:        []string{"datauri", "touri"},
+	Type:         TransformAction,
+	InputFormat:  imageFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "format", Doc: "png, jpeg, gif or bmp", Default: "png", Choices: imageEncodings}},
+	Func: func(in any, args ...string) (any, error) {
+		b, err := encodeImage(in.(*Image).Image, args[0])
+		if err != nil {
+			return nil, err
+		}
+		mime := "image/" + args[0]
+		if args[0] == "jpg" {
+			mime = "image/jpeg"
+		}
+		return []byte("data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(b)), nil
+	},
+}
+
+func (img *Image) String() string {
+	b := img.Image.Bounds()
+	s := fmt.Sprintf("%s image %dx%d", img.Format, b.Dx(), b.Dy())
+	if img.Frames > 1 {
+		s += fmt.Sprintf(", %d frames", img.Frames)
+	}
+	return s
+}
+
+func decodeImage(b []byte) (*Image, error) {
+	if s := bytes.TrimSpace(b); bytes.HasPrefix(s, []byte("data:")) {
+		data, err := decodeDataURI(string(s))
+		if err != nil {
+			return nil, err
+		}
+		b = data
+	}
+
+	// the size is read from the header first, a few bytes can declare a huge image
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImageSize(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+	if format == "gif" {
+		n, err := gifPixels(b)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxImagePixels {
+			return nil, fmt.Errorf("animation too large, the frames exceed %d megapixels", maxImagePixels>>20)
+		}
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	frames := 1
+	if format == "gif" {
+		g, err := gif.DecodeAll(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		frames = len(g.Image)
+	}
+	return &Image{Image: img, Format: format, Frames: frames}, nil
+}
+
+// gifPixels sums the pixels of the frames of a GIF, walking the blocks without decoding them
+func gifPixels(b []byte) (int, error) {
+	errTruncated := errors.New("truncated GIF")
+	if len(b) < 13 {
+		return 0, errTruncated
+	}
+	off := 13
+	// a global color table follows the logical screen descriptor
+	if b[10]&0x80 != 0 {
+		off += 3 << (b[10]&7 + 1)
+	}
+
+	// skipSubBlocks moves off after a sequence of data sub-blocks
+	skipSubBlocks := func() error {
+		for {
+			if off >= len(b) {
+				return errTruncated
+			}
+			n := int(b[off])
+			off += 1 + n
+			if n == 0 {
+				return nil
+			}
+		}
+	}
+
+	var total int
+	for off < len(b) {
+		switch b[off] {
+		case 0x21: // extension, its label then its sub-blocks
+			off += 2
+			if err := skipSubBlocks(); err != nil {
+				return 0, err
+			}
+		case 0x2c: // image descriptor
+			if off+10 > len(b) {
+				return 0, errTruncated
+			}
+			w := int(binary.LittleEndian.Uint16(b[off+5:]))
+			h := int(binary.LittleEndian.Uint16(b[off+7:]))
+			total += w * h
+			flags := b[off+9]
+			off += 10
+			if flags&0x80 != 0 {
+				off += 3 << (flags&7 + 1)
+			}
+			// the LZW minimum code size then the image data
+			off++
+			if err := skipSubBlocks(); err != nil {
+				return 0, err
+			}
+		case 0x3b: // trailer
+			return total, nil
+		default:
+			return 0, fmt.Errorf("invalid GIF block 0x%02x", b[off])
+		}
+	}
+	return total, nil
+}
+
+// decodeDataURI returns the bytes of a data:[<mediatype>][;base64],<data> URI
+func decodeDataURI(s string) ([]byte, error) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(s, "data:"), ",")
+	if !ok {
+		return nil, errors.New("invalid data URI, missing ,")
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		// line breaks are common in pasted data URIs
+		data = strings.Join(strings.Fields(data), "")
+		return base64.StdEncoding.DecodeString(data)
+	}
+	v, err := url.PathUnescape(data)
+	return []byte(v), err
+}
+
+func encodeImage(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg", "jpg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	case "bmp":
+		err = bmp.Encode(&buf, img)
+	default:
+		return nil, fmt.Errorf("unsupported image format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseImageSize returns the target size, keeping the aspect ratio of b when a dimension is missing
+func parseImageSize(s string, b image.Rectangle) (int, int, error) {
+	m := sizeRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || (m[1] == "" && m[2] == "" && m[3] == "") {
+		return 0, 0, fmt.Errorf("invalid size %q, expected WxH, W, xH or a percentage", s)
+	}
+
+	// the values are capped first, the computations can't overflow
+	var values [4]int
+	for i := 1; i < len(m); i++ {
+		if m[i] == "" {
+			continue
+		}
+		v, err := strconv.Atoi(m[i])
+		if err != nil || v > maxImagePixels {
+			return 0, 0, fmt.Errorf("invalid size %q, too large", s)
+		}
+		values[i] = v
+	}
+
+	dx, dy := b.Dx(), b.Dy()
+	var w, h int
+	switch {
+	case m[1] != "":
+		w, h = dx*values[1]/100, dy*values[1]/100
+	case m[2] != "" && m[3] != "":
+		w, h = values[2], values[3]
+	case m[2] != "":
+		w = values[2]
+		h = (dy*w + dx/2) / dx
+	default:
+		h = values[3]
+		w = (dx*h + dy/2) / dy
+	}
+	if w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q, the image would be empty", s)
+	}
+	if err := checkImageSize(w, h); err != nil {
+		return 0, 0, err
+	}
+	return w, h, nil
+}
+
+// checkImageSize returns an error when an image of w by h pixels is too large to be created
+func checkImageSize(w, h int) error {
+	if w > maxImagePixels || h > maxImagePixels || w*h > maxImagePixels {
+		return fmt.Errorf("image of %dx%d too large, the maximum is %d megapixels", w, h, maxImagePixels>>20)
+	}
+	return nil
+}
+
+func rotateImage(src image.Image, angle string) (image.Image, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	var dst *image.NRGBA
+	var at func(x, y int) (int, int)
+	switch strings.TrimSuffix(strings.TrimSpace(angle), "°") {
+	case "90", "-270":
+		dst = image.NewNRGBA(image.Rect(0, 0, h, w))
+		at = func(x, y int) (int, int) { return h - 1 - y, x }
+	case "180", "-180":
+		dst = image.NewNRGBA(image.Rect(0, 0, w, h))
+		at = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case "270", "-90":
+		dst = image.NewNRGBA(image.Rect(0, 0, h, w))
+		at = func(x, y int) (int, int) { return y, w - 1 - x }
+	default:
+		return nil, fmt.Errorf("invalid angle %q, 90, 180 or 270", angle)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := at(x, y)
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst, nil
+}
+
+func colorModelName(img image.Image) string {
+	switch i := img.(type) {
+	case *image.RGBA:
+		return "RGBA"
+	case *image.RGBA64:
+		return "RGBA 16 bits"
+	case *image.NRGBA:
+		return "NRGBA"
+	case *image.NRGBA64:
+		return "NRGBA 16 bits"
+	case *image.Gray:
+		return "gray"
+	case *image.Gray16:
+		return "gray 16 bits"
+	case *image.CMYK:
+		return "CMYK"
+	case *image.YCbCr:
+		return "YCbCr " + subsampleName(i.SubsampleRatio)
+	case *image.NYCbCrA:
+		return "YCbCr with alpha " + subsampleName(i.SubsampleRatio)
+	case *image.Paletted:
+		return fmt.Sprintf("paletted, %d colors", len(i.Palette))
+	}
+	if img.ColorModel() == color.GrayModel {
+		return "gray"
+	}
+	return fmt.Sprintf("%T", img.ColorModel())
+}
+
+// subsampleName returns the usual J:a:b notation, 4:2:0
+func subsampleName(r image.YCbCrSubsampleRatio) string {
+	s := strings.TrimPrefix(r.String(), "YCbCrSubsampleRatio")
+	if len(s) != 3 {
+		return s
+	}
+	return s[:1] + ":" + s[1:2] + ":" + s[2:]
+}
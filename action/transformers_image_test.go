@@ -0,0 +1,219 @@
+package action
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) ImageAction(action string, in *Image, args ...string) (any, error) {
+	a, ok := r.m[imageFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for image input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+var (
+	pxRed   = color.NRGBA{R: 255, A: 255}
+	pxGreen = color.NRGBA{G: 255, A: 255}
+	pxBlue  = color.NRGBA{B: 255, A: 255}
+)
+
+// testImage returns a 4x2 image, red top left pixel, green top right, blue bottom left
+func testImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.SetNRGBA(0, 0, pxRed)
+	img.SetNRGBA(3, 0, pxGreen)
+	img.SetNRGBA(0, 1, pxBlue)
+	return img
+}
+
+func testPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage()))
+	return buf.Bytes()
+}
+
+func testHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func testGIF(t *testing.T, frames int) []byte {
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, 2, 2), palette.Plan9))
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, g))
+	return buf.Bytes()
+}
+
+func TestAction_BinImageTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(imageActions...)
+
+	pngData := testPNG(t)
+	tests := []struct {
+		name    string
+		in      []byte
+		want    string
+		wantErr bool
+	}{
+		{"png", pngData, "png image 4x2", false},
+		{"data uri", []byte("data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData) + "\n"), "png image 4x2", false},
+		{"gif", testGIF(t, 1), "gif image 2x2", false},
+		{"animated gif", testGIF(t, 3), "gif image 2x2, 3 frames", false},
+		{"invalid", []byte("not an image"), "", true},
+		{"invalid data uri", []byte("data:image/png;base64"), "", true},
+		{"invalid base64", []byte("data:image/png;base64,!!"), "", true},
+		// a PNG header declaring 60000x60000 RGBA
+		{"png bomb", testHex(t, "89504e470d0a1a0a0000000d494844520000ea600000ea60080600000080d275420000000c49444154789c6360a03d00000064000186643c350000000049454e44ae426082"), "", true},
+		// two 8000x8000 frames, each one fits but not both
+		{"gif bomb", testHex(t, "474946383961401f401f000000"+strings.Repeat("2c00000000401f401f000200", 2)+"3b"), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[binFormat.Prefix+",image"].Func(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*Image).String())
+		})
+	}
+}
+
+func TestAction_ImageInfoTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(imageActions...)
+
+	in, err := decodeImage(testGIF(t, 2))
+	require.NoError(t, err)
+
+	got, err := r.ImageAction("info", in)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"format", "gif"}, {"width", "2"}, {"height", "2"},
+		{"color model", "paletted, 256 colors"}, {"frames", "2"},
+	}, got.(*Table).Rows)
+}
+
+func TestAction_ImageTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(imageActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"resize default", "resize", nil, "png image 2x1", false},
+		{"resize percent", "resize", []string{"200%"}, "png image 8x4", false},
+		{"resize width", "resize", []string{"8"}, "png image 8x4", false},
+		{"resize height", "resize", []string{"x1"}, "png image 2x1", false},
+		{"resize both", "resize", []string{"3x3"}, "png image 3x3", false},
+		{"resize empty", "resize", []string{"0%"}, "", true},
+		{"resize invalid", "resize", []string{"big"}, "", true},
+		{"resize too large percent", "resize", []string{"5000000%"}, "", true},
+		{"resize too large", "resize", []string{"100000x100000"}, "", true},
+		{"resize too large width", "resize", []string{"99999999999999999999"}, "", true},
+		{"crop", "crop", []string{"2x1+1+1"}, "png image 2x1", false},
+		{"crop no offset", "crop", []string{"2x2"}, "png image 2x2", false},
+		{"crop clipped", "crop", []string{"10x10+2+0"}, "png image 2x2", false},
+		{"crop outside", "crop", []string{"2x2+4+0"}, "", true},
+		{"crop invalid", "crop", []string{"2x"}, "", true},
+		{"rotate", "rotate", nil, "png image 2x4", false},
+		{"rotate 180", "rotate", []string{"180"}, "png image 4x2", false},
+		{"rotate invalid", "rotate", []string{"45"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := decodeImage(testPNG(t))
+			require.NoError(t, err)
+
+			got, err := r.ImageAction(tt.action, in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*Image).String())
+		})
+	}
+}
+
+func TestAction_ImageRotateTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(imageActions...)
+
+	tests := []struct {
+		angle string
+		// positions of the red, green and blue pixels after rotation
+		red, green, blue image.Point
+	}{
+		{"90", image.Pt(1, 0), image.Pt(1, 3), image.Pt(0, 0)},
+		{"180", image.Pt(3, 1), image.Pt(0, 1), image.Pt(3, 0)},
+		{"270", image.Pt(0, 3), image.Pt(0, 0), image.Pt(1, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.angle, func(t *testing.T) {
+			got, err := r.ImageAction("rotate", &Image{Image: testImage(), Format: "png", Frames: 1}, tt.angle)
+			require.NoError(t, err)
+			img := got.(*Image).Image
+			require.Equal(t, color.Color(pxRed), img.At(tt.red.X, tt.red.Y))
+			require.Equal(t, color.Color(pxGreen), img.At(tt.green.X, tt.green.Y))
+			require.Equal(t, color.Color(pxBlue), img.At(tt.blue.X, tt.blue.Y))
+		})
+	}
+}
+
+func TestAction_ImageEncodeTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(imageActions...)
+
+	in := &Image{Image: testImage(), Format: "png", Frames: 1}
+	for _, format := range imageEncodings() {
+		t.Run(format, func(t *testing.T) {
+			got, err := r.ImageAction("encode", in, format)
+			require.NoError(t, err)
+
+			// encoded images decode back to the same format and size
+			img, err := decodeImage(got.([]byte))
+			require.NoError(t, err)
+			require.Equal(t, format+" image 4x2", img.String())
+		})
+	}
+
+	_, err := r.ImageAction("encode", in, "tiff")
+	require.Error(t, err)
+
+	got, err := r.ImageAction("datauri", in, "jpg")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(got.([]byte)), "data:image/jpeg;base64,"))
+
+	img, err := decodeImage(got.([]byte))
+	require.NoError(t, err)
+	require.Equal(t, "jpeg image 4x2", img.String())
+}
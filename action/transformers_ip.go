@@ -0,0 +1,448 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxHosts caps the number of addresses listed by hosts
+const maxHosts = 1 << 16
+
+// IPNet is an IP address, a CIDR or a range of addresses
+type IPNet struct {
+	// Addr is the address as written, the first address of a range
+	Addr netip.Addr
+	// Bits is the prefix length of a CIDR, -1 otherwise
+	Bits int
+	// Last is the last address of a range, invalid otherwise
+	Last netip.Addr
+}
+
+var (
+	cgnatPrefix     = netip.MustParsePrefix("100.64.0.0/10")
+	benchmarkPrefix = netip.MustParsePrefix("198.18.0.0/15")
+	reservedPrefix  = netip.MustParsePrefix("240.0.0.0/4")
+	broadcastAddr   = netip.MustParseAddr("255.255.255.255")
+
+	documentationPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("3fff::/20"),
+	}
+)
+
+var ipActions = []Action{
+	parseIPAction, ipInfoAction, ipHostsAction, ipCountAction, ipContainsAction, ipClassifyAction,
+	ipToIntAction, numberToIPAction, ipMappedAction, ipUnmapAction, textListSummarizeAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(ipActions...)
+}
+
+var parseIPAction = Action{
+	Doc:          "Parse an IPv4 or IPv6 address, a CIDR (10.0.0.0/8) or a range (10.0.0.1-10.0.0.9)",
+	Names:        []string{"ip", "cidr"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: ipFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseIPNet(string(in.([]byte)))
+	},
+}
+
+var ipInfoAction = Action{
+	Doc:          "Show the network, broadcast, mask, prefix length and size",
+	Names:        []string{"info"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		n := in.(*IPNet)
+		first, last := n.First(), n.LastAddr()
+
+		t := &Table{Header: []string{"property", "value"}, Delimiter: ','}
+		add := func(k, v string) { t.Rows = append(t.Rows, []string{k, v}) }
+
+		add("address", n.Addr.String())
+		add("version", "IPv"+strconv.Itoa(ipVersion(n.Addr)))
+		if n.Bits >= 0 {
+			add("network", first.String())
+			if n.Addr.Is4() {
+				add("broadcast", last.String())
+			}
+			add("prefix length", strconv.Itoa(n.Bits))
+			mask := prefixMask(n.Addr.BitLen(), n.Bits)
+			if n.Addr.Is4() {
+				add("netmask", mask.String())
+				add("wildcard", invertAddr(mask).String())
+			} else {
+				add("netmask", mask.StringExpanded())
+			}
+		}
+		add("first", first.String())
+		add("last", last.String())
+		add("size", n.Size().String())
+		add("class", strings.Join(classifyIP(n.Addr), ", "))
+		return t, nil
+	},
+}
+
+var ipHostsAction = Action{
+	Doc:          "List the host addresses, the network and broadcast addresses of IPv4 CIDRs are excluded",
+	Names:        []string{"hosts"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		n := in.(*IPNet)
+		first, last := n.First(), n.LastAddr()
+		if n.Addr.Is4() && n.Bits >= 0 && n.Bits < 31 {
+			first, last = first.Next(), last.Prev()
+		}
+
+		if n.Size().Cmp(big.NewInt(maxHosts+2)) > 0 {
+			return nil, fmt.Errorf("more than %d hosts, use count", maxHosts)
+		}
+
+		var l []string
+		for a := first; a.IsValid() && a.Compare(last) <= 0; a = a.Next() {
+			l = append(l, a.String())
+		}
+		return l, nil
+	},
+}
+
+var ipCountAction = Action{
+	Doc:          "Count the addresses",
+	Names:        []string{"count", "size"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: numberFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return intNumber(in.(*IPNet).Size()), nil
+	},
+}
+
+var ipContainsAction = Action{
+	Doc:          "Check if an address, a CIDR or a range is contained, true or false",
+	Names:        []string{"contains"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "ip", Doc: "address, CIDR or range to check"}},
+	Func: func(in any, args ...string) (any, error) {
+		n := in.(*IPNet)
+		o, err := parseIPNet(args[0])
+		if err != nil {
+			return nil, err
+		}
+		contained := n.Addr.BitLen() == o.Addr.BitLen() &&
+			n.First().Compare(o.First()) <= 0 && n.LastAddr().Compare(o.LastAddr()) >= 0
+		return []byte(strconv.FormatBool(contained)), nil
+	},
+}
+
+var ipClassifyAction = Action{
+	Doc:          "Classify the address: private, loopback, multicast, documentation, public...",
+	Names:        []string{"classify"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(strings.Join(classifyIP(in.(*IPNet).Addr), ", ")), nil
+	},
+}
+
+var ipToIntAction = Action{
+	Doc:          "Transforms the address to an integer",
+	Names:        []string{"tonumber", "toint"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: numberFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return intNumber(new(big.Int).SetBytes(in.(*IPNet).Addr.AsSlice())), nil
+	},
+}
+
+var numberToIPAction = Action{
+	Doc:          "Transforms an integer to an IP address, IPv6 if it does not fit in 32 bits",
+	Names:        []string{"toip"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: ipFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		n := in.(*Number)
+		if n.Int == nil || n.Int.Sign() < 0 || n.Int.BitLen() > 128 {
+			return nil, errors.New("expecting a positive integer of up to 128 bits")
+		}
+		size := 4
+		if n.Int.BitLen() > 32 {
+			size = 16
+		}
+		a, _ := netip.AddrFromSlice(n.Int.FillBytes(make([]byte, size)))
+		return &IPNet{Addr: a, Bits: -1}, nil
+	},
+}
+
+var ipMappedAction = Action{
+	Doc:          "Transforms an IPv4 address to an IPv4-mapped IPv6 address",
+	Names:        []string{"tomapped", "toipv6"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: ipFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		n := in.(*IPNet)
+		if !n.Addr.Is4() {
+			return nil, errors.New("not an IPv4 address")
+		}
+		m := &IPNet{Addr: netip.AddrFrom16(n.Addr.As16()), Bits: -1}
+		if n.Bits >= 0 {
+			m.Bits = n.Bits + 96
+		}
+		if n.Last.IsValid() {
+			m.Last = netip.AddrFrom16(n.Last.As16())
+		}
+		return m, nil
+	},
+}
+
+var ipUnmapAction = Action{
+	Doc:          "Transforms an IPv4-mapped IPv6 address to IPv4",
+	Names:        []string{"unmap", "toipv4"},
+	Type:         TransformAction,
+	InputFormat:  ipFormat,
+	OutputFormat: ipFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		n := in.(*IPNet)
+		if !n.Addr.Is4In6() || (n.Bits >= 0 && n.Bits < 96) || (n.Last.IsValid() && !n.Last.Is4In6()) {
+			return nil, errors.New("not an IPv4-mapped address")
+		}
+		m := &IPNet{Addr: n.Addr.Unmap(), Bits: -1}
+		if n.Bits >= 0 {
+			m.Bits = n.Bits - 96
+		}
+		if n.Last.IsValid() {
+			m.Last = n.Last.Unmap()
+		}
+		return m, nil
+	},
+}
+
+var textListSummarizeAction = Action{
+	Doc:          "Summarize a list of addresses, CIDRs and ranges into the minimal list of CIDRs",
+	Names:        []string{"summarize", "cidrs"},
+	Type:         TransformAction,
+	InputFormat:  textListFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var ranges []*IPNet
+		for _, s := range in.([]string) {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			n, err := parseIPNet(s)
+			if err != nil {
+				return nil, err
+			}
+			ranges = append(ranges, &IPNet{Addr: n.First(), Bits: -1, Last: n.LastAddr()})
+		}
+
+		var l []string
+		for _, r := range mergeIPRanges(ranges) {
+			for _, p := range rangePrefixes(r.Addr, r.Last) {
+				l = append(l, p.String())
+			}
+		}
+		return l, nil
+	},
+}
+
+func (n *IPNet) String() string {
+	switch {
+	case n.Last.IsValid():
+		return n.Addr.String() + "-" + n.Last.String()
+	case n.Bits >= 0:
+		return n.Addr.String() + "/" + strconv.Itoa(n.Bits)
+	default:
+		return n.Addr.String()
+	}
+}
+
+// First returns the first address, the network address of a CIDR
+func (n *IPNet) First() netip.Addr {
+	if n.Bits >= 0 {
+		return netip.PrefixFrom(n.Addr, n.Bits).Masked().Addr()
+	}
+	return n.Addr
+}
+
+// LastAddr returns the last address, the broadcast address of an IPv4 CIDR
+func (n *IPNet) LastAddr() netip.Addr {
+	switch {
+	case n.Last.IsValid():
+		return n.Last
+	case n.Bits >= 0:
+		return orAddr(n.First(), invertAddr(prefixMask(n.Addr.BitLen(), n.Bits)))
+	default:
+		return n.Addr
+	}
+}
+
+// Size returns the number of addresses
+func (n *IPNet) Size() *big.Int {
+	first := new(big.Int).SetBytes(n.First().AsSlice())
+	last := new(big.Int).SetBytes(n.LastAddr().AsSlice())
+	return last.Sub(last, first).Add(last, big.NewInt(1))
+}
+
+// parseIPNet parses an address, a CIDR or a range
+func parseIPNet(s string) (*IPNet, error) {
+	s = strings.TrimSpace(s)
+
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		first, err := netip.ParseAddr(strings.TrimSpace(from))
+		if err != nil {
+			return nil, err
+		}
+		last, err := netip.ParseAddr(strings.TrimSpace(to))
+		if err != nil {
+			return nil, err
+		}
+		if first.BitLen() != last.BitLen() || first.Compare(last) > 0 {
+			return nil, fmt.Errorf("invalid range %q", s)
+		}
+		return &IPNet{Addr: first, Bits: -1, Last: last}, nil
+	}
+
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		return &IPNet{Addr: p.Addr(), Bits: p.Bits()}, nil
+	}
+
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return nil, err
+	}
+	return &IPNet{Addr: a, Bits: -1}, nil
+}
+
+// classifyIP returns the special use categories of a
+func classifyIP(a netip.Addr) []string {
+	var l []string
+	add := func(ok bool, name string) {
+		if ok {
+			l = append(l, name)
+		}
+	}
+
+	if a.Is4In6() {
+		l = append(l, "IPv4-mapped")
+		a = a.Unmap()
+	}
+	add(a.IsUnspecified(), "unspecified")
+	add(a.IsLoopback(), "loopback")
+	add(a.IsPrivate(), "private")
+	add(a.IsLinkLocalUnicast(), "link-local")
+	add(a.IsMulticast(), "multicast")
+	add(cgnatPrefix.Contains(a), "shared (CGNAT)")
+	add(benchmarkPrefix.Contains(a), "benchmarking")
+	for _, p := range documentationPrefixes {
+		add(p.Contains(a), "documentation")
+	}
+	add(a == broadcastAddr, "broadcast")
+	add(a != broadcastAddr && reservedPrefix.Contains(a), "reserved")
+
+	if len(l) == 0 || (len(l) == 1 && l[0] == "IPv4-mapped") {
+		add(a.IsGlobalUnicast(), "public")
+	}
+	return l
+}
+
+// mergeIPRanges sorts and merges overlapping or adjacent ranges
+func mergeIPRanges(ranges []*IPNet) []*IPNet {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Addr.Less(ranges[j].Addr) })
+
+	var merged []*IPNet
+	for _, r := range ranges {
+		if len(merged) > 0 {
+			prev := merged[len(merged)-1]
+			next := prev.Last.Next()
+			if prev.Addr.BitLen() == r.Addr.BitLen() && (!next.IsValid() || r.Addr.Compare(next) <= 0) {
+				if r.Last.Compare(prev.Last) > 0 {
+					prev.Last = r.Last
+				}
+				continue
+			}
+		}
+		c := *r
+		merged = append(merged, &c)
+	}
+	return merged
+}
+
+// rangePrefixes returns the minimal list of prefixes covering first to last
+func rangePrefixes(first, last netip.Addr) []netip.Prefix {
+	var l []netip.Prefix
+	for first.IsValid() && first.Compare(last) <= 0 {
+		bits := first.BitLen()
+		for bits > 0 {
+			p := netip.PrefixFrom(first, bits-1)
+			if p.Masked().Addr() != first || (&IPNet{Addr: first, Bits: bits - 1}).LastAddr().Compare(last) > 0 {
+				break
+			}
+			bits--
+		}
+		p := netip.PrefixFrom(first, bits)
+		l = append(l, p)
+		first = (&IPNet{Addr: first, Bits: bits}).LastAddr().Next()
+	}
+	return l
+}
+
+// prefixMask returns the mask of a prefix length
+func prefixMask(bitLen, bits int) netip.Addr {
+	b := make([]byte, bitLen/8)
+	for i := 0; i < bits; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	a, _ := netip.AddrFromSlice(b)
+	return a
+}
+
+func invertAddr(a netip.Addr) netip.Addr {
+	b := a.AsSlice()
+	for i := range b {
+		b[i] = ^b[i]
+	}
+	na, _ := netip.AddrFromSlice(b)
+	return na
+}
+
+func orAddr(a, o netip.Addr) netip.Addr {
+	b, ob := a.AsSlice(), o.AsSlice()
+	for i := range b {
+		b[i] |= ob[i]
+	}
+	na, _ := netip.AddrFromSlice(b)
+	return na
+}
+
+func ipVersion(a netip.Addr) int {
+	if a.Is4() {
+		return 4
+	}
+	return 6
+}
@@ -0,0 +1,224 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) IPAction(action string, in *IPNet, args ...string) (any, error) {
+	a, ok := r.m[ipFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for ip input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextIPTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(ipActions...)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"ipv4", "192.168.1.10", "192.168.1.10", false},
+		{"ipv6", " 2001:DB8::1\n", "2001:db8::1", false},
+		{"cidr", "192.168.1.10/24", "192.168.1.10/24", false},
+		{"ipv6 cidr", "2001:db8::/32", "2001:db8::/32", false},
+		{"range", "10.0.0.1 - 10.0.0.9", "10.0.0.1-10.0.0.9", false},
+		{"reversed range", "10.0.0.9-10.0.0.1", "", true},
+		{"mixed range", "10.0.0.1-::1", "", true},
+		{"invalid", "300.1.1.1", "", true},
+		{"invalid cidr", "10.0.0.0/33", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",ip"].Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*IPNet).String())
+		})
+	}
+}
+
+func TestAction_IPTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(ipActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		args    []string
+		want    any
+		wantErr bool
+	}{
+		{"hosts", "hosts", "10.0.0.0/30", nil, []string{"10.0.0.1", "10.0.0.2"}, false},
+		{"hosts /31", "hosts", "10.0.0.0/31", nil, []string{"10.0.0.0", "10.0.0.1"}, false},
+		{"hosts range", "hosts", "10.0.0.254-10.0.1.0", nil, []string{"10.0.0.254", "10.0.0.255", "10.0.1.0"}, false},
+		{"hosts ipv6", "hosts", "2001:db8::/127", nil, []string{"2001:db8::", "2001:db8::1"}, false},
+		{"hosts too many", "hosts", "10.0.0.0/8", nil, nil, true},
+		{"count", "count", "10.0.0.0/8", nil, "16777216", false},
+		{"count ipv6", "count", "2001:db8::/32", nil, "79228162514264337593543950336", false},
+		{"count range", "count", "10.0.0.1-10.0.0.9", nil, "9", false},
+		{"contains", "contains", "10.0.0.0/8", []string{"10.1.2.3"}, "true", false},
+		{"contains cidr", "contains", "10.0.0.0/8", []string{"10.1.0.0/16"}, "true", false},
+		{"not contains", "contains", "10.0.0.0/8", []string{"11.0.0.1"}, "false", false},
+		{"contains other version", "contains", "10.0.0.0/8", []string{"::1"}, "false", false},
+		{"contains invalid", "contains", "10.0.0.0/8", nil, nil, true},
+		{"private", "classify", "192.168.1.1", nil, "private", false},
+		{"loopback", "classify", "::1", nil, "loopback", false},
+		{"multicast", "classify", "224.0.0.251", nil, "multicast", false},
+		{"documentation", "classify", "2001:db8::1", nil, "documentation", false},
+		{"cgnat", "classify", "100.64.1.1", nil, "shared (CGNAT)", false},
+		{"mapped", "classify", "::ffff:10.0.0.1", nil, "IPv4-mapped, private", false},
+		{"public", "classify", "8.8.8.8", nil, "public", false},
+		{"toint", "toint", "192.168.1.1", nil, "3232235777", false},
+		{"toint ipv6", "toint", "::1:0", nil, "65536", false},
+		{"mapped", "tomapped", "10.0.0.0/8", nil, "::ffff:10.0.0.0/104", false},
+		{"mapped ipv6", "tomapped", "::1", nil, nil, true},
+		{"unmap", "unmap", "::ffff:10.0.0.1", nil, "10.0.0.1", false},
+		{"unmap ipv6", "unmap", "2001:db8::1", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := parseIPNet(tt.in)
+			require.NoError(t, err)
+
+			got, err := r.IPAction(tt.action, in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch v := got.(type) {
+			case []byte:
+				require.Equal(t, tt.want, string(v))
+			case *Number:
+				require.Equal(t, tt.want, v.String())
+			case *IPNet:
+				require.Equal(t, tt.want, v.String())
+			default:
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAction_IPInfoTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(ipActions...)
+
+	in, err := parseIPNet("192.168.1.10/22")
+	require.NoError(t, err)
+	got, err := r.IPAction("info", in)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"address", "192.168.1.10"},
+		{"version", "IPv4"},
+		{"network", "192.168.0.0"},
+		{"broadcast", "192.168.3.255"},
+		{"prefix length", "22"},
+		{"netmask", "255.255.252.0"},
+		{"wildcard", "0.0.3.255"},
+		{"first", "192.168.0.0"},
+		{"last", "192.168.3.255"},
+		{"size", "1024"},
+		{"class", "private"},
+	}, got.(*Table).Rows)
+
+	in, err = parseIPNet("2001:db8::1/64")
+	require.NoError(t, err)
+	got, err = r.IPAction("info", in)
+	require.NoError(t, err)
+	require.Contains(t, got.(*Table).Rows, []string{"netmask", "ffff:ffff:ffff:ffff:0000:0000:0000:0000"})
+	require.Contains(t, got.(*Table).Rows, []string{"last", "2001:db8::ffff:ffff:ffff:ffff"})
+}
+
+func TestAction_NumberIPTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(ipActions...)
+
+	for _, tt := range []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"3232235777", "192.168.1.1", false},
+		{"4294967296", "::1:0:0", false},
+		{"-1", "", true},
+		{"1.5", "", true},
+	} {
+		n, err := parseNumber(tt.in)
+		require.NoError(t, err)
+		got, err := r.m[numberFormat.Prefix+",toip"].Func(n)
+		if tt.wantErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tt.want, got.(*IPNet).String())
+	}
+}
+
+func TestAction_TextListSummarizeTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(ipActions...)
+
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			"adjacent",
+			[]string{"10.0.0.1", "10.0.0.0", "10.0.0.2", "10.0.0.3"},
+			[]string{"10.0.0.0/30"},
+			false,
+		},
+		{
+			"overlapping",
+			[]string{"10.0.0.0/24", "10.0.0.128/25", "10.0.1.0/24", ""},
+			[]string{"10.0.0.0/23"},
+			false,
+		},
+		{
+			"range",
+			[]string{"10.0.0.1-10.0.0.6"},
+			[]string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"},
+			false,
+		},
+		{
+			"mixed versions",
+			[]string{"2001:db8::1", "10.0.0.1", "2001:db8::"},
+			[]string{"10.0.0.1/32", "2001:db8::/127"},
+			false,
+		},
+		{"full", []string{"0.0.0.0/1", "128.0.0.0/1"}, []string{"0.0.0.0/0"}, false},
+		{"invalid", []string{"10.0.0.1", "nope"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.TextListTextListAction("summarize", tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
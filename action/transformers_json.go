@@ -0,0 +1,115 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var jsonActions = []Action{
+	parseJSONAction, toJSONAction, toJSONCompactAction, jsonKeysAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(jsonActions...)
+}
+
+var parseJSONAction = Action{
+	Doc:          "Parse JSON from input into a JSON tree",
+	Names:        []string{"json"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return decodeJSON(in.([]byte))
+	},
+}
+
+var toJSONAction = Action{
+	Doc:          "Transforms a JSON tree to indented JSON text",
+	Names:        []string{"tojson", "pretty"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return json.MarshalIndent(in, "", "  ")
+	},
+}
+
+var toJSONCompactAction = Action{
+	Doc:          "Transforms a JSON tree to compact JSON text",
+	Names:        []string{"tojsoncompact", "minify"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return json.Marshal(in)
+	},
+}
+
+var jsonKeysAction = Action{
+	Doc:          "List the keys of a JSON object",
+	Names:        []string{"keys"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m, ok := in.(map[string]any)
+		if !ok {
+			return nil, errors.New("not a JSON object")
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys, nil
+	},
+}
+
+// decodeJSON decodes b into a JSON tree, numbers are kept as json.Number to avoid loosing precision
+// syntax errors are reported with their line and column
+func decodeJSON(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		var serr *json.SyntaxError
+		if errors.As(err, &serr) {
+			line, col := lineColumn(b, serr.Offset)
+			return nil, fmt.Errorf("line %d, column %d: %w", line, col, err)
+		}
+		return nil, err
+	}
+
+	if dec.More() {
+		line, col := lineColumn(b, dec.InputOffset())
+		return nil, fmt.Errorf("line %d, column %d: unexpected data after JSON value", line, col)
+	}
+
+	return v, nil
+}
+
+// jsonTree converts any Go value to a JSON tree, using its JSON representation
+func jsonTree(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(b)
+}
+
+// lineColumn returns the 1-based line and column of offset in b
+func lineColumn(b []byte, offset int64) (line, col int) {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+	line = 1 + bytes.Count(b[:offset], []byte("\n"))
+	col = int(offset) - bytes.LastIndexByte(b[:offset], '\n')
+	return line, col
+}
@@ -0,0 +1,128 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) TextJSONAction(action string, in []byte) (any, error) {
+	a, ok := r.m[textFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for text input", action)
+	}
+	return a.Func(in)
+}
+
+func (r *ActionRegistry) JSONTextAction(action string, in any) ([]byte, error) {
+	a, ok := r.m[jsonFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for json input", action)
+	}
+	ab, err := a.Func(in)
+	b, _ := ab.([]byte)
+	return b, err
+}
+
+func (r *ActionRegistry) JSONTextListAction(action string, in any) ([]string, error) {
+	a, ok := r.m[jsonFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for json input", action)
+	}
+	ab, err := a.Func(in)
+	l, _ := ab.([]string)
+	return l, err
+}
+
+func TestAction_TextJSONTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(jsonActions...)
+
+	tests := []struct {
+		action  string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"json", `{"b": [1, 2.5, true], "a": null}`, `{"a":null,"b":[1,2.5,true]}`, false},
+		{"json", `{"id": 1152921504606846977}`, `{"id":1152921504606846977}`, false},
+		{"json", "{\n\"a\": 1,\n\"b\": }", "line 3, column 7: invalid character '}' looking for beginning of value", true},
+		{"json", `{"a": 1} {}`, "line 1, column 10: unexpected data after JSON value", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			got, err := r.TextJSONAction(tt.action, []byte(tt.in))
+			if tt.wantErr {
+				require.EqualError(t, err, tt.want)
+			} else {
+				require.NoError(t, err)
+				b, err := json.Marshal(got)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(b))
+			}
+		})
+	}
+}
+
+func TestAction_JSONTextTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(jsonActions...)
+
+	tests := []struct {
+		action  string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"tojson", `{"a":[1,2]}`, "{\n  \"a\": [\n    1,\n    2\n  ]\n}", false},
+		{"tojsoncompact", "{\"a\":\n [1, 2]}", `{"a":[1,2]}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			v, err := decodeJSON([]byte(tt.in))
+			require.NoError(t, err)
+
+			got, err := r.JSONTextAction(tt.action, v)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestAction_JSONTextListTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(jsonActions...)
+
+	tests := []struct {
+		action  string
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"keys", `{"b":1,"a":2}`, []string{"a", "b"}, false},
+		{"keys", `[1,2]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			v, err := decodeJSON([]byte(tt.in))
+			require.NoError(t, err)
+
+			got, err := r.JSONTextListAction(tt.action, v)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package action
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT is a decoded JSON Web Token, header and claims are JSON trees
+type JWT struct {
+	Header    map[string]any
+	Claims    map[string]any
+	Signature []byte
+	Raw       string
+}
+
+// jwtTimeClaims are the registered claims holding a NumericDate
+var jwtTimeClaims = []string{"iat", "nbf", "exp"}
+
+var jwtActions = []Action{
+	parseJWTAction, jwtHeaderAction, jwtClaimsAction, jwtPartsAction, jwtTimesAction, jwtExpiryAction,
+	jwtVerifyAction, jsonSignJWTAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(jwtActions...)
+}
+
+// jwtAlgorithms are the choices for signing
+func jwtAlgorithms() []string {
+	return []string{
+		"HS256", "HS384", "HS512", "RS256", "RS384", "RS512",
+		"PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA",
+	}
+}
+
+var parseJWTAction = Action{
+	Doc:          "Parse a JWT, an Authorization Bearer prefix is ignored",
+	Names:        []string{"jwt"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: jwtFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseJWT(string(in.([]byte)))
+	},
+}
+
+var jwtHeaderAction = Action{
+	Doc:          "JOSE header",
+	Names:        []string{"header"},
+	Type:         TransformAction,
+	InputFormat:  jwtFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return in.(*JWT).Header, nil
+	},
+}
+
+var jwtClaimsAction = Action{
+	Doc:          "Claims set",
+	Names:        []string{"claims", "payload"},
+	Type:         TransformAction,
+	InputFormat:  jwtFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return in.(*JWT).Claims, nil
+	},
+}
+
+var jwtPartsAction = Action{
+	Doc:          "Header and claims as they were encoded",
+	Names:        []string{"parts"},
+	Type:         TransformAction,
+	InputFormat:  jwtFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		parts := strings.Split(in.(*JWT).Raw, ".")
+		out := make([]string, 2)
+		for i, p := range parts[:2] {
+			b, err := decodeJWTSegment(p)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = string(b)
+		}
+		return out, nil
+	},
+}
+
+var jwtTimesAction = Action{
+	Doc:          "Issued at, not before and expiration times with their status",
+	Names:        []string{"times", "validity"},
+	Type:         TransformAction,
+	InputFormat:  jwtFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		j := in.(*JWT)
+		now := time.Now()
+		t := &Table{Header: []string{"claim", "time", "status"}, Delimiter: ','}
+		for _, c := range jwtTimeClaims {
+			ts, ok, err := j.Time(c)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				t.Rows = append(t.Rows, []string{c, ts.UTC().Format(time.RFC3339), jwtTimeStatus(c, ts, now)})
+			}
+		}
+		return t, nil
+	},
+}
+
+var jwtExpiryAction = Action{
+	Doc:          "Expiration time",
+	Names:        []string{"expiry", "exp"},
+	Type:         TransformAction,
+	InputFormat:  jwtFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		ts, ok, err := in.(*JWT).Time("exp")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("no exp claim")
+		}
+		return ts, nil
+	},
+}
+
+var jwtVerifyAction = Action{
+	Doc:          "Verify the signature with an HMAC secret, a PEM public key or certificate, or a JWK",
+	Names:        []string{"verify"},
+	Type:         TransformAction,
+	InputFormat:  jwtFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "key", Doc: "HMAC secret or oct JWK, PEM public key or JWK"}},
+	Func: func(in any, args ...string) (any, error) {
+		j := in.(*JWT)
+		alg, _ := j.Header["alg"].(string)
+		if err := j.Verify(args[0]); err != nil {
+			return nil, err
+		}
+
+		msg := "valid " + alg + " signature"
+		if exp, ok, _ := j.Time("exp"); ok {
+			msg += ", " + jwtTimeStatus("exp", exp, time.Now())
+		}
+		return []byte(msg), nil
+	},
+}
+
+var jsonSignJWTAction = Action{
+	Doc:          "Sign the JSON object as JWT claims, with an HMAC secret or a PEM private key",
+	Names:        []string{"sign", "signjwt"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: textFormat,
+	Params: []Param{
+		{Name: "algorithm", Doc: "signing algorithm", Default: "HS256", Choices: jwtAlgorithms},
+		{Name: "key", Doc: "HMAC secret or PEM private key"},
+	},
+	Func: func(in any, args ...string) (any, error) {
+		claims, ok := in.(map[string]any)
+		if !ok {
+			return nil, errors.New("claims must be a JSON object")
+		}
+		m := jwt.GetSigningMethod(args[0])
+		if m == nil || m == jwt.SigningMethodNone {
+			return nil, fmt.Errorf("unsupported algorithm %q", args[0])
+		}
+		key, err := jwtSigningKey(m.Alg(), args[1])
+		if err != nil {
+			return nil, err
+		}
+		s, err := jwt.NewWithClaims(m, jwt.MapClaims(claims)).SignedString(key)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	},
+}
+
+func (j *JWT) String() string {
+	var sb strings.Builder
+	for _, v := range []any{j.Header, j.Claims} {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return j.Raw
+		}
+		sb.Write(b)
+		sb.WriteString("\n")
+	}
+	now := time.Now()
+	for _, c := range jwtTimeClaims {
+		if ts, ok, _ := j.Time(c); ok {
+			fmt.Fprintf(&sb, "%s: %s, %s\n", c, ts.UTC().Format(time.RFC3339), jwtTimeStatus(c, ts, now))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Time returns the NumericDate claim c, ok is false if the claim is absent
+func (j *JWT) Time(c string) (t time.Time, ok bool, err error) {
+	v, ok := j.Claims[c]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, isNumber := v.(json.Number)
+	if !isNumber {
+		return time.Time{}, false, fmt.Errorf("claim %s is not a number", c)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("claim %s: %w", c, err)
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), true, nil
+}
+
+// Verify checks the signature with key, an HMAC secret or an oct JWK for HS algorithms, a PEM key or certificate, or a JWK
+func (j *JWT) Verify(key string) error {
+	alg, _ := j.Header["alg"].(string)
+	m := jwt.GetSigningMethod(alg)
+	if m == nil {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if m == jwt.SigningMethodNone {
+		return errors.New("token is not signed")
+	}
+	if key == "" {
+		return errors.New("a key is required")
+	}
+
+	kid, _ := j.Header["kid"].(string)
+	var k any
+	var err error
+	if strings.HasPrefix(alg, "HS") {
+		k, err = jwtHMACKey(key, kid)
+	} else {
+		k, err = jwtVerifyingKey(key, kid)
+	}
+	if err != nil {
+		return err
+	}
+
+	i := strings.LastIndexByte(j.Raw, '.')
+	if err := m.Verify(j.Raw[:i], j.Signature, k); err != nil {
+		return fmt.Errorf("invalid %s signature: %w", alg, err)
+	}
+	return nil
+}
+
+// jwtTimeStatus describes a time claim relative to now
+func jwtTimeStatus(c string, t, now time.Time) string {
+	switch c {
+	case "exp":
+		if !now.Before(t) {
+			return "expired " + humanDuration(now.Sub(t), 2) + " ago"
+		}
+		return "expires in " + humanDuration(t.Sub(now), 2)
+	case "nbf":
+		if now.Before(t) {
+			return "not valid yet, starts in " + humanDuration(t.Sub(now), 2)
+		}
+		return "valid since " + humanDuration(now.Sub(t), 2)
+	}
+	if now.Before(t) {
+		return "issued in the future, in " + humanDuration(t.Sub(now), 2)
+	}
+	return "issued " + humanDuration(now.Sub(t), 2) + " ago"
+}
+
+func parseJWT(s string) (*JWT, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 7 && strings.EqualFold(s[:7], "bearer ") {
+		s = strings.TrimSpace(s[7:])
+	}
+	parts := strings.Split(s, ".")
+	switch len(parts) {
+	case 3:
+	case 5:
+		return nil, errors.New("encrypted JWT (JWE) are not supported")
+	default:
+		return nil, errors.New("not a valid JWT")
+	}
+
+	j := &JWT{Raw: s}
+	for i, dst := range []*map[string]any{&j.Header, &j.Claims} {
+		b, err := decodeJWTSegment(parts[i])
+		if err != nil {
+			return nil, err
+		}
+		v, err := decodeJSON(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON in part %d of the JWT: %w", i+1, err)
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("part %d of the JWT is not a JSON object", i+1)
+		}
+		*dst = m
+	}
+
+	sig, err := decodeJWTSegment(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	j.Signature = sig
+	return j, nil
+}
+
+// decodeJWTSegment decodes base64url without padding, tolerating padding and the standard alphabet
+func decodeJWTSegment(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.NewReplacer("+", "-", "/", "_").Replace(s), "=")
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode base64 part of the JWT: %w", err)
+	}
+	return b, nil
+}
+
+// jwtVerifyingKey parses a PEM public key or certificate, a JWK or a JWK set, kid selects the key in a set
+func jwtVerifyingKey(key, kid string) (crypto.PublicKey, error) {
+	if strings.HasPrefix(strings.TrimSpace(key), "{") {
+		return parseJWK(key, kid)
+	}
+
+	for _, b := range pemBlocks([]byte(key)) {
+		switch b.Type {
+		case "PUBLIC KEY":
+			return x509.ParsePKIXPublicKey(b.Bytes)
+		case "RSA PUBLIC KEY":
+			return x509.ParsePKCS1PublicKey(b.Bytes)
+		case "CERTIFICATE":
+			c, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return c.PublicKey, nil
+		}
+	}
+	return nil, errors.New("no public key, certificate or JWK found")
+}
+
+// jwtHMACKey returns the secret, a public key used as a secret would accept a token
+// whose alg was changed to HS256 and signed with the public key
+func jwtHMACKey(key, kid string) ([]byte, error) {
+	if strings.Contains(key, "-----BEGIN") {
+		return nil, errors.New("a PEM key is not an HMAC secret, the token alg may have been tampered")
+	}
+	if k, err := findJWK(key, kid); err == nil {
+		if k.Kty != "oct" {
+			return nil, fmt.Errorf("a %s JWK is not an HMAC secret, the token alg may have been tampered", k.Kty)
+		}
+		return k.field("k")
+	}
+	return []byte(key), nil
+}
+
+// jwtSigningKey returns the HMAC secret or parses a PEM private key
+func jwtSigningKey(alg, key string) (any, error) {
+	if key == "" {
+		return nil, errors.New("a key is required")
+	}
+	if strings.HasPrefix(alg, "HS") {
+		return []byte(key), nil
+	}
+
+	for _, b := range pemBlocks([]byte(key)) {
+		switch b.Type {
+		case "PRIVATE KEY":
+			return x509.ParsePKCS8PrivateKey(b.Bytes)
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(b.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(b.Bytes)
+		}
+	}
+	return nil, errors.New("no PEM private key found")
+}
+
+// jwk is a JSON Web Key, the other members like x5c and key_ops are ignored
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// field returns the base64url decoded member name
+func (k *jwk) field(name string) ([]byte, error) {
+	v := map[string]string{"n": k.N, "e": k.E, "x": k.X, "y": k.Y, "k": k.K}[name]
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(v, "="))
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid JWK %s", name)
+	}
+	return b, nil
+}
+
+// findJWK parses a JWK or a JWK set, returning the key kid of the set or the first one
+func findJWK(s, kid string) (*jwk, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(s), &set); err != nil || len(set.Keys) == 0 {
+		var k jwk
+		if err := json.Unmarshal([]byte(s), &k); err != nil {
+			return nil, fmt.Errorf("invalid JWK: %w", err)
+		}
+		set.Keys = append(set.Keys[:0], k)
+	}
+
+	k := &set.Keys[0]
+	for i := range set.Keys {
+		if kid != "" && set.Keys[i].Kid == kid {
+			k = &set.Keys[i]
+			break
+		}
+	}
+	if k.Kty == "" {
+		return nil, errors.New("invalid JWK: missing kty")
+	}
+	return k, nil
+}
+
+// parseJWK parses the public part of an RSA, EC or Ed25519 JWK
+func parseJWK(s, kid string) (crypto.PublicKey, error) {
+	k, err := findJWK(s, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := k.field("n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := k.field("e")
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported JWK curve %q", k.Crv)
+		}
+		x, err := k.field("x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := k.field("y")
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported JWK curve %q", k.Crv)
+		}
+		x, err := k.field("x")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid JWK x")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported JWK key type %q", k.Kty)
+}
@@ -0,0 +1,554 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Logs are log records parsed from text, one record per line,
+// indented lines are continuations of the previous record (stack traces)
+type Logs struct {
+	Records []*LogRecord
+}
+
+// LogRecord is a parsed log line, Fields are the fields other than the time, level and message
+type LogRecord struct {
+	Time time.Time
+	// Level is normalized to trace, debug, info, warn, error or fatal, empty if unknown
+	Level   string
+	Message string
+	Fields  []LogField
+	Raw     string
+}
+
+type LogField struct {
+	Key, Value string
+}
+
+// logLevels are the normalized levels by increasing severity
+var logLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// log keys holding the time, level and message in JSON and logfmt records
+var (
+	logTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp", "t", "date"}
+	logLevelKeys   = []string{"level", "lvl", "severity", "loglevel", "log.level", "@level"}
+	logMessageKeys = []string{"msg", "message", "@message", "event"}
+)
+
+var (
+	// syslog5424Re matches <PRI>1 TIMESTAMP HOST APP PROCID MSGID SD MSG
+	syslog5424Re = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]"\\]|\\.|"(?:[^"\\]|\\.)*")*\])+)(?: (.*))?$`)
+	// syslog3164Re matches <PRI>Mmm dd hh:mm:ss HOST TAG[PID]: MSG, the priority is optional in files
+	syslog3164Re = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d) (\S+) ([^:\[\s]+)(?:\[(\d+)\])?: ?(.*)$`)
+	// sdParamRe matches a structured data parameter, name="value"
+	sdParamRe = regexp.MustCompile(`([^\s=\]]+)="((?:[^"\\]|\\.)*)"`)
+	// accessLogRe matches the common and combined log formats of Apache and nginx
+	accessLogRe = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\S+)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+	// goLogRe matches the default output of log and log/slog, 2006/01/02 15:04:05 LEVEL msg key=value
+	goLogRe = regexp.MustCompile(`^(\d{4}/\d\d/\d\d \d\d:\d\d:\d\d(?:\.\d+)?) (?:(DEBUG|INFO|WARN|ERROR)(?:[+-]\d+)? )?(.*)$`)
+	// logfmtPairRe matches a key=value pair of logfmt
+	logfmtPairRe = regexp.MustCompile(`(?:^|\s)([^\s="]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+	// levelWordRe finds a level in an unstructured line
+	levelWordRe = regexp.MustCompile(`(?i)\b(trace|debug|info|notice|warn|warning|error|err|fatal|panic|crit|critical)\b`)
+)
+
+var logActions = []Action{
+	parseLogsAction, logLevelAction, logBetweenAction, logFieldsAction, logCountAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(logActions...)
+}
+
+// logKinds are the choices for the parser, auto detects it line by line
+func logKinds() []string {
+	return []string{"auto", "json", "logfmt", "access", "syslog", "go"}
+}
+
+var parseLogsAction = Action{
+	Doc:          "Parse log lines: JSON, logfmt, Apache/nginx access, syslog or Go log/slog",
+	Names:        []string{"logs", "log"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: logFormat,
+	Params:       []Param{{Name: "format", Doc: "auto, json, logfmt, access, syslog or go", Default: "auto", Choices: logKinds}},
+	Func: func(in any, args ...string) (any, error) {
+		return parseLogs(in.([]byte), args[0])
+	},
+}
+
+var logLevelAction = Action{
+	Doc:          "Keep the records at or above a level",
+	Names:        []string{"level", "severity"},
+	Type:         TransformAction,
+	InputFormat:  logFormat,
+	OutputFormat: logFormat,
+	Params: []Param{{
+		Name: "level", Doc: "trace, debug, info, warn, error or fatal", Default: "warn",
+		Choices: func() []string { return logLevels },
+	}},
+	Func: func(in any, args ...string) (any, error) {
+		threshold := levelRank(normalizeLevel(args[0]))
+		if threshold < 0 {
+			return nil, fmt.Errorf("unknown level %q", args[0])
+		}
+		return filterLogs(in.(*Logs), func(r *LogRecord) bool { return levelRank(r.Level) >= threshold }), nil
+	},
+}
+
+var logBetweenAction = Action{
+	Doc:          "Keep the records in a time range",
+	Names:        []string{"between", "timerange"},
+	Type:         TransformAction,
+	InputFormat:  logFormat,
+	OutputFormat: logFormat,
+	Params: []Param{
+		{Name: "from", Doc: "start time or duration relative to now (-1h), empty for no start"},
+		{Name: "to", Doc: "end time or duration relative to now, empty for no end"},
+	},
+	Func: func(in any, args ...string) (any, error) {
+		from, err := parseLogBound(args[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseLogBound(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return filterLogs(in.(*Logs), func(r *LogRecord) bool {
+			if r.Time.IsZero() {
+				return false
+			}
+			return (from.IsZero() || !r.Time.Before(from)) && (to.IsZero() || !r.Time.After(to))
+		}), nil
+	},
+}
+
+var logFieldsAction = Action{
+	Doc:          "Project fields of the records into a table",
+	Names:        []string{"fields", "table"},
+	Type:         TransformAction,
+	InputFormat:  logFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{{Name: "fields", Doc: "comma separated fields, time, level, msg and all the others if empty"}},
+	Func: func(in any, args ...string) (any, error) {
+		l := in.(*Logs)
+		var fields []string
+		for _, f := range strings.Split(args[0], ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) == 0 {
+			fields = l.keys()
+		}
+
+		t := &Table{Header: fields, Delimiter: ','}
+		for _, r := range l.Records {
+			row := make([]string, len(fields))
+			for i, f := range fields {
+				row[i] = r.Get(f)
+			}
+			t.Rows = append(t.Rows, row)
+		}
+		return t, nil
+	},
+}
+
+var logCountAction = Action{
+	Doc:          "Count the records by value of a field",
+	Names:        []string{"count", "countby"},
+	Type:         TransformAction,
+	InputFormat:  logFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{{Name: "field", Doc: "field to group by", Default: "level"}},
+	Func: func(in any, args ...string) (any, error) {
+		field := strings.TrimSpace(args[0])
+		counts := make(map[string]int)
+		for _, r := range in.(*Logs).Records {
+			counts[r.Get(field)]++
+		}
+
+		t := &Table{Header: []string{field, "count"}, Delimiter: ','}
+		for v, c := range counts {
+			t.Rows = append(t.Rows, []string{v, strconv.Itoa(c)})
+		}
+		sort.Slice(t.Rows, func(i, j int) bool {
+			if counts[t.Rows[i][0]] != counts[t.Rows[j][0]] {
+				return counts[t.Rows[i][0]] > counts[t.Rows[j][0]]
+			}
+			return t.Rows[i][0] < t.Rows[j][0]
+		})
+		return t, nil
+	},
+}
+
+func (l *Logs) String() string {
+	lines := make([]string, len(l.Records))
+	for i, r := range l.Records {
+		lines[i] = r.Raw
+	}
+	return strings.Join(lines, "\n")
+}
+
+// keys returns time, level, msg then the other fields in order of appearance
+func (l *Logs) keys() []string {
+	keys := []string{"time", "level", "msg"}
+	seen := map[string]bool{"time": true, "level": true, "msg": true}
+	for _, r := range l.Records {
+		for _, f := range r.Fields {
+			if !seen[f.Key] {
+				seen[f.Key] = true
+				keys = append(keys, f.Key)
+			}
+		}
+	}
+	return keys
+}
+
+// Get returns the value of a field, time, level and msg are the parsed values
+func (r *LogRecord) Get(key string) string {
+	switch key {
+	case "time":
+		if r.Time.IsZero() {
+			return ""
+		}
+		return r.Time.Format(time.RFC3339Nano)
+	case "level":
+		return r.Level
+	case "msg", "message":
+		return r.Message
+	}
+	for _, f := range r.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+func filterLogs(l *Logs, keep func(*LogRecord) bool) *Logs {
+	nl := &Logs{}
+	for _, r := range l.Records {
+		if keep(r) {
+			nl.Records = append(nl.Records, r)
+		}
+	}
+	return nl
+}
+
+func parseLogBound(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(d), nil
+	}
+	return parseTimeText(s)
+}
+
+func parseLogs(b []byte, kind string) (*Logs, error) {
+	var parse func(string) (*LogRecord, bool)
+	switch kind {
+	case "auto", "":
+		parse = parseLogLine
+	case "json":
+		parse = parseJSONLog
+	case "logfmt":
+		parse = parseLogfmtLog
+	case "access":
+		parse = parseAccessLog
+	case "syslog":
+		parse = func(line string) (*LogRecord, bool) {
+			if r, ok := parseSyslog5424(line); ok {
+				return r, true
+			}
+			return parseSyslog3164(line)
+		}
+	case "go":
+		parse = parseGoLog
+	default:
+		return nil, fmt.Errorf("unknown log format %q", kind)
+	}
+
+	l := &Logs{}
+	for _, line := range strings.Split(strings.TrimRight(string(b), "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// indented lines continue the previous record, stack traces
+		if n := len(l.Records); n > 0 && (line[0] == ' ' || line[0] == '\t') {
+			last := l.Records[n-1]
+			last.Raw += "\n" + line
+			last.Message += "\n" + line
+			continue
+		}
+
+		r, ok := parse(line)
+		if !ok {
+			if kind != "auto" && kind != "" {
+				return nil, fmt.Errorf("line %d is not a %s log: %q", len(l.Records)+1, kind, line)
+			}
+			r = &LogRecord{Message: line, Level: guessLevel(line)}
+		}
+		r.Raw = line
+		l.Records = append(l.Records, r)
+	}
+	if len(l.Records) == 0 {
+		return nil, errors.New("no log records")
+	}
+	return l, nil
+}
+
+// parseLogLine detects the format of a line
+func parseLogLine(line string) (*LogRecord, bool) {
+	for _, parse := range []func(string) (*LogRecord, bool){
+		parseJSONLog, parseSyslog5424, parseSyslog3164, parseAccessLog, parseGoLog, parseLogfmtLog,
+	} {
+		if r, ok := parse(line); ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+func parseJSONLog(line string) (*LogRecord, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, false
+	}
+
+	var fields []LogField
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := t.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, false
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			var buf bytes.Buffer
+			_ = json.Compact(&buf, raw)
+			s = buf.String()
+		}
+		fields = append(fields, LogField{key, s})
+	}
+	return newLogRecord(fields), true
+}
+
+func parseLogfmtLog(line string) (*LogRecord, bool) {
+	fields, ok := parseLogfmt(line)
+	if !ok {
+		return nil, false
+	}
+	return newLogRecord(fields), true
+}
+
+// parseLogfmt parses key=value pairs, the line must start with a pair
+func parseLogfmt(s string) ([]LogField, bool) {
+	var fields []LogField
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		m := logfmtPairRe.FindStringSubmatchIndex(rest)
+		// pairs must follow each other
+		if m == nil || strings.TrimSpace(rest[:m[0]]) != "" {
+			return nil, false
+		}
+		key, value := rest[m[2]:m[3]], rest[m[4]:m[5]]
+		if strings.HasPrefix(value, `"`) {
+			v, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, false
+			}
+			value = v
+		}
+		fields = append(fields, LogField{key, value})
+		rest = strings.TrimSpace(rest[m[1]:])
+	}
+	return fields, len(fields) > 0
+}
+
+func parseAccessLog(line string) (*LogRecord, bool) {
+	m := accessLogRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	r := &LogRecord{Message: m[5]}
+	r.Time, _ = parseTimeText(m[4])
+	r.Fields = append(r.Fields, LogField{"remote", m[1]}, LogField{"user", m[3]})
+	if parts := strings.SplitN(m[5], " ", 3); len(parts) == 3 {
+		r.Fields = append(r.Fields, LogField{"method", parts[0]}, LogField{"path", parts[1]}, LogField{"protocol", parts[2]})
+	}
+	r.Fields = append(r.Fields, LogField{"status", m[6]}, LogField{"bytes", m[7]})
+	if m[8] != "" || m[9] != "" {
+		r.Fields = append(r.Fields, LogField{"referer", m[8]}, LogField{"agent", m[9]})
+	}
+
+	switch m[6][0] {
+	case '5':
+		r.Level = "error"
+	case '4':
+		r.Level = "warn"
+	default:
+		r.Level = "info"
+	}
+	return r, true
+}
+
+func parseSyslog5424(line string) (*LogRecord, bool) {
+	m := syslog5424Re.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	r := &LogRecord{Message: strings.TrimPrefix(m[8], "\ufeff")}
+	r.Level, r.Fields = syslogPriority(m[1])
+	r.Time, _ = parseTimeText(m[2])
+	for i, k := range []string{"host", "app", "pid", "msgid"} {
+		if v := m[3+i]; v != "-" {
+			r.Fields = append(r.Fields, LogField{k, v})
+		}
+	}
+	for _, p := range sdParamRe.FindAllStringSubmatch(m[7], -1) {
+		r.Fields = append(r.Fields, LogField{p[1], strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\]`, `]`).Replace(p[2])})
+	}
+	return r, true
+}
+
+func parseSyslog3164(line string) (*LogRecord, bool) {
+	m := syslog3164Re.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	r := &LogRecord{Message: m[6]}
+	if m[1] != "" {
+		r.Level, r.Fields = syslogPriority(m[1])
+	} else {
+		r.Level = guessLevel(m[6])
+	}
+	if t, err := parseTimeText(m[2]); err == nil {
+		r.Time = syslogYear(t, time.Now())
+	}
+	r.Fields = append(r.Fields, LogField{"host", m[3]}, LogField{"app", m[4]})
+	if m[5] != "" {
+		r.Fields = append(r.Fields, LogField{"pid", m[5]})
+	}
+	return r, true
+}
+
+// syslogYear moves t, parsed in the current year, to the previous year when it is after now,
+// RFC 3164 timestamps have no year and a December line read in January is from last year
+func syslogYear(t, now time.Time) time.Time {
+	if t.After(now) {
+		return t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// syslogPriority returns the level and the facility of a syslog priority
+func syslogPriority(s string) (string, []LogField) {
+	pri, _ := strconv.Atoi(s)
+	levels := []string{"fatal", "fatal", "fatal", "error", "warn", "info", "info", "debug"}
+	return levels[pri%8], []LogField{{"facility", strconv.Itoa(pri / 8)}}
+}
+
+func parseGoLog(line string) (*LogRecord, bool) {
+	m := goLogRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	r := &LogRecord{Message: m[3], Level: normalizeLevel(m[2])}
+	r.Time, _ = time.ParseInLocation("2006/01/02 15:04:05.999999999", m[1], time.Local)
+
+	// slog attributes follow the message
+	if i := logfmtPairRe.FindStringIndex(m[3]); i != nil {
+		if fields, ok := parseLogfmt(m[3][i[0]:]); ok {
+			r.Message = strings.TrimSpace(m[3][:i[0]])
+			r.Fields = fields
+		}
+	}
+	if r.Level == "" {
+		r.Level = guessLevel(r.Message)
+	}
+	return r, true
+}
+
+// newLogRecord extracts the time, level and message from key value fields
+func newLogRecord(fields []LogField) *LogRecord {
+	r := &LogRecord{}
+	var found [3]bool
+	for _, f := range fields {
+		k := strings.ToLower(f.Key)
+		switch {
+		case !found[0] && slices.Contains(logTimeKeys, k):
+			if t, err := parseTimeText(f.Value); err == nil {
+				r.Time, found[0] = t, true
+				continue
+			}
+		case !found[1] && slices.Contains(logLevelKeys, k):
+			r.Level, found[1] = normalizeLevel(f.Value), true
+			continue
+		case !found[2] && slices.Contains(logMessageKeys, k):
+			r.Message, found[2] = f.Value, true
+			continue
+		}
+		r.Fields = append(r.Fields, f)
+	}
+	return r
+}
+
+// normalizeLevel maps the common level names and numbers (bunyan, pino) to the levels
+func normalizeLevel(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace", "trc", "t", "10":
+		return "trace"
+	case "debug", "dbg", "d", "20":
+		return "debug"
+	case "info", "inf", "i", "information", "informational", "notice", "30":
+		return "info"
+	case "warn", "warning", "wrn", "w", "40":
+		return "warn"
+	case "error", "err", "eror", "e", "50":
+		return "error"
+	case "fatal", "ftl", "f", "panic", "dpanic", "crit", "critical", "alert", "emerg", "emergency", "60":
+		return "fatal"
+	}
+	return ""
+}
+
+// guessLevel looks for a level name in an unstructured line
+func guessLevel(s string) string {
+	if m := levelWordRe.FindString(s); m != "" {
+		return normalizeLevel(m)
+	}
+	return ""
+}
+
+// levelRank returns the severity of a normalized level, -1 if unknown
+func levelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,240 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) LogsAction(action string, in *Logs, args ...string) (any, error) {
+	a, ok := r.m[logFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for log input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextLogsTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(logActions...)
+
+	tests := []struct {
+		name       string
+		in         string
+		kind       string
+		wantTime   time.Time
+		wantLevel  string
+		wantMsg    string
+		wantFields []LogField
+		wantErr    bool
+	}{
+		{
+			"json", `{"time":"2024-01-02T03:04:05Z","level":"WARNING","msg":"disk full","used":0.97,"tags":["a","b"]}`, "auto",
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "warn", "disk full",
+			[]LogField{{"used", "0.97"}, {"tags", `["a","b"]`}}, false,
+		},
+		{
+			"json pino", `{"level":50,"time":1704164645000,"msg":"boom","pid":42}`, "auto",
+			time.UnixMilli(1704164645000), "error", "boom", []LogField{{"pid", "42"}}, false,
+		},
+		{
+			"logfmt", `ts=2024-01-02T03:04:05Z lvl=info msg="user logged in" user=bob id=`, "auto",
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "info", "user logged in",
+			[]LogField{{"user", "bob"}, {"id", ""}}, false,
+		},
+		{
+			"slog text", `time=2024-01-02T03:04:05.123+01:00 level=DEBUG msg=starting port=8080`, "auto",
+			time.Date(2024, 1, 2, 2, 4, 5, 123000000, time.UTC), "debug", "starting", []LogField{{"port", "8080"}}, false,
+		},
+		{
+			"slog default", `2024/01/02 03:04:05 ERROR connection lost addr=10.0.0.1:443 retry=true`, "auto",
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), "error", "connection lost",
+			[]LogField{{"addr", "10.0.0.1:443"}, {"retry", "true"}}, false,
+		},
+		{
+			"go log", `2024/01/02 03:04:05 listening on :8080`, "go",
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), "", "listening on :8080", nil, false,
+		},
+		{
+			"combined", `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 404 2326 "http://www.example.com/start.html" "Mozilla/4.08"`, "auto",
+			time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC), "warn", "GET /apache_pb.gif HTTP/1.0",
+			[]LogField{
+				{"remote", "127.0.0.1"}, {"user", "frank"}, {"method", "GET"}, {"path", "/apache_pb.gif"},
+				{"protocol", "HTTP/1.0"}, {"status", "404"}, {"bytes", "2326"},
+				{"referer", "http://www.example.com/start.html"}, {"agent", "Mozilla/4.08"},
+			}, false,
+		},
+		{
+			"common", `10.1.1.1 - - [10/Oct/2000:13:55:36 +0000] "POST /api HTTP/1.1" 502 -`, "access",
+			time.Date(2000, 10, 10, 13, 55, 36, 0, time.UTC), "error", "POST /api HTTP/1.1",
+			[]LogField{
+				{"remote", "10.1.1.1"}, {"user", "-"}, {"method", "POST"}, {"path", "/api"},
+				{"protocol", "HTTP/1.1"}, {"status", "502"}, {"bytes", "-"},
+			}, false,
+		},
+		{
+			"rfc5424", `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`, "auto",
+			time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), "info", "An application event",
+			[]LogField{
+				{"facility", "20"}, {"host", "mymachine.example.com"}, {"app", "evntslog"}, {"msgid", "ID47"},
+				{"iut", "3"}, {"eventSource", "Application"},
+			}, false,
+		},
+		{
+			"rfc3164", `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`, "syslog",
+			time.Time{}, "fatal", "'su root' failed for lonvick on /dev/pts/8",
+			[]LogField{{"facility", "4"}, {"host", "mymachine"}, {"app", "su"}, {"pid", "123"}}, false,
+		},
+		{
+			"plain", `[ERROR] something bad happened`, "auto",
+			time.Time{}, "error", "[ERROR] something bad happened", nil, false,
+		},
+		{"wrong format", `not json`, "json", time.Time{}, "", "", nil, true},
+		{"unknown format", `a=b`, "xml", time.Time{}, "", "", nil, true},
+		{"empty", "\n\n", "auto", time.Time{}, "", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",logs"].Func([]byte(tt.in), tt.kind)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			l := got.(*Logs)
+			require.Len(t, l.Records, 1)
+			rec := l.Records[0]
+			if !tt.wantTime.IsZero() {
+				require.True(t, tt.wantTime.Equal(rec.Time), "got %v", rec.Time)
+			}
+			require.Equal(t, tt.wantLevel, rec.Level)
+			require.Equal(t, tt.wantMsg, rec.Message)
+			require.Equal(t, tt.wantFields, rec.Fields)
+			require.Equal(t, tt.in, l.String())
+		})
+	}
+}
+
+func TestSyslogYear(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   time.Time
+		want time.Time
+	}{
+		{"past", time.Date(2026, 10, 11, 22, 14, 15, 0, time.UTC), time.Date(2026, 10, 11, 22, 14, 15, 0, time.UTC)},
+		{"future", time.Date(2026, 12, 24, 8, 0, 0, 0, time.UTC), time.Date(2025, 12, 24, 8, 0, 0, 0, time.UTC)},
+		{"later today", time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC), time.Date(2025, 10, 19, 13, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, syslogYear(tt.in, now))
+		})
+	}
+}
+
+const testLogs = `{"time":"2024-01-02T03:00:00Z","level":"info","msg":"start","svc":"api"}
+{"time":"2024-01-02T04:00:00Z","level":"error","msg":"crash","svc":"api"}
+	goroutine 1 [running]:
+	main.main()
+{"time":"2024-01-02T05:00:00Z","level":"warn","msg":"slow","svc":"db"}
+{"time":"2024-01-02T06:00:00Z","level":"info","msg":"done","svc":"api"}
+`
+
+func TestAction_LogsTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(logActions...)
+
+	in, err := parseLogs([]byte(testLogs), "auto")
+	require.NoError(t, err)
+	require.Len(t, in.Records, 4)
+	require.Equal(t, "crash\n\tgoroutine 1 [running]:\n\tmain.main()", in.Records[1].Message)
+
+	tests := []struct {
+		name    string
+		action  string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"level", "level", nil, []string{"crash\n\tgoroutine 1 [running]:\n\tmain.main()", "slow"}, false},
+		{"level error", "level", []string{"ERR"}, []string{"crash\n\tgoroutine 1 [running]:\n\tmain.main()"}, false},
+		{"level invalid", "level", []string{"loud"}, nil, true},
+		{"between", "between", []string{"2024-01-02T04:00:00Z", "2024-01-02 05:00:00"}, []string{"crash\n\tgoroutine 1 [running]:\n\tmain.main()", "slow"}, false},
+		{"from", "between", []string{"2024-01-02T05:00:00Z"}, []string{"slow", "done"}, false},
+		{"to", "between", []string{"", "2024-01-02T03:30:00Z"}, []string{"start"}, false},
+		{"relative", "between", []string{"-1h"}, nil, false},
+		{"invalid", "between", []string{"yesterday"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.LogsAction(tt.action, in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var msgs []string
+			for _, rec := range got.(*Logs).Records {
+				msgs = append(msgs, rec.Message)
+			}
+			require.Equal(t, tt.want, msgs)
+		})
+	}
+}
+
+func TestAction_LogsTableTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(logActions...)
+
+	in, err := parseLogs([]byte(testLogs), "auto")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		action     string
+		args       []string
+		wantHeader []string
+		wantRows   [][]string
+	}{
+		{
+			"fields", "fields", []string{"level, svc,missing"},
+			[]string{"level", "svc", "missing"},
+			[][]string{{"info", "api", ""}, {"error", "api", ""}, {"warn", "db", ""}, {"info", "api", ""}},
+		},
+		{
+			"all fields", "fields", nil,
+			[]string{"time", "level", "msg", "svc"},
+			[][]string{
+				{"2024-01-02T03:00:00Z", "info", "start", "api"},
+				{"2024-01-02T04:00:00Z", "error", "crash\n\tgoroutine 1 [running]:\n\tmain.main()", "api"},
+				{"2024-01-02T05:00:00Z", "warn", "slow", "db"},
+				{"2024-01-02T06:00:00Z", "info", "done", "api"},
+			},
+		},
+		{
+			"count", "count", nil,
+			[]string{"level", "count"},
+			[][]string{{"info", "2"}, {"error", "1"}, {"warn", "1"}},
+		},
+		{
+			"count by field", "count", []string{"svc"},
+			[]string{"svc", "count"},
+			[][]string{{"api", "3"}, {"db", "1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.LogsAction(tt.action, in, tt.args...)
+			require.NoError(t, err)
+			tb := got.(*Table)
+			require.Equal(t, tt.wantHeader, tb.Header)
+			require.Equal(t, tt.wantRows, tb.Rows)
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package action
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Markdown is a parsed GitHub flavored markdown document
+type Markdown struct {
+	Source []byte
+	Doc    ast.Node
+}
+
+// gfm parses and renders GitHub flavored markdown: tables, strikethrough, autolinks and task lists
+var gfm = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+var markdownActions = []Action{
+	parseMarkdownAction, markdownHTMLAction, markdownTextAction, markdownLinksAction,
+	markdownCodeBlocksAction, markdownCodeAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(markdownActions...)
+}
+
+var parseMarkdownAction = Action{
+	Doc:          "Parse GitHub flavored markdown",
+	Names:        []string{"markdown", "md"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: markdownFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseMarkdown(in.([]byte)), nil
+	},
+}
+
+var markdownHTMLAction = Action{
+	Doc:          "Convert to HTML",
+	Names:        []string{"html", "tohtml"},
+	Type:         TransformAction,
+	InputFormat:  markdownFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m := in.(*Markdown)
+		var buf bytes.Buffer
+		if err := gfm.Renderer().Render(&buf, m.Source, m.Doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	},
+}
+
+var markdownTextAction = Action{
+	Doc:          "Convert to plain text, removing the markup",
+	Names:        []string{"totext", "plain"},
+	Type:         TransformAction,
+	InputFormat:  markdownFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m := in.(*Markdown)
+		var b strings.Builder
+		writeMarkdownText(&b, m.Doc, m.Source)
+		return []byte(strings.TrimSpace(b.String())), nil
+	},
+}
+
+var markdownLinksAction = Action{
+	Doc:          "Extract the links with their text",
+	Names:        []string{"links"},
+	Type:         TransformAction,
+	InputFormat:  markdownFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m := in.(*Markdown)
+		t := &Table{Header: []string{"text", "url"}, Delimiter: ','}
+		err := ast.Walk(m.Doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering {
+				return ast.WalkContinue, nil
+			}
+			switch l := n.(type) {
+			case *ast.Link:
+				var b strings.Builder
+				writeMarkdownText(&b, l, m.Source)
+				t.Rows = append(t.Rows, []string{b.String(), string(l.Destination)})
+				return ast.WalkSkipChildren, nil
+			case *ast.AutoLink:
+				u := string(l.URL(m.Source))
+				t.Rows = append(t.Rows, []string{string(l.Label(m.Source)), u})
+			}
+			return ast.WalkContinue, nil
+		})
+		return t, err
+	},
+}
+
+var markdownCodeBlocksAction = Action{
+	Doc:          "Extract the fenced code blocks with their language",
+	Names:        []string{"codeblocks", "fences"},
+	Type:         TransformAction,
+	InputFormat:  markdownFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := &Table{Header: []string{"language", "code"}, Delimiter: ','}
+		for _, c := range codeBlocks(in.(*Markdown)) {
+			t.Rows = append(t.Rows, []string{c.language, c.code})
+		}
+		return t, nil
+	},
+}
+
+var markdownCodeAction = Action{
+	Doc:          "Extract the fenced code blocks as a list, optionally only one language",
+	Names:        []string{"code"},
+	Type:         TransformAction,
+	InputFormat:  markdownFormat,
+	OutputFormat: textListFormat,
+	Params:       []Param{{Name: "language", Doc: "keep only the blocks of this language, all if empty"}},
+	Func: func(in any, args ...string) (any, error) {
+		lang := strings.TrimSpace(args[0])
+		var l []string
+		for _, c := range codeBlocks(in.(*Markdown)) {
+			if lang == "" || strings.EqualFold(lang, c.language) {
+				l = append(l, c.code)
+			}
+		}
+		return l, nil
+	},
+}
+
+func (m *Markdown) String() string {
+	return string(m.Source)
+}
+
+func parseMarkdown(b []byte) *Markdown {
+	return &Markdown{Source: b, Doc: gfm.Parser().Parse(text.NewReader(b))}
+}
+
+type codeBlock struct {
+	language, code string
+}
+
+func codeBlocks(m *Markdown) []codeBlock {
+	var blocks []codeBlock
+	_ = ast.Walk(m.Doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if c, ok := n.(*ast.FencedCodeBlock); ok && entering {
+			blocks = append(blocks, codeBlock{
+				language: string(c.Language(m.Source)),
+				code:     strings.TrimSuffix(string(nodeLines(c, m.Source)), "\n"),
+			})
+		}
+		return ast.WalkContinue, nil
+	})
+	return blocks
+}
+
+// nodeLines returns the raw content of a block such as a code block
+func nodeLines(n ast.Node, src []byte) []byte {
+	var b bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		s := lines.At(i)
+		b.Write(s.Value(src))
+	}
+	return b.Bytes()
+}
+
+// writeMarkdownText writes the text content of the children of n,
+// blocks are separated by a blank line, list items and table rows by a line break
+func writeMarkdownText(b *strings.Builder, n ast.Node, src []byte) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch v := c.(type) {
+		case *ast.Text:
+			b.Write(v.Segment.Value(src))
+			if v.SoftLineBreak() || v.HardLineBreak() {
+				b.WriteByte('\n')
+			}
+		case *ast.String:
+			b.Write(v.Value)
+		case *ast.AutoLink:
+			b.Write(v.Label(src))
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			b.WriteString(strings.TrimSuffix(string(nodeLines(v, src)), "\n"))
+		case *ast.HTMLBlock, *ast.RawHTML, *ast.ThematicBreak:
+			continue
+		default:
+			writeMarkdownText(b, c, src)
+		}
+
+		if c.Type() != ast.TypeBlock || c.NextSibling() == nil {
+			continue
+		}
+		if _, ok := n.(*ast.ListItem); ok {
+			b.WriteByte('\n')
+			continue
+		}
+		switch c.(type) {
+		case *east.TableCell:
+			b.WriteByte('\t')
+		case *ast.ListItem, *east.TableHeader, *east.TableRow:
+			b.WriteByte('\n')
+		default:
+			b.WriteString("\n\n")
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) MarkdownAction(action string, in *Markdown, args ...string) (any, error) {
+	a, ok := r.m[markdownFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for markdown input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+const testMarkdown = "# ovr\n\n" +
+	"A **tool** to [transform](https://github.com/akhenakh/ovr \"repo\") text,\nsee https://example.com.\n\n" +
+	"- one\n- two with `code`\n  - nested\n\n" +
+	"| name | value |\n| ---- | ----- |\n| a | 1 |\n\n" +
+	"```go\nfmt.Println(\"hello\")\n```\n\n" +
+	"<div>html</div>\n\n" +
+	"---\n\n" +
+	"```\nplain\n```\n\n" +
+	"```Go\nreturn nil\n```\n\n" +
+	"Use [the docs][docs].\n\n" +
+	"[docs]: https://pkg.go.dev\n"
+
+func TestAction_MarkdownTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(markdownActions...)
+
+	tests := []struct {
+		name   string
+		action string
+		args   []string
+		want   string
+	}{
+		{
+			"html", "html", nil,
+			"<h1>ovr</h1>\n<p>A <strong>tool</strong> to <a href=\"https://github.com/akhenakh/ovr\" title=\"repo\">transform</a> text,\n" +
+				"see <a href=\"https://example.com\">https://example.com</a>.</p>\n",
+		},
+		{
+			"totext", "totext", nil,
+			"ovr\n\nA tool to transform text,\nsee https://example.com.\n\n" +
+				"one\ntwo with code\nnested\n\n" +
+				"name\tvalue\na\t1\n\n" +
+				"fmt.Println(\"hello\")\n\nplain\n\nreturn nil\n\nUse the docs.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.MarkdownAction(tt.action, parseMarkdown([]byte(testMarkdown)), tt.args...)
+			require.NoError(t, err)
+			if tt.action == "html" {
+				require.Contains(t, string(got.([]byte)), tt.want)
+				return
+			}
+			require.Equal(t, tt.want, string(got.([]byte)))
+		})
+	}
+}
+
+func TestAction_MarkdownExtractTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(markdownActions...)
+
+	in := parseMarkdown([]byte(testMarkdown))
+
+	got, err := r.MarkdownAction("links", in)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"transform", "https://github.com/akhenakh/ovr"},
+		{"https://example.com", "https://example.com"},
+		{"the docs", "https://pkg.go.dev"},
+	}, got.(*Table).Rows)
+
+	got, err = r.MarkdownAction("codeblocks", in)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"go", "fmt.Println(\"hello\")"},
+		{"", "plain"},
+		{"Go", "return nil"},
+	}, got.(*Table).Rows)
+
+	tests := []struct {
+		name     string
+		language string
+		want     []string
+	}{
+		{"all", "", []string{"fmt.Println(\"hello\")", "plain", "return nil"}},
+		{"go", "go", []string{"fmt.Println(\"hello\")", "return nil"}},
+		{"none", "rust", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.MarkdownAction("code", in, tt.language)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.([]string))
+		})
+	}
+}
@@ -0,0 +1,559 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// floatPrec is the precision in bits of non integer numbers
+const floatPrec = 256
+
+// Number is an arbitrary precision number, Int is set for integers, Float otherwise
+type Number struct {
+	Int   *big.Int
+	Float *big.Float
+}
+
+var numberActions = []Action{
+	parseNumberAction, parseByteSizeAction,
+	numberDecimalAction, numberHexAction, numberOctalAction, numberBinaryAction, numberBaseAction,
+	numberScientificAction, numberThousandsAction, numberByteSizeAction,
+	numberRoundAction, numberAddAction, numberSubAction, numberMulAction, numberDivAction,
+	numberModAction, numberPowAction, numberEpochAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(numberActions...)
+}
+
+var parseNumberAction = Action{
+	Doc:          "Parse a number, decimal, hex 0x, octal 0o or 0, binary 0b or scientific notation",
+	Names:        []string{"number"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: numberFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseNumber(string(in.([]byte)))
+	},
+}
+
+var parseByteSizeAction = Action{
+	Doc:          "Parse a human readable byte size, e.g. 1.5GiB, 10 MB, 4k (single letter units are binary)",
+	Names:        []string{"bytesize", "size"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: numberFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseByteSize(string(in.([]byte)))
+	},
+}
+
+var numberDecimalAction = Action{
+	Doc:          "Transforms a number to decimal text",
+	Names:        []string{"decimal", "totext"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(in.(*Number).String()), nil
+	},
+}
+
+var numberHexAction = Action{
+	Doc:          "Transforms an integer to hexadecimal text",
+	Names:        []string{"tohex"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return numberInBase(in.(*Number), 16, "0x")
+	},
+}
+
+var numberOctalAction = Action{
+	Doc:          "Transforms an integer to octal text",
+	Names:        []string{"tooctal"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return numberInBase(in.(*Number), 8, "0o")
+	},
+}
+
+var numberBinaryAction = Action{
+	Doc:          "Transforms an integer to binary text",
+	Names:        []string{"tobinary"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return numberInBase(in.(*Number), 2, "0b")
+	},
+}
+
+var numberBaseAction = Action{
+	Doc:          "Transforms an integer to text in any base from 2 to 62, without prefix",
+	Names:        []string{"tobase"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "base", Doc: "base from 2 to 62", Default: "36"}},
+	Func: func(in any, args ...string) (any, error) {
+		base, err := strconv.Atoi(args[0])
+		if err != nil || base < 2 || base > big.MaxBase {
+			return nil, fmt.Errorf("invalid base %q", args[0])
+		}
+		return numberInBase(in.(*Number), base, "")
+	},
+}
+
+var numberScientificAction = Action{
+	Doc:          "Transforms a number to scientific notation text",
+	Names:        []string{"toscientific"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(in.(*Number).float().Text('e', -1)), nil
+	},
+}
+
+var numberThousandsAction = Action{
+	Doc:          "Transforms a number to decimal text with thousands separators",
+	Names:        []string{"thousands"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "separator", Doc: "thousands separator", Default: ","}},
+	Func: func(in any, args ...string) (any, error) {
+		return []byte(groupThousands(in.(*Number).String(), args[0])), nil
+	},
+}
+
+var numberByteSizeAction = Action{
+	Doc:          "Transforms a number of bytes to a human readable size, e.g. 1.5GiB",
+	Names:        []string{"tobytesize", "human"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "units", Doc: "iec for powers of 1024 (KiB), si for powers of 1000 (kB)", Default: "iec"}},
+	Func: func(in any, args ...string) (any, error) {
+		return formatByteSize(in.(*Number), args[0])
+	},
+}
+
+var numberRoundAction = Action{
+	Doc:          "Round a number to a number of decimals, half away from zero, negative decimals round to tens, hundreds...",
+	Names:        []string{"round"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "decimals", Doc: "number of decimals", Default: "0"}},
+	Func: func(in any, args ...string) (any, error) {
+		d, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimals %q", args[0])
+		}
+		return roundNumber(in.(*Number), d), nil
+	},
+}
+
+var numberAddAction = Action{
+	Doc:          "Add a number",
+	Names:        []string{"add"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "operand", Doc: "number to add", Default: "1"}},
+	Func: func(in any, args ...string) (any, error) {
+		return numberOp(in.(*Number), args[0], '+')
+	},
+}
+
+var numberSubAction = Action{
+	Doc:          "Subtract a number",
+	Names:        []string{"sub"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "operand", Doc: "number to subtract", Default: "1"}},
+	Func: func(in any, args ...string) (any, error) {
+		return numberOp(in.(*Number), args[0], '-')
+	},
+}
+
+var numberMulAction = Action{
+	Doc:          "Multiply by a number",
+	Names:        []string{"mul"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "operand", Doc: "multiplier", Default: "2"}},
+	Func: func(in any, args ...string) (any, error) {
+		return numberOp(in.(*Number), args[0], '*')
+	},
+}
+
+var numberDivAction = Action{
+	Doc:          "Divide by a number, integers stay integers when the division is exact",
+	Names:        []string{"div"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "operand", Doc: "divisor", Default: "2"}},
+	Func: func(in any, args ...string) (any, error) {
+		return numberOp(in.(*Number), args[0], '/')
+	},
+}
+
+var numberModAction = Action{
+	Doc:          "Integer modulo, the result has the sign of the divisor",
+	Names:        []string{"mod"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "operand", Doc: "divisor", Default: "2"}},
+	Func: func(in any, args ...string) (any, error) {
+		return numberOp(in.(*Number), args[0], '%')
+	},
+}
+
+var numberPowAction = Action{
+	Doc:          "Raise to a power",
+	Names:        []string{"pow"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: numberFormat,
+	Params:       []Param{{Name: "exponent", Doc: "exponent", Default: "2"}},
+	Func: func(in any, args ...string) (any, error) {
+		return numberOp(in.(*Number), args[0], '^')
+	},
+}
+
+var numberEpochAction = Action{
+	Doc:          "Number of seconds since Epoch to time, fractions are kept",
+	Names:        []string{"epoch"},
+	Type:         TransformAction,
+	InputFormat:  numberFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		n := in.(*Number)
+		if n.Int != nil {
+			if !n.Int.IsInt64() {
+				return nil, errors.New("out of time range")
+			}
+			return time.Unix(n.Int.Int64(), 0), nil
+		}
+		if err := n.finite(); err != nil {
+			return nil, err
+		}
+		r, _ := n.Float.Rat(nil)
+		sec := new(big.Int).Quo(r.Num(), r.Denom())
+		frac := new(big.Rat).Sub(r, new(big.Rat).SetInt(sec))
+		nsec, _ := new(big.Rat).Mul(frac, big.NewRat(int64(time.Second), 1)).Float64()
+		if !sec.IsInt64() {
+			return nil, errors.New("out of time range")
+		}
+		return time.Unix(sec.Int64(), int64(math.Round(nsec))), nil
+	},
+}
+
+func (n *Number) String() string {
+	if n.Int != nil {
+		return n.Int.String()
+	}
+	return n.Float.Text('f', -1)
+}
+
+// finite returns an error for an infinite number, some big.Float operations panic on them
+func (n *Number) finite() error {
+	if n.Float != nil && n.Float.IsInf() {
+		return errors.New("infinite number")
+	}
+	return nil
+}
+
+// float returns the number as a big.Float
+func (n *Number) float() *big.Float {
+	if n.Int != nil {
+		return new(big.Float).SetPrec(floatPrec).SetInt(n.Int)
+	}
+	return n.Float
+}
+
+// rat returns the exact value of the number, infinite numbers have none
+func (n *Number) rat() (*big.Rat, error) {
+	if n.Int != nil {
+		return new(big.Rat).SetInt(n.Int), nil
+	}
+	if err := n.finite(); err != nil {
+		return nil, err
+	}
+	r, _ := n.Float.Rat(nil)
+	return r, nil
+}
+
+// intNumber returns an integer Number
+func intNumber(i *big.Int) *Number {
+	return &Number{Int: i}
+}
+
+// floatNumber returns a Number, integers are stored as Int
+func floatNumber(f *big.Float) *Number {
+	if !f.IsInf() && f.IsInt() {
+		i, _ := f.Int(nil)
+		return &Number{Int: i}
+	}
+	return &Number{Float: f}
+}
+
+// thousandsRe matches integer parts grouped by 3 with commas
+var thousandsRe = regexp.MustCompile(`^[+-]?\d{1,3}(,\d{3})+(\.\d*)?$`)
+
+// parseNumber parses s, in decimal, hex, octal, binary or scientific notation,
+// "_" separators and "," thousands separators are accepted
+func parseNumber(s string) (*Number, error) {
+	s = strings.TrimSpace(s)
+	if thousandsRe.MatchString(s) {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	if i, ok := new(big.Int).SetString(s, 0); ok {
+		return intNumber(i), nil
+	}
+
+	f, _, err := big.ParseFloat(s, 0, floatPrec, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	if f.IsInf() {
+		return nil, fmt.Errorf("invalid number %q: infinite", s)
+	}
+	return floatNumber(f), nil
+}
+
+var byteSizeRe = regexp.MustCompile(`^([0-9.,_]+(?:[eE][+-]?[0-9]+)?)\s*([kKmMgGtTpPeE]?)(i?)([bB]?)$`)
+
+// parseByteSize parses a size with an optional unit, kB, MB ... are powers of 1000,
+// KiB, MiB ... and K, M ... are powers of 1024
+func parseByteSize(s string) (*Number, error) {
+	m := byteSizeRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, fmt.Errorf("invalid size %q", s)
+	}
+
+	n, err := parseNumber(m[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if m[2] == "" {
+		if m[3] != "" {
+			return nil, fmt.Errorf("invalid size %q", s)
+		}
+		return n, nil
+	}
+
+	base := int64(1024)
+	if m[3] == "" && m[4] != "" {
+		base = 1000
+	}
+	exp := strings.Index("kmgtpe", strings.ToLower(m[2])) + 1
+
+	mult := new(big.Int).Exp(big.NewInt(base), big.NewInt(int64(exp)), nil)
+	return floatNumber(new(big.Float).SetPrec(floatPrec).Mul(n.float(), new(big.Float).SetInt(mult))), nil
+}
+
+// formatByteSize formats n with the largest unit keeping the value over 1, with up to 2 decimals
+func formatByteSize(n *Number, units string) ([]byte, error) {
+	var base float64
+	var suffixes []string
+	switch strings.ToLower(units) {
+	case "iec":
+		base = 1024
+		suffixes = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	case "si":
+		base = 1000
+		suffixes = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	default:
+		return nil, fmt.Errorf("unknown units %q, expecting iec or si", units)
+	}
+
+	f, _ := n.float().Float64()
+	i := 0
+	for math.Abs(f) >= base && i < len(suffixes)-1 {
+		f /= base
+		i++
+	}
+	return []byte(strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64) + suffixes[i]), nil
+}
+
+// numberInBase formats an integer in base with a prefix, the sign comes before the prefix
+func numberInBase(n *Number, base int, prefix string) ([]byte, error) {
+	if n.Int == nil {
+		return nil, errors.New("not an integer")
+	}
+	s := n.Int.Text(base)
+	if n.Int.Sign() < 0 {
+		return []byte("-" + prefix + s[1:]), nil
+	}
+	return []byte(prefix + s), nil
+}
+
+// groupThousands inserts sep every 3 digits of the integer part of a decimal number
+func groupThousands(s, sep string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(sep)
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + frac
+}
+
+// roundNumber rounds n to decimals, half away from zero
+func roundNumber(n *Number, decimals int) *Number {
+	if n.Int != nil && decimals >= 0 {
+		return n
+	}
+	if n.Float != nil && n.Float.IsInf() {
+		return n
+	}
+
+	var r *big.Rat
+	if n.Int != nil {
+		r = new(big.Rat).SetInt(n.Int)
+	} else {
+		r, _ = n.Float.Rat(nil)
+	}
+
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(decimals))), nil))
+	if decimals < 0 {
+		scale.Inv(scale)
+	}
+	r.Mul(r, scale)
+
+	// (2*num + sign*den) / (2*den), truncated toward zero
+	num := new(big.Int).Mul(r.Num(), big.NewInt(2))
+	den := new(big.Int).Mul(r.Denom(), big.NewInt(2))
+	if r.Sign() < 0 {
+		num.Sub(num, r.Denom())
+	} else {
+		num.Add(num, r.Denom())
+	}
+	q := new(big.Int).Quo(num, den)
+
+	res := new(big.Rat).SetInt(q)
+	res.Quo(res, scale)
+	if res.IsInt() {
+		return intNumber(new(big.Int).Set(res.Num()))
+	}
+	return &Number{Float: new(big.Float).SetPrec(floatPrec).SetRat(res)}
+}
+
+// numberOp applies op with the operand, integer operations stay integers
+func numberOp(n *Number, operand string, op byte) (*Number, error) {
+	o, err := parseNumber(operand)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.finite(); err != nil {
+		return nil, err
+	}
+
+	if n.Int != nil && o.Int != nil {
+		switch op {
+		case '+':
+			return intNumber(new(big.Int).Add(n.Int, o.Int)), nil
+		case '-':
+			return intNumber(new(big.Int).Sub(n.Int, o.Int)), nil
+		case '*':
+			return intNumber(new(big.Int).Mul(n.Int, o.Int)), nil
+		case '/':
+			if o.Int.Sign() == 0 {
+				return nil, errors.New("division by zero")
+			}
+			q, m := new(big.Int).QuoRem(n.Int, o.Int, new(big.Int))
+			if m.Sign() == 0 {
+				return intNumber(q), nil
+			}
+		case '%':
+			if o.Int.Sign() == 0 {
+				return nil, errors.New("division by zero")
+			}
+			m := new(big.Int).Rem(n.Int, o.Int)
+			// sign of the divisor, as in most languages used for modulo arithmetic
+			if m.Sign() != 0 && m.Sign() != o.Int.Sign() {
+				m.Add(m, o.Int)
+			}
+			return intNumber(m), nil
+		case '^':
+			if o.Int.Sign() >= 0 {
+				if !o.Int.IsInt64() || o.Int.Int64() > 1<<20 {
+					return nil, errors.New("exponent too large")
+				}
+				return intNumber(new(big.Int).Exp(n.Int, o.Int, nil)), nil
+			}
+		}
+	}
+
+	a, b := n.float(), o.float()
+	res := new(big.Float).SetPrec(floatPrec)
+	switch op {
+	case '+':
+		res.Add(a, b)
+	case '-':
+		res.Sub(a, b)
+	case '*':
+		res.Mul(a, b)
+	case '/':
+		if b.Sign() == 0 {
+			return nil, errors.New("division by zero")
+		}
+		res.Quo(a, b)
+	case '%':
+		return nil, errors.New("modulo needs integers")
+	case '^':
+		af, _ := a.Float64()
+		bf, _ := b.Float64()
+		p := math.Pow(af, bf)
+		if math.IsNaN(p) {
+			return nil, errors.New("result is not a number")
+		}
+		if math.IsInf(p, 0) {
+			return nil, errors.New("result out of range")
+		}
+		res.SetFloat64(p)
+	}
+	// the exponent of a big.Float can overflow too
+	if res.IsInf() {
+		return nil, errors.New("result out of range")
+	}
+	return floatNumber(res), nil
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
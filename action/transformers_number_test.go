@@ -0,0 +1,179 @@
+package action
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) NumberAction(action string, in *Number, args ...string) (any, error) {
+	a, ok := r.m[numberFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for number input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextNumberTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(numberActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"decimal", "number", "42", "42", false},
+		{"negative", "number", " -17\n", "-17", false},
+		{"big", "number", "123456789012345678901234567890", "123456789012345678901234567890", false},
+		{"hex", "number", "0xff", "255", false},
+		{"octal", "number", "0o755", "493", false},
+		{"binary", "number", "0b1010", "10", false},
+		{"underscores", "number", "1_000_000", "1000000", false},
+		{"thousands", "number", "1,234,567.5", "1234567.5", false},
+		{"float", "number", "0.1", "0.1", false},
+		{"scientific", "number", "1.5e3", "1500", false},
+		{"not thousands", "number", "1,5", "", true},
+		{"invalid", "number", "abc", "", true},
+		{"infinity", "number", "inf", "", true},
+		{"negative infinity", "number", "-inf", "", true},
+		{"size iec", "bytesize", "1.5GiB", "1610612736", false},
+		{"size si", "bytesize", "10 MB", "10000000", false},
+		{"size letter", "bytesize", "4k", "4096", false},
+		{"size bytes", "bytesize", "512", "512", false},
+		{"size fraction", "bytesize", "1.5B", "1.5", false},
+		{"size invalid unit", "bytesize", "3 XB", "", true},
+		{"size invalid i", "bytesize", "3iB", "", true},
+		{"size exa", "bytesize", "1EiB", "1152921504606846976", false},
+		{"size exa letter", "bytesize", "2E", "2305843009213693952", false},
+		{"size exa si", "bytesize", "1.5EB", "1500000000000000000", false},
+		{"size scientific", "bytesize", "1e3 kB", "1000000", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := r.m[textFormat.Prefix+","+tt.action]
+			require.True(t, ok)
+			got, err := a.Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*Number).String())
+		})
+	}
+
+	// the sizes written by tobytesize are parsed back
+	for _, size := range []string{"1EiB", "1.5GiB", "100B"} {
+		n, err := r.m[textFormat.Prefix+",bytesize"].Func([]byte(size))
+		require.NoError(t, err)
+		got, err := r.NumberAction("tobytesize", n.(*Number))
+		require.NoError(t, err)
+		require.Equal(t, size, string(got.([]byte)))
+	}
+}
+
+func TestAction_NumberTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(numberActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"hex", "tohex", "255", nil, "0xff", false},
+		{"negative hex", "tohex", "-255", nil, "-0xff", false},
+		{"hex float", "tohex", "1.5", nil, "", true},
+		{"octal", "tooctal", "493", nil, "0o755", false},
+		{"binary", "tobinary", "10", nil, "0b1010", false},
+		{"base default", "tobase", "35", nil, "z", false},
+		{"base 3", "tobase", "10", []string{"3"}, "101", false},
+		{"invalid base", "tobase", "10", []string{"63"}, "", true},
+		{"scientific", "toscientific", "1500", nil, "1.5e+03", false},
+		{"thousands", "thousands", "-1234567.25", nil, "-1,234,567.25", false},
+		{"thousands separator", "thousands", "1234567", []string{" "}, "1 234 567", false},
+		{"thousands small", "thousands", "123", nil, "123", false},
+		{"size iec", "tobytesize", "1610612736", nil, "1.5GiB", false},
+		{"size si", "tobytesize", "1234567", []string{"si"}, "1.23MB", false},
+		{"size small", "tobytesize", "100", nil, "100B", false},
+		{"size unknown units", "tobytesize", "100", []string{"bits"}, "", true},
+		{"round", "round", "2.5", nil, "3", false},
+		{"round negative", "round", "-2.5", nil, "-3", false},
+		{"round decimals", "round", "3.14159", []string{"2"}, "3.14", false},
+		{"round tens", "round", "1234", []string{"-2"}, "1200", false},
+		{"round int", "round", "7", []string{"2"}, "7", false},
+		{"add", "add", "41", nil, "42", false},
+		{"add float", "add", "0.1", []string{"0.2"}, "0.3", false},
+		{"add big", "add", "18446744073709551615", nil, "18446744073709551616", false},
+		{"sub", "sub", "10", []string{"0x10"}, "-6", false},
+		{"mul", "mul", "1.5", []string{"4"}, "6", false},
+		{"div exact", "div", "10", nil, "5", false},
+		{"div", "div", "5", nil, "2.5", false},
+		{"div zero", "div", "5", []string{"0"}, "", true},
+		{"mod", "mod", "-7", []string{"3"}, "2", false},
+		{"mod float", "mod", "7.5", []string{"3"}, "", true},
+		{"pow", "pow", "2", []string{"100"}, "1267650600228229401496703205376", false},
+		{"pow float", "pow", "4", []string{"0.5"}, "2", false},
+		{"pow overflow", "pow", "1.5", []string{"10000"}, "", true},
+		{"pow overflow exponent", "pow", "10", []string{"1e400"}, "", true},
+		{"infinite operand", "mul", "0.5", []string{"-inf"}, "", true},
+		{"invalid operand", "add", "1", []string{"x"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := parseNumber(tt.in)
+			require.NoError(t, err)
+
+			got, err := r.NumberAction(tt.action, in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch v := got.(type) {
+			case []byte:
+				require.Equal(t, tt.want, string(v))
+			case *Number:
+				require.Equal(t, tt.want, v.String())
+			}
+		})
+	}
+}
+
+func TestAction_NumberTimeTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(numberActions...)
+
+	for _, tt := range []struct {
+		in   string
+		want time.Time
+	}{
+		{"1704198144", time.Unix(1704198144, 0)},
+		{"1704198144.25", time.Unix(1704198144, 250000000)},
+		{"1.704198144e9", time.Unix(1704198144, 0)},
+	} {
+		in, err := parseNumber(tt.in)
+		require.NoError(t, err)
+		got, err := r.NumberAction("epoch", in)
+		require.NoError(t, err)
+		require.True(t, tt.want.Equal(got.(time.Time)), "%s: %v", tt.in, got)
+	}
+
+	// infinite numbers can't be parsed, but must not panic if built otherwise
+	_, err := r.NumberAction("epoch", &Number{Float: new(big.Float).SetInf(false)})
+	require.Error(t, err)
+	_, err = r.NumberAction("mul", &Number{Float: new(big.Float).SetInf(true)}, "0")
+	require.Error(t, err)
+}
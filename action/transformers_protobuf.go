@@ -0,0 +1,192 @@
+package action
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// protobuf wire types
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+var protobufActions = []Action{
+	protobufDecodeRawAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(protobufActions...)
+}
+
+var protobufDecodeRawAction = Action{
+	Doc: "Decode a protobuf message without schema like protoc --decode_raw, " +
+		"into a JSON list of fields with their number, wire type and interpretations",
+	Names:        []string{"protobuf", "decoderaw"},
+	Type:         ParseAction,
+	InputFormat:  binFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		fields, rest, err := decodeProtobuf(in.([]byte), 0)
+		if len(fields) == 0 {
+			if err == nil {
+				err = errors.New("empty message")
+			}
+			return nil, fmt.Errorf("not a protobuf message: %w", err)
+		}
+		if err != nil {
+			// keep what was decoded, the undecodable end is kept as raw bytes
+			fields = append(fields, map[string]any{
+				"type":   "raw",
+				"offset": json.Number(strconv.Itoa(len(in.([]byte)) - len(rest))),
+				"error":  err.Error(),
+				"value":  base64.StdEncoding.EncodeToString(rest),
+			})
+		}
+		return fields, nil
+	},
+}
+
+// decodeProtobuf decodes fields from b until its end or the end of group,
+// returns the decoded fields and the remaining bytes when failing
+func decodeProtobuf(b []byte, group uint64) ([]any, []byte, error) {
+	fields := []any{}
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fields, b, errors.New("invalid tag")
+		}
+		num, wire := tag>>3, tag&7
+		if num == 0 {
+			return fields, b, errors.New("invalid field number 0")
+		}
+		data := b[n:]
+
+		field := map[string]any{"field": json.Number(strconv.FormatUint(num, 10))}
+		switch wire {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fields, b, fmt.Errorf("field %d: invalid varint", num)
+			}
+			field["type"] = "varint"
+			field["value"] = jsonUint(v)
+			if int64(v) < 0 {
+				field["int64"] = jsonInt(int64(v))
+			}
+			field["zigzag"] = jsonInt(int64(v>>1) ^ -int64(v&1))
+			data = data[n:]
+
+		case wireFixed64:
+			if len(data) < 8 {
+				return fields, b, fmt.Errorf("field %d: truncated fixed64", num)
+			}
+			v := binary.LittleEndian.Uint64(data)
+			field["type"] = "fixed64"
+			field["value"] = jsonUint(v)
+			field["int64"] = jsonInt(int64(v))
+			field["double"] = jsonFloat(math.Float64frombits(v), 64)
+			data = data[8:]
+
+		case wireFixed32:
+			if len(data) < 4 {
+				return fields, b, fmt.Errorf("field %d: truncated fixed32", num)
+			}
+			v := binary.LittleEndian.Uint32(data)
+			field["type"] = "fixed32"
+			field["value"] = jsonUint(uint64(v))
+			field["int32"] = jsonInt(int64(int32(v)))
+			field["float"] = jsonFloat(float64(math.Float32frombits(v)), 32)
+			data = data[4:]
+
+		case wireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || l > uint64(len(data)-n) {
+				return fields, b, fmt.Errorf("field %d: invalid length", num)
+			}
+			v := data[n : n+int(l)]
+			field["type"], field["value"] = protobufBytes(v)
+			data = data[n+int(l):]
+
+		case wireStartGroup:
+			sub, rest, err := decodeProtobuf(data, num)
+			if err != nil {
+				return fields, b, fmt.Errorf("field %d: %w", num, err)
+			}
+			field["type"] = "group"
+			field["value"] = sub
+			data = rest
+
+		case wireEndGroup:
+			if num != group {
+				return fields, b, fmt.Errorf("unexpected end of group %d", num)
+			}
+			return fields, data, nil
+
+		default:
+			return fields, b, fmt.Errorf("field %d: invalid wire type %d", num, wire)
+		}
+
+		fields = append(fields, field)
+		b = data
+	}
+
+	if group != 0 {
+		return fields, b, fmt.Errorf("missing end of group %d", group)
+	}
+	return fields, b, nil
+}
+
+// protobufBytes guesses the content of a length delimited field like protoc --decode_raw:
+// a fully decodable nested message, then printable text is a string, or bytes
+func protobufBytes(v []byte) (string, any) {
+	if sub, _, err := decodeProtobuf(v, 0); err == nil && len(sub) > 0 {
+		return "message", sub
+	}
+	if isPrintable(v) {
+		return "string", string(v)
+	}
+	return "bytes", base64.StdEncoding.EncodeToString(v)
+}
+
+// isPrintable returns true for valid UTF-8 without control characters other than blanks
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func jsonUint(v uint64) json.Number {
+	return json.Number(strconv.FormatUint(v, 10))
+}
+
+func jsonInt(v int64) json.Number {
+	return json.Number(strconv.FormatInt(v, 10))
+}
+
+// jsonFloat returns f as a JSON number, NaN and infinities are not valid JSON numbers and are returned as strings
+func jsonFloat(f float64, bitSize int) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
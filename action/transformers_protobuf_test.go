@@ -0,0 +1,108 @@
+package action
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) BinJSONAction(action string, in []byte) (any, error) {
+	a, ok := r.m[binFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for bin input", action)
+	}
+	return a.Func(in)
+}
+
+func TestAction_BinProtobufTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(protobufActions...)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{
+			"varint",
+			"089601",
+			`[{"field":1,"type":"varint","value":150,"zigzag":75}]`,
+			false,
+		},
+		{
+			"negative varint",
+			"30ffffffffffffffffff01",
+			`[{"field":6,"int64":-1,"type":"varint","value":18446744073709551615,"zigzag":-9223372036854775808}]`,
+			false,
+		},
+		{
+			"string and nested message",
+			"120774657374696e671a03089601",
+			`[{"field":2,"type":"string","value":"testing"},{"field":3,"type":"message","value":[{"field":1,"type":"varint","value":150,"zigzag":75}]}]`,
+			false,
+		},
+		{
+			// the nested message bytes are " A", a message is preferred like protoc does
+			"printable nested message",
+			"1a022041",
+			`[{"field":3,"type":"message","value":[{"field":4,"type":"varint","value":65,"zigzag":-33}]}]`,
+			false,
+		},
+		{
+			"fixed",
+			"250000c03f290000000000000440",
+			`[{"field":4,"float":1.5,"int32":1069547520,"type":"fixed32","value":1069547520},{"double":2.5,"field":5,"int64":4612811918334230528,"type":"fixed64","value":4612811918334230528}]`,
+			false,
+		},
+		{
+			"bytes",
+			"3a02fffe",
+			`[{"field":7,"type":"bytes","value":"//4="}]`,
+			false,
+		},
+		{
+			"group",
+			"0b089601 0c",
+			`[{"field":1,"type":"group","value":[{"field":1,"type":"varint","value":150,"zigzag":75}]}]`,
+			false,
+		},
+		{
+			"truncated field kept as raw",
+			"0896011205ab",
+			`[{"field":1,"type":"varint","value":150,"zigzag":75},{"error":"field 2: invalid length","offset":3,"type":"raw","value":"EgWr"}]`,
+			false,
+		},
+		{"not protobuf", "ffffffffffffffffffffff", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := hex.DecodeString(stripSpaces(tt.in))
+			require.NoError(t, err)
+
+			got, err := r.BinJSONAction("protobuf", in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			b, err := json.Marshal(got)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(b))
+		})
+	}
+}
+
+func stripSpaces(s string) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != ' ' {
+			out = append(out, s[i])
+		}
+	}
+	return string(out)
+}
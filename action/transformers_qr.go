@@ -0,0 +1,133 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	"github.com/makiuchi-d/gozxing/oned"
+	zxingqr "github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/skip2/go-qrcode"
+)
+
+var qrActions = []Action{qrCodeAction, qrPNGAction, barcodeDecodeAction}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(qrActions...)
+}
+
+// qrLevels are the choices for the error correction, from 7% to 30% of recoverable data
+func qrLevels() []string {
+	return []string{"low", "medium", "high", "highest"}
+}
+
+var qrLevelParam = Param{Name: "level", Doc: "error correction: low, medium, high or highest", Default: "medium", Choices: qrLevels}
+
+var qrCodeAction = Action{
+	Doc:          "Render the text as a QR code with Unicode half blocks",
+	Names:        []string{"qrcode", "qr"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{qrLevelParam},
+	Func: func(in any, args ...string) (any, error) {
+		q, err := newQRCode(in.([]byte), args[0])
+		if err != nil {
+			return nil, err
+		}
+		// dark modules are blank, it scans on dark terminal backgrounds
+		return []byte(strings.TrimSuffix(q.ToSmallString(false), "\n")), nil
+	},
+}
+
+var qrPNGAction = Action{
+	Doc:          "Encode the text as a QR code PNG image",
+	Names:        []string{"qrpng", "qrimage"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: binFormat,
+	Params:       []Param{qrLevelParam, {Name: "size", Doc: "width and height in pixels", Default: "256"}},
+	Func: func(in any, args ...string) (any, error) {
+		q, err := newQRCode(in.([]byte), args[0])
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(args[1]))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid size %q", args[1])
+		}
+		if err := checkImageSize(size, size); err != nil {
+			return nil, err
+		}
+		return q.PNG(size)
+	},
+}
+
+var barcodeDecodeAction = Action{
+	Doc:          "Decode a QR code, Data Matrix, Aztec or 1D barcode in the image",
+	Names:        []string{"decode", "barcode", "qrdecode"},
+	Type:         TransformAction,
+	InputFormat:  imageFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		res, err := decodeBarcode(in.(*Image))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(res.GetText()), nil
+	},
+}
+
+func newQRCode(b []byte, level string) (*qrcode.QRCode, error) {
+	var l qrcode.RecoveryLevel
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "low", "l":
+		l = qrcode.Low
+	case "medium", "m":
+		l = qrcode.Medium
+	case "high", "q":
+		l = qrcode.High
+	case "highest", "h":
+		l = qrcode.Highest
+	default:
+		return nil, fmt.Errorf("invalid error correction level %q", level)
+	}
+	return qrcode.New(string(b), l)
+}
+
+// barcodeReaders are tried in order, the matrix codes first as the 1D readers are more lenient
+func barcodeReaders() []gozxing.Reader {
+	return []gozxing.Reader{
+		zxingqr.NewQRCodeReader(),
+		datamatrix.NewDataMatrixReader(),
+		aztec.NewAztecReader(),
+		oned.NewMultiFormatUPCEANReader(nil),
+		oned.NewCode128Reader(),
+		oned.NewCode39Reader(),
+		oned.NewCode93Reader(),
+		oned.NewCodaBarReader(),
+		oned.NewITFReader(),
+	}
+}
+
+func decodeBarcode(img *Image) (*gozxing.Result, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	for _, r := range barcodeReaders() {
+		res, err := r.Decode(bmp, hints)
+		if err == nil {
+			return res, nil
+		}
+	}
+	return nil, errors.New("no barcode found in the image")
+}
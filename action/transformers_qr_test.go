@@ -0,0 +1,79 @@
+package action
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_TextQRCodeTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(qrActions...)
+	r.RegisterActions(imageActions...)
+
+	tests := []struct {
+		name    string
+		in      string
+		level   string
+		wantErr bool
+	}{
+		{"url", "https://github.com/akhenakh/ovr", "medium", false},
+		{"low", "hello", "low", false},
+		{"highest", "hello", "highest", false},
+		{"short level", "hello", "Q", false},
+		{"unicode", "héllo wörld ✓", "high", false},
+		{"invalid level", "hello", "max", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := r.m[textFormat.Prefix+",qrcode"]
+			got, err := a.Func([]byte(tt.in), tt.level)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			lines := strings.Split(string(got.([]byte)), "\n")
+			// a QR code is square, a line holds two rows of modules
+			require.Equal(t, (len([]rune(lines[0]))+1)/2, len(lines))
+
+			// the PNG export decodes back to the input
+			a = r.m[textFormat.Prefix+",qrpng"]
+			b, err := a.Func([]byte(tt.in), tt.level, "200")
+			require.NoError(t, err)
+			img, err := decodeImage(b.([]byte))
+			require.NoError(t, err)
+			require.Equal(t, "png image 200x200", img.String())
+
+			text, err := r.ImageAction("decode", img)
+			require.NoError(t, err)
+			require.Equal(t, tt.in, string(text.([]byte)))
+		})
+	}
+
+	_, err := r.m[textFormat.Prefix+",qrpng"].Func([]byte("hello"), "medium", "-1")
+	require.Error(t, err)
+	_, err = r.m[textFormat.Prefix+",qrpng"].Func([]byte("hello"), "medium", "100000")
+	require.Error(t, err)
+}
+
+func TestAction_ImageBarcodeTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(qrActions...)
+
+	code128, err := oned.NewCode128Writer().Encode("OVR-0042", gozxing.BarcodeFormat_CODE_128, 200, 50, nil)
+	require.NoError(t, err)
+
+	got, err := r.ImageAction("barcode", &Image{Image: code128, Format: "png", Frames: 1})
+	require.NoError(t, err)
+	require.Equal(t, "OVR-0042", string(got.([]byte)))
+
+	_, err = r.ImageAction("barcode", &Image{Image: image.NewGray(image.Rect(0, 0, 50, 50)), Format: "png", Frames: 1})
+	require.Error(t, err)
+}
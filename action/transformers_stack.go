@@ -0,0 +1,436 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stacks are the goroutines of a Go panic or dump, or the threads of a Java dump or exception
+type Stacks struct {
+	// Lang is go or java
+	Lang    string         `json:"lang"`
+	Panic   string         `json:"panic,omitempty"`
+	Threads []*StackThread `json:"threads"`
+}
+
+// StackThread is a goroutine or a thread, after grouping it stands for all the identical ones
+type StackThread struct {
+	IDs   []string `json:"ids"`
+	Name  string   `json:"name,omitempty"`
+	State string   `json:"state"`
+	// Wait is the wait duration in minutes of a blocked goroutine, WaitMax the longest in a group
+	Wait      int          `json:"wait,omitempty"`
+	WaitMax   int          `json:"wait_max,omitempty"`
+	Locked    bool         `json:"locked,omitempty"`
+	Frames    []StackFrame `json:"frames"`
+	CreatedBy *StackFrame  `json:"created_by,omitempty"`
+}
+
+type StackFrame struct {
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+var (
+	goroutineRe = regexp.MustCompile(`^goroutine (\d+) (?:gp=\S+ m=\S+ (?:mp=\S+ )?)?\[(.*)\]:$`)
+	// goFileRe matches the location of a Go frame, \t/path/file.go:12 +0x1d
+	goFileRe    = regexp.MustCompile(`^\s+(.+?):(\d+)(?: \+0x[0-9a-f]+)?(?: fp=.*)?$`)
+	goCreatedRe = regexp.MustCompile(`^created by (.+?)(?: in goroutine \d+)?$`)
+	goWaitRe    = regexp.MustCompile(`^(\d+) minutes?$`)
+	// javaThreadRe matches a thread header of jstack, "name" #1 daemon prio=5 ... state
+	javaThreadRe = regexp.MustCompile(`^"((?:[^"\\]|\\.)*)"(?: #(\d+))?`)
+	javaStateRe  = regexp.MustCompile(`^\s+java\.lang\.Thread\.State: (.+)$`)
+	javaFrameRe  = regexp.MustCompile(`^\s+at (?:[\w.@$-]+/)*([\w$.<>]+)\.([\w$<>-]+)\((.*)\)$`)
+	// javaExceptionRe matches the first line of an exception, optionally with its thread, and its causes
+	javaExceptionRe = regexp.MustCompile(`^(?:Exception in thread "((?:[^"\\]|\\.)*)" |(Caused by|Suppressed): )?((?:[\w$]+\.)+[\w$]*(?:Exception|Error|Throwable))(?:: (.*))?$`)
+)
+
+var stackActions = []Action{
+	parseStackAction, stackGroupAction, stackFilterAction, stackOwnFrameAction, stackJSONAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(stackActions...)
+}
+
+var parseStackAction = Action{
+	Doc:          "Parse a Go panic or goroutine dump, a Java thread dump or exception",
+	Names:        []string{"stack", "stacktrace", "goroutines"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: stackFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseStacks(string(in.([]byte)))
+	},
+}
+
+var stackGroupAction = Action{
+	Doc:          "Group the identical stacks with their count",
+	Names:        []string{"group", "dedup"},
+	Type:         TransformAction,
+	InputFormat:  stackFormat,
+	OutputFormat: stackFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return groupStacks(in.(*Stacks)), nil
+	},
+}
+
+var stackFilterAction = Action{
+	Doc:          "Keep the stacks with a frame in a package",
+	Names:        []string{"filter", "package"},
+	Type:         TransformAction,
+	InputFormat:  stackFormat,
+	OutputFormat: stackFormat,
+	Params:       []Param{{Name: "package", Doc: "package or package prefix, e.g. net/http or com.example"}},
+	Func: func(in any, args ...string) (any, error) {
+		s := in.(*Stacks)
+		pkg := strings.TrimSpace(args[0])
+		if pkg == "" {
+			return nil, errors.New("a package is required")
+		}
+		ns := &Stacks{Lang: s.Lang, Panic: s.Panic}
+		for _, t := range s.Threads {
+			for _, f := range t.Frames {
+				if inPackage(f.Package, pkg) {
+					ns.Threads = append(ns.Threads, t)
+					break
+				}
+			}
+		}
+		return ns, nil
+	},
+}
+
+var stackOwnFrameAction = Action{
+	Doc:          "Show the first frame of each stack in your own code",
+	Names:        []string{"own", "firstframe"},
+	Type:         TransformAction,
+	InputFormat:  stackFormat,
+	OutputFormat: tableFormat,
+	Params: []Param{{
+		Name: "module",
+		Doc:  "package prefix of your code, the first frame outside the standard library if empty",
+	}},
+	Func: func(in any, args ...string) (any, error) {
+		s := in.(*Stacks)
+		module := strings.TrimSpace(args[0])
+		t := &Table{Header: []string{"ids", "count", "state", "function", "location"}, Delimiter: ','}
+		for _, th := range s.Threads {
+			row := []string{strings.Join(th.IDs, " "), strconv.Itoa(len(th.IDs)), th.state(), "", ""}
+			for _, f := range th.Frames {
+				if module != "" && inPackage(f.Package, module) || module == "" && !isStdPackage(s.Lang, f.Package) {
+					row[3] = f.Package + "." + f.Function
+					row[4] = f.File + ":" + strconv.Itoa(f.Line)
+					break
+				}
+			}
+			t.Rows = append(t.Rows, row)
+		}
+		return t, nil
+	},
+}
+
+var stackJSONAction = Action{
+	Doc:          "Convert to JSON",
+	Names:        []string{"tojson", "json"},
+	Type:         TransformAction,
+	InputFormat:  stackFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return jsonTree(in)
+	},
+}
+
+func (s *Stacks) String() string {
+	var b strings.Builder
+	if s.Panic != "" {
+		b.WriteString(s.Panic + "\n\n")
+	}
+	for i, t := range s.Threads {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(t.header(s.Lang) + "\n")
+		for _, f := range t.Frames {
+			b.WriteString(f.text(s.Lang) + "\n")
+		}
+		if c := t.CreatedBy; c != nil {
+			fmt.Fprintf(&b, "created by %s.%s\n\t%s:%d\n", c.Package, c.Function, c.File, c.Line)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (t *StackThread) state() string {
+	if t.Wait == 0 {
+		return t.State
+	}
+	if t.WaitMax > t.Wait {
+		return fmt.Sprintf("%s, %d-%d minutes", t.State, t.Wait, t.WaitMax)
+	}
+	return fmt.Sprintf("%s, %d minutes", t.State, t.Wait)
+}
+
+func (t *StackThread) header(lang string) string {
+	state := t.state()
+	if t.Locked {
+		state += ", locked to thread"
+	}
+	if lang == "java" {
+		name := t.Name
+		if len(t.IDs) > 1 {
+			name = fmt.Sprintf("%d threads: %s", len(t.IDs), t.Name)
+		}
+		return fmt.Sprintf("%q %s", name, state)
+	}
+	if len(t.IDs) > 1 {
+		return fmt.Sprintf("%d goroutines [%s]: %s", len(t.IDs), state, strings.Join(t.IDs, " "))
+	}
+	return fmt.Sprintf("goroutine %s [%s]:", strings.Join(t.IDs, " "), state)
+}
+
+func (f StackFrame) text(lang string) string {
+	if lang == "java" {
+		loc := f.File
+		if f.Line > 0 {
+			loc += ":" + strconv.Itoa(f.Line)
+		}
+		return fmt.Sprintf("\tat %s.%s(%s)", f.Package, f.Function, loc)
+	}
+	name := f.Function
+	if f.Package != "" {
+		name = f.Package + "." + f.Function
+	}
+	return fmt.Sprintf("%s(...)\n\t%s:%d", name, f.File, f.Line)
+}
+
+// inPackage returns true if pkg is prefix or a sub package of prefix
+func inPackage(pkg, prefix string) bool {
+	if !strings.HasPrefix(pkg, prefix) {
+		return false
+	}
+	rest := pkg[len(prefix):]
+	return rest == "" || rest[0] == '/' || rest[0] == '.' || strings.HasSuffix(prefix, "/") || strings.HasSuffix(prefix, ".")
+}
+
+// isStdPackage returns true for packages of the runtime and the standard library
+func isStdPackage(lang, pkg string) bool {
+	if lang == "java" {
+		for _, p := range []string{"java.", "javax.", "jdk.", "sun.", "com.sun."} {
+			if strings.HasPrefix(pkg, p) {
+				return true
+			}
+		}
+		return false
+	}
+	// standard packages have no dot in their first path element
+	first, _, _ := strings.Cut(pkg, "/")
+	return pkg != "main" && !strings.Contains(first, ".")
+}
+
+func parseStacks(s string) (*Stacks, error) {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for _, l := range lines {
+		if goroutineRe.MatchString(strings.TrimSpace(l)) {
+			return parseGoStacks(lines)
+		}
+	}
+	return parseJavaStacks(lines)
+}
+
+func parseGoStacks(lines []string) (*Stacks, error) {
+	st := &Stacks{Lang: "go"}
+	var cur *StackThread
+	var panicLines []string
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " ")
+		trimmed := strings.TrimSpace(line)
+
+		if m := goroutineRe.FindStringSubmatch(trimmed); m != nil {
+			cur = &StackThread{IDs: []string{m[1]}}
+			for j, part := range strings.Split(m[2], ", ") {
+				switch wm := goWaitRe.FindStringSubmatch(part); {
+				case j == 0:
+					cur.State = part
+				case wm != nil:
+					cur.Wait, _ = strconv.Atoi(wm[1])
+				case part == "locked to thread":
+					cur.Locked = true
+				default:
+					cur.State += ", " + part
+				}
+			}
+			st.Threads = append(st.Threads, cur)
+			continue
+		}
+
+		if cur == nil {
+			// the panic message and the fatal error precede the goroutines
+			if trimmed != "" && !strings.HasPrefix(trimmed, "[signal ") {
+				panicLines = append(panicLines, trimmed)
+			}
+			continue
+		}
+		if trimmed == "" {
+			cur = nil
+			continue
+		}
+		if strings.HasPrefix(trimmed, "...") {
+			continue
+		}
+
+		// a function line is followed by its location
+		if i+1 >= len(lines) {
+			break
+		}
+		fm := goFileRe.FindStringSubmatch(lines[i+1])
+		if fm == nil {
+			continue
+		}
+		i++
+		n, _ := strconv.Atoi(fm[2])
+
+		if cm := goCreatedRe.FindStringSubmatch(trimmed); cm != nil {
+			pkg, fn := splitGoFunc(cm[1])
+			cur.CreatedBy = &StackFrame{Package: pkg, Function: fn, File: fm[1], Line: n}
+			continue
+		}
+		name := trimmed
+		if j := strings.LastIndex(name, "("); j > 0 && strings.HasSuffix(name, ")") {
+			name = name[:j]
+		}
+		pkg, fn := splitGoFunc(name)
+		cur.Frames = append(cur.Frames, StackFrame{Package: pkg, Function: fn, File: fm[1], Line: n})
+	}
+
+	if len(st.Threads) == 0 {
+		return nil, errors.New("no goroutine found")
+	}
+	st.Panic = strings.Join(panicLines, "\n")
+	return st, nil
+}
+
+// splitGoFunc splits a qualified function, net/http.(*Server).Serve is net/http and (*Server).Serve
+func splitGoFunc(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
+
+func parseJavaStacks(lines []string) (*Stacks, error) {
+	st := &Stacks{Lang: "java"}
+	var cur *StackThread
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \r")
+		trimmed := strings.TrimSpace(line)
+
+		if m := javaThreadRe.FindStringSubmatch(line); m != nil {
+			id := m[2]
+			if id == "" {
+				id = strconv.Itoa(len(st.Threads) + 1)
+			}
+			cur = &StackThread{IDs: []string{id}, Name: m[1]}
+			st.Threads = append(st.Threads, cur)
+			continue
+		}
+		if m := javaStateRe.FindStringSubmatch(line); m != nil {
+			if cur != nil {
+				cur.State = m[1]
+			}
+			continue
+		}
+		if m := javaExceptionRe.FindStringSubmatch(trimmed); m != nil {
+			// an exception is a thread, its causes are stacks of their own
+			name := "exception"
+			switch {
+			case m[1] != "":
+				name = m[1]
+			case m[2] != "":
+				name = strings.ToLower(m[2])
+			}
+			cur = &StackThread{IDs: []string{strconv.Itoa(len(st.Threads) + 1)}, Name: name, State: m[3]}
+			if m[4] != "" {
+				cur.State += ": " + m[4]
+			}
+			st.Threads = append(st.Threads, cur)
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if m := javaFrameRe.FindStringSubmatch(line); m != nil {
+			// the class is part of the package to keep the frames qualified
+			f := StackFrame{Package: m[1], Function: m[2], File: m[3]}
+			// the location may start with the module, java.base@17/Thread.java:1
+			if i := strings.LastIndex(f.File, "/"); i >= 0 {
+				f.File = f.File[i+1:]
+			}
+			if file, n, ok := strings.Cut(f.File, ":"); ok {
+				f.File = file
+				f.Line, _ = strconv.Atoi(n)
+			}
+			cur.Frames = append(cur.Frames, f)
+		}
+	}
+
+	if len(st.Threads) == 0 {
+		return nil, errors.New("no goroutine or thread found")
+	}
+	return st, nil
+}
+
+// groupStacks merges the threads with the same state and frames, like panicparse
+func groupStacks(s *Stacks) *Stacks {
+	ns := &Stacks{Lang: s.Lang, Panic: s.Panic}
+	groups := make(map[string]*StackThread)
+	for _, t := range s.Threads {
+		key := t.key()
+		g, ok := groups[key]
+		if !ok {
+			g = &StackThread{
+				Name: t.Name, State: t.State, Wait: t.Wait, WaitMax: max(t.Wait, t.WaitMax), Locked: t.Locked,
+				Frames: t.Frames, CreatedBy: t.CreatedBy,
+			}
+			groups[key] = g
+			ns.Threads = append(ns.Threads, g)
+		} else {
+			g.Wait = min(g.Wait, t.Wait)
+			g.WaitMax = max(g.WaitMax, t.WaitMax, t.Wait)
+		}
+		g.IDs = append(g.IDs, t.IDs...)
+	}
+
+	for _, g := range ns.Threads {
+		if g.WaitMax <= g.Wait {
+			g.WaitMax = 0
+		}
+	}
+	// biggest groups first, the order of the dump is kept otherwise
+	sort.SliceStable(ns.Threads, func(i, j int) bool { return len(ns.Threads[i].IDs) > len(ns.Threads[j].IDs) })
+	return ns
+}
+
+// key identifies identical stacks, the wait duration and the ids are ignored
+func (t *StackThread) key() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%t|", t.State, t.Locked)
+	for _, f := range t.Frames {
+		fmt.Fprintf(&b, "%s.%s %s:%d|", f.Package, f.Function, f.File, f.Line)
+	}
+	if t.CreatedBy != nil {
+		fmt.Fprintf(&b, "created %s.%s %s:%d", t.CreatedBy.Package, t.CreatedBy.Function, t.CreatedBy.File, t.CreatedBy.Line)
+	}
+	return b.String()
+}
@@ -0,0 +1,289 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) StacksAction(action string, in *Stacks, args ...string) (any, error) {
+	a, ok := r.m[stackFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for stack input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+const testGoPanic = `panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a8e5d]
+
+goroutine 1 [running]:
+github.com/akhenakh/ovr/action.(*Data).String(0x0)
+	/src/ovr/action/data.go:120 +0x1d
+main.main()
+	/src/ovr/cmd/ovr/main.go:42 +0x25
+
+goroutine 18 [chan receive, 5 minutes]:
+net/http.(*conn).serve(0xc000120000, {0x6f1e28, 0xc00007e0f0})
+	/usr/local/go/src/net/http/server.go:2009 +0x65d
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/local/go/src/net/http/server.go:3086 +0x5cb
+
+goroutine 19 [chan receive, 12 minutes]:
+net/http.(*conn).serve(0xc000120090, {0x6f1e28, 0xc00007e0f0})
+	/usr/local/go/src/net/http/server.go:2009 +0x65d
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/local/go/src/net/http/server.go:3086 +0x5cb
+
+goroutine 20 [select, locked to thread]:
+runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)
+	/usr/local/go/src/runtime/proc.go:398 +0xce
+github.com/akhenakh/ovr/worker.Run(...)
+	/src/ovr/worker/run.go:12
+exit status 2
+`
+
+const testJavaDump = `Full thread dump OpenJDK 64-Bit Server VM (17.0.2+8-86 mixed mode, sharing):
+
+"main" #1 prio=5 os_prio=0 cpu=45.12ms elapsed=12.34s tid=0x00007f1c1c025000 nid=0x1a03 waiting on condition  [0x00007f1c22fe4000]
+   java.lang.Thread.State: TIMED_WAITING (sleeping)
+	at java.lang.Thread.sleep(java.base@17.0.2/Native Method)
+	at com.example.App.main(App.java:10)
+
+"pool-1-thread-1" #12 prio=5 os_prio=0 tid=0x00007f1c1c2a1000 nid=0x1a10 waiting on condition  [0x00007f1bf8efe000]
+   java.lang.Thread.State: WAITING (parking)
+	at jdk.internal.misc.Unsafe.park(java.base@17.0.2/Native Method)
+	- parking to wait for  <0x000000062a4a0b48> (a java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject)
+	at java.util.concurrent.LinkedBlockingQueue.take(java.base@17.0.2/LinkedBlockingQueue.java:435)
+
+"pool-1-thread-2" #13 prio=5 os_prio=0 tid=0x00007f1c1c2a2000 nid=0x1a11 waiting on condition  [0x00007f1bf8dfd000]
+   java.lang.Thread.State: WAITING (parking)
+	at jdk.internal.misc.Unsafe.park(java.base@17.0.2/Native Method)
+	- parking to wait for  <0x000000062a4a0b48> (a java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject)
+	at java.util.concurrent.LinkedBlockingQueue.take(java.base@17.0.2/LinkedBlockingQueue.java:435)
+`
+
+const testJavaException = `Exception in thread "main" java.lang.IllegalStateException: boom
+	at com.example.Service.run(Service.java:42)
+	at com.example.App.main(App.java:10)
+Caused by: java.io.IOException: disk full
+	at java.io.FileOutputStream.write(FileOutputStream.java:326)
+	... 2 more
+`
+
+func TestAction_TextStackTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(stackActions...)
+
+	got, err := r.m[textFormat.Prefix+",stack"].Func([]byte(testGoPanic))
+	require.NoError(t, err)
+	s := got.(*Stacks)
+	require.Equal(t, "go", s.Lang)
+	require.Equal(t, "panic: runtime error: invalid memory address or nil pointer dereference", s.Panic)
+	require.Len(t, s.Threads, 4)
+
+	require.Equal(t, &StackThread{
+		IDs: []string{"18"}, State: "chan receive", Wait: 5,
+		Frames: []StackFrame{{Package: "net/http", Function: "(*conn).serve", File: "/usr/local/go/src/net/http/server.go", Line: 2009}},
+		CreatedBy: &StackFrame{
+			Package: "net/http", Function: "(*Server).Serve", File: "/usr/local/go/src/net/http/server.go", Line: 3086,
+		},
+	}, s.Threads[1])
+	require.Equal(t, StackFrame{
+		Package: "github.com/akhenakh/ovr/action", Function: "(*Data).String", File: "/src/ovr/action/data.go", Line: 120,
+	}, s.Threads[0].Frames[0])
+	require.True(t, s.Threads[3].Locked)
+	require.Equal(t, "select", s.Threads[3].State)
+	require.Len(t, s.Threads[3].Frames, 2)
+
+	got, err = r.m[textFormat.Prefix+",stack"].Func([]byte(testJavaDump))
+	require.NoError(t, err)
+	s = got.(*Stacks)
+	require.Equal(t, "java", s.Lang)
+	require.Len(t, s.Threads, 3)
+	require.Equal(t, &StackThread{
+		IDs: []string{"1"}, Name: "main", State: "TIMED_WAITING (sleeping)",
+		Frames: []StackFrame{
+			{Package: "java.lang.Thread", Function: "sleep", File: "Native Method"},
+			{Package: "com.example.App", Function: "main", File: "App.java", Line: 10},
+		},
+	}, s.Threads[0])
+
+	got, err = r.m[textFormat.Prefix+",stack"].Func([]byte(testJavaException))
+	require.NoError(t, err)
+	s = got.(*Stacks)
+	require.Len(t, s.Threads, 2)
+	require.Equal(t, "main", s.Threads[0].Name)
+	require.Equal(t, "java.lang.IllegalStateException: boom", s.Threads[0].State)
+	require.Len(t, s.Threads[0].Frames, 2)
+	require.Equal(t, "caused by", s.Threads[1].Name)
+	require.Equal(t, "java.io.IOException: disk full", s.Threads[1].State)
+	require.Equal(t, []StackFrame{{Package: "java.io.FileOutputStream", Function: "write", File: "FileOutputStream.java", Line: 326}}, s.Threads[1].Frames)
+
+	_, err = r.m[textFormat.Prefix+",stack"].Func([]byte("hello world"))
+	require.Error(t, err)
+}
+
+func TestAction_StackTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(stackActions...)
+
+	goStacks, err := parseStacks(testGoPanic)
+	require.NoError(t, err)
+	javaStacks, err := parseStacks(testJavaDump)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      *Stacks
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{
+			"group go", "group", goStacks, nil,
+			`panic: runtime error: invalid memory address or nil pointer dereference
+
+2 goroutines [chan receive, 5-12 minutes]: 18 19
+net/http.(*conn).serve(...)
+	/usr/local/go/src/net/http/server.go:2009
+created by net/http.(*Server).Serve
+	/usr/local/go/src/net/http/server.go:3086
+
+goroutine 1 [running]:
+github.com/akhenakh/ovr/action.(*Data).String(...)
+	/src/ovr/action/data.go:120
+main.main(...)
+	/src/ovr/cmd/ovr/main.go:42
+
+goroutine 20 [select, locked to thread]:
+runtime.gopark(...)
+	/usr/local/go/src/runtime/proc.go:398
+github.com/akhenakh/ovr/worker.Run(...)
+	/src/ovr/worker/run.go:12`, false,
+		},
+		{
+			"group java", "group", javaStacks, nil,
+			`"2 threads: pool-1-thread-1" WAITING (parking)
+	at jdk.internal.misc.Unsafe.park(Native Method)
+	at java.util.concurrent.LinkedBlockingQueue.take(LinkedBlockingQueue.java:435)
+
+"main" TIMED_WAITING (sleeping)
+	at java.lang.Thread.sleep(Native Method)
+	at com.example.App.main(App.java:10)`, false,
+		},
+		{
+			"filter", "filter", goStacks, []string{"github.com/akhenakh/ovr"},
+			`panic: runtime error: invalid memory address or nil pointer dereference
+
+goroutine 1 [running]:
+github.com/akhenakh/ovr/action.(*Data).String(...)
+	/src/ovr/action/data.go:120
+main.main(...)
+	/src/ovr/cmd/ovr/main.go:42
+
+goroutine 20 [select, locked to thread]:
+runtime.gopark(...)
+	/usr/local/go/src/runtime/proc.go:398
+github.com/akhenakh/ovr/worker.Run(...)
+	/src/ovr/worker/run.go:12`, false,
+		},
+		{
+			"filter java", "filter", javaStacks, []string{"com.example"},
+			`"main" TIMED_WAITING (sleeping)
+	at java.lang.Thread.sleep(Native Method)
+	at com.example.App.main(App.java:10)`, false,
+		},
+		{"filter prefix only", "filter", goStacks, []string{"net/htt"}, "", false},
+		{"filter empty", "filter", goStacks, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.StacksAction(tt.action, tt.in, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			s := got.(*Stacks)
+			if tt.want == "" {
+				require.Empty(t, s.Threads)
+				return
+			}
+			require.Equal(t, tt.want, s.String())
+		})
+	}
+
+	// the first goroutine of a group waits the longest
+	swapped := strings.Replace(testGoPanic, "5 minutes]", "10 minutes]", 1)
+	swapped = strings.Replace(swapped, "12 minutes]", "5 minutes]", 1)
+	swappedStacks, err := parseStacks(swapped)
+	require.NoError(t, err)
+	got, err := r.StacksAction("group", swappedStacks)
+	require.NoError(t, err)
+	require.Contains(t, got.(*Stacks).String(), "2 goroutines [chan receive, 5-10 minutes]: 18 19")
+}
+
+func TestAction_StackOwnFrameTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(stackActions...)
+
+	goStacks, err := parseStacks(testGoPanic)
+	require.NoError(t, err)
+	javaStacks, err := parseStacks(testJavaDump)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		in     *Stacks
+		module string
+		want   [][]string
+	}{
+		{
+			"guess", groupStacks(goStacks), "",
+			[][]string{
+				{"18 19", "2", "chan receive, 5-12 minutes", "", ""},
+				{"1", "1", "running", "github.com/akhenakh/ovr/action.(*Data).String", "/src/ovr/action/data.go:120"},
+				{"20", "1", "select", "github.com/akhenakh/ovr/worker.Run", "/src/ovr/worker/run.go:12"},
+			},
+		},
+		{
+			"module", goStacks, "main",
+			[][]string{
+				{"1", "1", "running", "main.main", "/src/ovr/cmd/ovr/main.go:42"},
+				{"18", "1", "chan receive, 5 minutes", "", ""},
+				{"19", "1", "chan receive, 12 minutes", "", ""},
+				{"20", "1", "select", "", ""},
+			},
+		},
+		{
+			"java", javaStacks, "",
+			[][]string{
+				{"1", "1", "TIMED_WAITING (sleeping)", "com.example.App.main", "App.java:10"},
+				{"12", "1", "WAITING (parking)", "", ""},
+				{"13", "1", "WAITING (parking)", "", ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.StacksAction("own", tt.in, tt.module)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.(*Table).Rows)
+		})
+	}
+
+	got, err := r.StacksAction("tojson", groupStacks(goStacks))
+	require.NoError(t, err)
+	m := got.(map[string]any)
+	require.Equal(t, "go", m["lang"])
+	threads := m["threads"].([]any)
+	require.Len(t, threads, 3)
+	require.Equal(t, []any{"18", "19"}, threads[0].(map[string]any)["ids"])
+}
@@ -0,0 +1,777 @@
+package action
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table holds rows of cells, Header is empty when the input has no header row
+type Table struct {
+	Header    []string
+	Rows      [][]string
+	Delimiter rune
+}
+
+var tableActions = []Action{
+	parseCSVAction, parseTSVAction, jsonTableAction,
+	tableToCSVAction, tableToTSVAction, tableToMarkdownAction, tableToJSONAction, tableCountAction,
+	tableSelectAction, tableDropAction, tableSortAction, tableReverseAction, tableFilterAction,
+	tableDedupAction, tableTransposeAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(tableActions...)
+}
+
+var headerParam = Param{Name: "header", Doc: "the first row is a header (yes/no)", Default: "yes"}
+
+var parseCSVAction = Action{
+	Doc:          "Parse CSV from input into a table, the delimiter (, ; tab |) is detected",
+	Names:        []string{"csv"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{headerParam},
+	Func: func(in any, args ...string) (any, error) {
+		header, err := parseBoolArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		b := in.([]byte)
+		return parseTable(b, detectDelimiter(b), header)
+	},
+}
+
+var parseTSVAction = Action{
+	Doc:          "Parse tab separated values from input into a table",
+	Names:        []string{"tsv"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{headerParam},
+	Func: func(in any, args ...string) (any, error) {
+		header, err := parseBoolArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return parseTable(in.([]byte), '\t', header)
+	},
+}
+
+var jsonTableAction = Action{
+	Doc:          "Transforms a JSON array of objects or of arrays into a table",
+	Names:        []string{"table"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		l, ok := in.([]any)
+		if !ok {
+			return nil, errors.New("not a JSON array")
+		}
+		return jsonToTable(l)
+	},
+}
+
+var tableToCSVAction = Action{
+	Doc:          "Transforms a table to CSV",
+	Names:        []string{"tocsv"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return in.(*Table).CSV(','), nil
+	},
+}
+
+var tableToTSVAction = Action{
+	Doc:          "Transforms a table to tab separated values",
+	Names:        []string{"totsv"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return in.(*Table).CSV('\t'), nil
+	},
+}
+
+var tableToMarkdownAction = Action{
+	Doc:          "Transforms a table to a markdown table",
+	Names:        []string{"tomarkdown"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return in.(*Table).Markdown(), nil
+	},
+}
+
+var tableToJSONAction = Action{
+	Doc:          "Transforms a table to a JSON array of objects using the header as keys, or an array of arrays without header",
+	Names:        []string{"tojson"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := in.(*Table)
+		l := make([]any, len(t.Rows))
+		for i, row := range t.Rows {
+			if len(t.Header) == 0 {
+				cells := make([]any, len(row))
+				for j, c := range row {
+					cells[j] = c
+				}
+				l[i] = cells
+				continue
+			}
+			o := make(map[string]any, len(t.Header))
+			for j, h := range t.Header {
+				o[h] = t.cell(row, j)
+			}
+			l[i] = o
+		}
+		return l, nil
+	},
+}
+
+var tableCountAction = Action{
+	Doc:          "Count the rows of a table",
+	Names:        []string{"count"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(strconv.Itoa(len(in.(*Table).Rows))), nil
+	},
+}
+
+var tableSelectAction = Action{
+	Doc:          "Select and reorder columns",
+	Names:        []string{"select", "columns"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{{Name: "columns", Doc: "comma separated column names or 1-based indexes"}},
+	Func: func(in any, args ...string) (any, error) {
+		t := in.(*Table)
+		cols, err := t.columns(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return t.project(cols), nil
+	},
+}
+
+var tableDropAction = Action{
+	Doc:          "Drop columns",
+	Names:        []string{"drop"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: tableFormat,
+	Params:       []Param{{Name: "columns", Doc: "comma separated column names or 1-based indexes"}},
+	Func: func(in any, args ...string) (any, error) {
+		t := in.(*Table)
+		dropped, err := t.columns(args[0])
+		if err != nil {
+			return nil, err
+		}
+		var cols []int
+	next:
+		for i := 0; i < t.Width(); i++ {
+			for _, d := range dropped {
+				if i == d {
+					continue next
+				}
+			}
+			cols = append(cols, i)
+		}
+		return t.project(cols), nil
+	},
+}
+
+var tableSortAction = Action{
+	Doc:          "Sort rows by a column, use reverse for a descending order",
+	Names:        []string{"sort"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: tableFormat,
+	Params: []Param{
+		{Name: "column", Doc: "column name or 1-based index", Default: "1"},
+		{Name: "mode", Doc: "natural, numeric or lexical", Default: "natural"},
+	},
+	Func: func(in any, args ...string) (any, error) {
+		t := in.(*Table)
+		col, err := t.column(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		var less func(a, b string) bool
+		switch args[1] {
+		case "natural":
+			less = naturalLess
+		case "lexical":
+			less = func(a, b string) bool { return a < b }
+		case "numeric":
+			// non numeric cells are sorted last
+			less = func(a, b string) bool {
+				fa, erra := strconv.ParseFloat(strings.TrimSpace(a), 64)
+				fb, errb := strconv.ParseFloat(strings.TrimSpace(b), 64)
+				switch {
+				case erra != nil && errb != nil:
+					return a < b
+				case erra != nil:
+					return false
+				case errb != nil:
+					return true
+				}
+				return fa < fb
+			}
+		default:
+			return nil, fmt.Errorf("unknown sort mode %q", args[1])
+		}
+
+		nt := t.clone()
+		sort.SliceStable(nt.Rows, func(i, j int) bool {
+			return less(nt.cell(nt.Rows[i], col), nt.cell(nt.Rows[j], col))
+		})
+		return nt, nil
+	},
+}
+
+var tableReverseAction = Action{
+	Doc:          "Reverse the order of the rows",
+	Names:        []string{"reverse"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		nt := in.(*Table).clone()
+		for i, j := 0, len(nt.Rows)-1; i < j; i, j = i+1, j-1 {
+			nt.Rows[i], nt.Rows[j] = nt.Rows[j], nt.Rows[i]
+		}
+		return nt, nil
+	},
+}
+
+var tableFilterAction = Action{
+	Doc:          "Keep rows matching an expression, e.g. age >= 18 && name ~ \"^A\"",
+	Names:        []string{"filter", "where"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: tableFormat,
+	Params: []Param{{
+		Name: "expression",
+		Doc:  "column op value, op one of == != < <= > >= ~ !~ (regexp), combined with && and ||, columns by name or $index",
+	}},
+	Func: func(in any, args ...string) (any, error) {
+		t := in.(*Table)
+		match, err := parseRowFilter(t, args[0])
+		if err != nil {
+			return nil, err
+		}
+		nt := &Table{Header: t.Header, Delimiter: t.Delimiter}
+		for _, row := range t.Rows {
+			if match(row) {
+				nt.Rows = append(nt.Rows, row)
+			}
+		}
+		return nt, nil
+	},
+}
+
+var tableDedupAction = Action{
+	Doc:          "Remove duplicated rows, keeping the first one",
+	Names:        []string{"dedup", "uniq"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := in.(*Table)
+		seen := make(map[string]struct{}, len(t.Rows))
+		nt := &Table{Header: t.Header, Delimiter: t.Delimiter}
+		for _, row := range t.Rows {
+			k := strings.Join(row, "\x00")
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			nt.Rows = append(nt.Rows, row)
+		}
+		return nt, nil
+	},
+}
+
+var tableTransposeAction = Action{
+	Doc:          "Transpose rows and columns, the header becomes the first column",
+	Names:        []string{"transpose"},
+	Type:         TransformAction,
+	InputFormat:  tableFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := in.(*Table)
+		rows := t.Rows
+		if len(t.Header) > 0 {
+			rows = append([][]string{t.Header}, rows...)
+		}
+		nt := &Table{Delimiter: t.Delimiter, Rows: make([][]string, t.Width())}
+		for i := range nt.Rows {
+			nt.Rows[i] = make([]string, len(rows))
+			for j, row := range rows {
+				nt.Rows[i][j] = t.cell(row, i)
+			}
+		}
+		return nt, nil
+	},
+}
+
+// Width returns the number of columns of the widest row or header
+func (t *Table) Width() int {
+	w := len(t.Header)
+	for _, row := range t.Rows {
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	return w
+}
+
+// CSV encodes the table, header included, using delim as separator
+func (t *Table) CSV(delim rune) []byte {
+	if delim == 0 {
+		delim = ','
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = delim
+	if len(t.Header) > 0 {
+		_ = w.Write(t.Header)
+	}
+	_ = w.WriteAll(t.Rows)
+	return buf.Bytes()
+}
+
+// Markdown encodes the table as a markdown table, 1-based column numbers are used as header when missing
+func (t *Table) Markdown() []byte {
+	width := t.Width()
+	header := t.Header
+	if len(header) == 0 {
+		header = make([]string, width)
+		for i := range header {
+			header[i] = strconv.Itoa(i + 1)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeRow := func(row []string) {
+		buf.WriteString("|")
+		for i := 0; i < width; i++ {
+			buf.WriteString(" ")
+			buf.WriteString(strings.ReplaceAll(t.cell(row, i), "|", "\\|"))
+			buf.WriteString(" |")
+		}
+		buf.WriteString("\n")
+	}
+
+	writeRow(header)
+	buf.WriteString("|")
+	for i := 0; i < width; i++ {
+		buf.WriteString(" --- |")
+	}
+	buf.WriteString("\n")
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	return buf.Bytes()
+}
+
+// cell returns the cell i of row, or an empty string for short rows
+func (t *Table) cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// column returns the 0-based index of the column named or numbered (1-based, $2 or 2) by spec
+func (t *Table) column(spec string) (int, error) {
+	spec = strings.TrimSpace(spec)
+	for i, h := range t.Header {
+		if h == spec {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(strings.TrimPrefix(spec, "$")); err == nil {
+		if i < 1 || i > t.Width() {
+			return 0, fmt.Errorf("column %d out of range 1-%d", i, t.Width())
+		}
+		return i - 1, nil
+	}
+	for i, h := range t.Header {
+		if strings.EqualFold(h, spec) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown column %q", spec)
+}
+
+// columns returns the indexes of a comma separated list of columns
+func (t *Table) columns(spec string) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, errors.New("no column given")
+	}
+	var cols []int
+	for _, s := range strings.Split(spec, ",") {
+		c, err := t.column(s)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// project returns a new table with only cols in that order
+func (t *Table) project(cols []int) *Table {
+	nt := &Table{Delimiter: t.Delimiter, Rows: make([][]string, len(t.Rows))}
+	if len(t.Header) > 0 {
+		nt.Header = make([]string, len(cols))
+		for i, c := range cols {
+			nt.Header[i] = t.cell(t.Header, c)
+		}
+	}
+	for i, row := range t.Rows {
+		nt.Rows[i] = make([]string, len(cols))
+		for j, c := range cols {
+			nt.Rows[i][j] = t.cell(row, c)
+		}
+	}
+	return nt
+}
+
+// clone returns a copy of the table, sharing the rows content
+func (t *Table) clone() *Table {
+	rows := make([][]string, len(t.Rows))
+	copy(rows, t.Rows)
+	return &Table{Header: t.Header, Rows: rows, Delimiter: t.Delimiter}
+}
+
+// parseTable reads delimited values from b
+func parseTable(b []byte, delim rune, header bool) (*Table, error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no rows")
+	}
+
+	t := &Table{Rows: rows, Delimiter: delim}
+	if header {
+		t.Header, t.Rows = rows[0], rows[1:]
+	}
+	return t, nil
+}
+
+// detectDelimiter returns the candidate delimiter found the same number of times on the first lines,
+// defaults to ,
+func detectDelimiter(b []byte) rune {
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) > 10 {
+		lines = lines[:10]
+	}
+
+	best, bestCount := ',', 0
+	for _, d := range []rune{',', '\t', ';', '|'} {
+		count := -1
+		for _, l := range lines {
+			c := strings.Count(l, string(d))
+			if count == -1 {
+				count = c
+			}
+			if c != count {
+				count = 0
+				break
+			}
+		}
+		if count > bestCount {
+			best, bestCount = d, count
+		}
+	}
+	return best
+}
+
+// jsonToTable converts a JSON array of objects, using the union of the keys as header,
+// or a JSON array of arrays
+func jsonToTable(l []any) (*Table, error) {
+	t := &Table{Delimiter: ','}
+	if len(l) == 0 {
+		return t, nil
+	}
+
+	if _, ok := l[0].(map[string]any); ok {
+		keys := make(map[string]struct{})
+		for _, e := range l {
+			o, ok := e.(map[string]any)
+			if !ok {
+				return nil, errors.New("mixed objects and non objects in the array")
+			}
+			for k := range o {
+				if _, ok := keys[k]; !ok {
+					keys[k] = struct{}{}
+					t.Header = append(t.Header, k)
+				}
+			}
+		}
+		sort.Strings(t.Header)
+		for _, e := range l {
+			o := e.(map[string]any)
+			row := make([]string, len(t.Header))
+			for i, h := range t.Header {
+				row[i] = jsonCell(o[h])
+			}
+			t.Rows = append(t.Rows, row)
+		}
+		return t, nil
+	}
+
+	for _, e := range l {
+		a, ok := e.([]any)
+		if !ok {
+			return nil, errors.New("expecting an array of objects or an array of arrays")
+		}
+		row := make([]string, len(a))
+		for i, v := range a {
+			row[i] = jsonCell(v)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, nil
+}
+
+// jsonCell returns the text of a scalar JSON value, or its compact JSON
+func jsonCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// naturalLess compares strings with their embedded numbers compared by value: a2 < a10
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ca, cb := a[0], b[0]
+		if isDigit(ca) && isDigit(cb) {
+			na, ra := splitDigits(a)
+			nb, rb := splitDigits(b)
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			a, b = ra, rb
+			continue
+		}
+		if ca != cb {
+			return ca < cb
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func splitDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseBoolArg parses yes/no and the values accepted by strconv.ParseBool
+func parseBoolArg(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// parseRowFilter compiles a filter expression: conditions "column op value" joined by && and ||,
+// && binds tighter than ||
+func parseRowFilter(t *Table, expr string) (func(row []string) bool, error) {
+	toks, err := filterTokens(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, errors.New("empty expression")
+	}
+
+	var ors [][]func(row []string) bool
+	var ands []func(row []string) bool
+	for len(toks) > 0 {
+		if len(toks) < 3 {
+			return nil, fmt.Errorf("incomplete condition %q", strings.Join(toks, " "))
+		}
+		cond, err := rowCondition(t, toks[0], toks[1], toks[2])
+		if err != nil {
+			return nil, err
+		}
+		ands = append(ands, cond)
+		toks = toks[3:]
+
+		if len(toks) == 0 {
+			break
+		}
+		switch toks[0] {
+		case "&&":
+		case "||":
+			ors = append(ors, ands)
+			ands = nil
+		default:
+			return nil, fmt.Errorf("expecting && or || got %q", toks[0])
+		}
+		toks = toks[1:]
+		if len(toks) == 0 {
+			return nil, errors.New("expression ends with an operator")
+		}
+	}
+	ors = append(ors, ands)
+
+	return func(row []string) bool {
+	or:
+		for _, ands := range ors {
+			for _, cond := range ands {
+				if !cond(row) {
+					continue or
+				}
+			}
+			return true
+		}
+		return false
+	}, nil
+}
+
+// rowCondition compiles a single comparison, numbers are compared by value
+func rowCondition(t *Table, column, op, value string) (func(row []string) bool, error) {
+	col, err := t.column(unquoteToken(column))
+	if err != nil {
+		return nil, err
+	}
+	value = unquoteToken(value)
+
+	if op == "~" || op == "!~" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []string) bool {
+			return re.MatchString(t.cell(row, col)) == (op == "~")
+		}, nil
+	}
+
+	var cmp func(c int) bool
+	switch op {
+	case "=", "==":
+		cmp = func(c int) bool { return c == 0 }
+	case "!=":
+		cmp = func(c int) bool { return c != 0 }
+	case "<":
+		cmp = func(c int) bool { return c < 0 }
+	case "<=":
+		cmp = func(c int) bool { return c <= 0 }
+	case ">":
+		cmp = func(c int) bool { return c > 0 }
+	case ">=":
+		cmp = func(c int) bool { return c >= 0 }
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+
+	fv, verr := strconv.ParseFloat(value, 64)
+	return func(row []string) bool {
+		cell := t.cell(row, col)
+		if fc, err := strconv.ParseFloat(strings.TrimSpace(cell), 64); err == nil && verr == nil {
+			switch {
+			case fc < fv:
+				return cmp(-1)
+			case fc > fv:
+				return cmp(1)
+			}
+			return cmp(0)
+		}
+		return cmp(strings.Compare(cell, value))
+	}, nil
+}
+
+// filterTokens splits a filter expression into words, quoted strings and operators
+func filterTokens(expr string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			toks = append(toks, expr[i:i+end+2])
+			i += end + 2
+		case strings.ContainsRune("=!<>~&|", rune(c)):
+			j := i + 1
+			for j < len(expr) && strings.ContainsRune("=!<>~&|", rune(expr[j])) {
+				j++
+			}
+			toks = append(toks, expr[i:j])
+			i = j
+		default:
+			j := i + 1
+			for j < len(expr) && !strings.ContainsRune(" \t\"'=!<>~&|", rune(expr[j])) {
+				j++
+			}
+			toks = append(toks, expr[i:j])
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+func unquoteToken(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) TextTableAction(action string, in []byte, args ...string) (*Table, error) {
+	a, ok := r.m[textFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for text input", action)
+	}
+	a = a.WithArgs(args...)
+	ab, err := a.Func(in, a.args()...)
+	t, _ := ab.(*Table)
+	return t, err
+}
+
+func (r *ActionRegistry) TableAction(action string, in *Table, args ...string) (any, error) {
+	a, ok := r.m[tableFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for table input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextTableTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(tableActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		args    []string
+		want    *Table
+		wantErr bool
+	}{
+		{
+			"comma",
+			"csv",
+			"name,age\nbob,42\nalice,7\n",
+			nil,
+			&Table{Header: []string{"name", "age"}, Rows: [][]string{{"bob", "42"}, {"alice", "7"}}, Delimiter: ','},
+			false,
+		},
+		{
+			"semicolon no header",
+			"csv",
+			"a;b;c,d\ne;f;g,h\n",
+			[]string{"no"},
+			&Table{Rows: [][]string{{"a", "b", "c,d"}, {"e", "f", "g,h"}}, Delimiter: ';'},
+			false,
+		},
+		{
+			"tsv",
+			"tsv",
+			"a\tb\n1\t2\n",
+			nil,
+			&Table{Header: []string{"a", "b"}, Rows: [][]string{{"1", "2"}}, Delimiter: '\t'},
+			false,
+		},
+		{"bad header option", "csv", "a,b\n", []string{"maybe"}, nil, true},
+		{"empty", "csv", "\n", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.TextTableAction(tt.action, []byte(tt.in), tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAction_TableTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(tableActions...)
+
+	people := &Table{
+		Header: []string{"name", "age", "file"},
+		Rows: [][]string{
+			{"bob", "42", "img10.png"},
+			{"alice", "7", "img2.png"},
+			{"carol", "130", "img1.png"},
+			{"alice", "7", "img2.png"},
+		},
+		Delimiter: ',',
+	}
+
+	tests := []struct {
+		name    string
+		action  string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"select", "select", []string{"3,name"}, "file,name\nimg10.png,bob\nimg2.png,alice\nimg1.png,carol\nimg2.png,alice\n", false},
+		{"select unknown", "select", []string{"email"}, "", true},
+		{"drop", "drop", []string{"2,file"}, "name\nbob\nalice\ncarol\nalice\n", false},
+		{"sort natural", "sort", []string{"file"}, "name,age,file\ncarol,130,img1.png\nalice,7,img2.png\nalice,7,img2.png\nbob,42,img10.png\n", false},
+		{"sort numeric", "sort", []string{"age", "numeric"}, "name,age,file\nalice,7,img2.png\nalice,7,img2.png\nbob,42,img10.png\ncarol,130,img1.png\n", false},
+		{"sort lexical", "sort", []string{"2", "lexical"}, "name,age,file\ncarol,130,img1.png\nbob,42,img10.png\nalice,7,img2.png\nalice,7,img2.png\n", false},
+		{"sort unknown mode", "sort", []string{"2", "random"}, "", true},
+		{"reverse", "reverse", nil, "name,age,file\nalice,7,img2.png\ncarol,130,img1.png\nalice,7,img2.png\nbob,42,img10.png\n", false},
+		{"filter numeric", "filter", []string{"age >= 42"}, "name,age,file\nbob,42,img10.png\ncarol,130,img1.png\n", false},
+		{"filter and or", "filter", []string{`name ~ "^a" && age < 10 || $3 == 'img1.png'`}, "name,age,file\nalice,7,img2.png\ncarol,130,img1.png\nalice,7,img2.png\n", false},
+		{"filter index", "filter", []string{"1 != bob"}, "name,age,file\nalice,7,img2.png\ncarol,130,img1.png\nalice,7,img2.png\n", false},
+		{"filter incomplete", "filter", []string{"age >="}, "", true},
+		{"dedup", "dedup", nil, "name,age,file\nbob,42,img10.png\nalice,7,img2.png\ncarol,130,img1.png\n", false},
+		{"transpose", "transpose", nil, "name,bob,alice,carol,alice\nage,42,7,130,7\nfile,img10.png,img2.png,img1.png,img2.png\n", false},
+		{"count", "count", nil, "4", false},
+		{"tomarkdown", "tomarkdown", nil, "| name | age | file |\n| --- | --- | --- |\n| bob | 42 | img10.png |\n| alice | 7 | img2.png |\n| carol | 130 | img1.png |\n| alice | 7 | img2.png |\n", false},
+		{"totsv", "totsv", nil, "name\tage\tfile\nbob\t42\timg10.png\nalice\t7\timg2.png\ncarol\t130\timg1.png\nalice\t7\timg2.png\n", false},
+		{"tojson", "tojson", nil, `[{"age":"42","file":"img10.png","name":"bob"},{"age":"7","file":"img2.png","name":"alice"},{"age":"130","file":"img1.png","name":"carol"},{"age":"7","file":"img2.png","name":"alice"}]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.TableAction(tt.action, people, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch v := got.(type) {
+			case *Table:
+				require.Equal(t, tt.want, string(v.CSV(',')))
+			case []byte:
+				require.Equal(t, tt.want, string(v))
+			default:
+				b, err := json.Marshal(v)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(b))
+			}
+		})
+	}
+}
+
+func TestAction_JSONTableTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(tableActions...)
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{`[{"b":1,"a":"x"},{"a":"y","c":[1]}]`, "a,b,c\nx,1,\ny,,[1]\n", false},
+		{`[[1,2],[3]]`, "1,2\n3\n", false},
+		{`{"a":1}`, "", true},
+		{`[1,2]`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := decodeJSON([]byte(tt.in))
+			require.NoError(t, err)
+
+			a := r.m[jsonFormat.Prefix+",table"]
+			got, err := a.Func(v)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(got.(*Table).CSV(',')))
+			}
+		})
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	require.True(t, naturalLess("a2", "a10"))
+	require.True(t, naturalLess("a02", "a10"))
+	require.False(t, naturalLess("a10", "a2"))
+	require.True(t, naturalLess("abc", "abd"))
+	require.True(t, naturalLess("ab", "abc"))
+}
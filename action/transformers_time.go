@@ -0,0 +1,421 @@
+package action
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// worldClockEnv is the environment variable listing the world clock zones, separated by commas
+const worldClockEnv = "OVR_WORLDCLOCK"
+
+var timeActions = []Action{
+	parseTimeAction, parseTimeLayoutAction, timeFormatAction, timeTruncateAction, timeRoundAction,
+	timeZoneAction, worldClockAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(timeActions...)
+}
+
+var parseTimeAction = Action{
+	Doc:          "Parse time detecting the format: RFC3339, RFC1123, RFC822, syslog, epochs in s, ms, µs or ns... times without zone are UTC",
+	Names:        []string{"time"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseTimeText(string(in.([]byte)))
+	},
+}
+
+var parseTimeLayoutAction = Action{
+	Doc:          "Parse time using a strftime (%Y-%m-%d) or Go (2006-01-02) layout",
+	Names:        []string{"strptime", "parsetime"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Params:       []Param{{Name: "layout", Doc: "strftime or Go layout", Default: "%Y-%m-%d %H:%M:%S"}},
+	Func: func(in any, args ...string) (any, error) {
+		layout, err := goLayout(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return time.Parse(layout, strings.TrimSpace(string(in.([]byte))))
+	},
+}
+
+var timeFormatAction = Action{
+	Doc:          "Format time using a strftime (%Y-%m-%d) or Go (2006-01-02) layout",
+	Names:        []string{"format", "strftime"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "layout", Doc: "strftime or Go layout", Default: time.RFC3339}},
+	Func: func(in any, args ...string) (any, error) {
+		layout, err := goLayout(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return []byte(in.(time.Time).Format(layout)), nil
+	},
+}
+
+var timeTruncateAction = Action{
+	Doc:          "Truncate time to a unit, in its timezone",
+	Names:        []string{"truncate"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: timeFormat,
+	Params:       []Param{{Name: "unit", Doc: "ms, s, m, h, day, month or year", Default: "h"}},
+	Func: func(in any, args ...string) (any, error) {
+		t, _, err := truncateTime(in.(time.Time), args[0])
+		return t, err
+	},
+}
+
+var timeRoundAction = Action{
+	Doc:          "Round time to the nearest unit, in its timezone, half up",
+	Names:        []string{"round"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: timeFormat,
+	Params:       []Param{{Name: "unit", Doc: "ms, s, m, h, day, month or year", Default: "h"}},
+	Func: func(in any, args ...string) (any, error) {
+		t := in.(time.Time)
+		start, next, err := truncateTime(t, args[0])
+		if err != nil {
+			return nil, err
+		}
+		if t.Sub(start) >= next.Sub(t) {
+			return next, nil
+		}
+		return start, nil
+	},
+}
+
+var timeZoneAction = Action{
+	Doc:          "Change time to a timezone",
+	Names:        []string{"tz", "timezone"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: timeFormat,
+	Params: []Param{{
+		Name:    "zone",
+		Doc:     "IANA timezone, e.g. Europe/Paris, or Local",
+		Default: "UTC",
+		Choices: TimeZones,
+	}},
+	Func: func(in any, args ...string) (any, error) {
+		loc, err := loadLocation(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return in.(time.Time).In(loc), nil
+	},
+}
+
+var worldClockAction = Action{
+	Doc:          "Show time in several timezones, default zones are read from " + worldClockEnv,
+	Names:        []string{"worldclock", "zones"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: tableFormat,
+	Params: []Param{{
+		Name:    "zones",
+		Doc:     "IANA timezones separated by commas",
+		Default: worldClockZones(),
+	}},
+	Func: func(in any, args ...string) (any, error) {
+		t := in.(time.Time)
+		tb := &Table{Header: []string{"zone", "time", "abbreviation", "offset", "dst"}, Delimiter: ','}
+		for _, name := range strings.Split(args[0], ",") {
+			loc, err := loadLocation(name)
+			if err != nil {
+				return nil, err
+			}
+			lt := t.In(loc)
+			abbr, offset := lt.Zone()
+			dst := ""
+			if lt.IsDST() {
+				dst = "DST"
+			}
+			tb.Rows = append(tb.Rows, []string{
+				loc.String(), lt.Format("Mon 2006-01-02 15:04:05"), abbr, formatOffset(offset), dst,
+			})
+		}
+		return tb, nil
+	},
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+	time.DateOnly,
+	time.RFC1123Z, time.RFC1123, time.RFC850, time.RFC822Z, time.RFC822,
+	time.ANSIC, time.UnixDate, time.RubyDate,
+	// Apache common log
+	"02/Jan/2006:15:04:05 -0700",
+}
+
+// syslogLayout has no year
+const syslogLayout = "Jan _2 15:04:05.999999999"
+
+// epochRe matches a number of seconds, milliseconds, microseconds or nanoseconds since Epoch
+var epochRe = regexp.MustCompile(`^-?(\d+)(\.\d+)?$`)
+
+// parseTimeText parses a time in a common layout or as an Epoch,
+// the Epoch unit is detected by the number of digits
+func parseTimeText(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if m := epochRe.FindStringSubmatch(s); m != nil {
+		return parseEpoch(s, len(m[1]), m[2] != "")
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	// syslog timestamps are in the current year, unless it would be in the future
+	if t, err := time.Parse(syslogLayout, strings.Join(strings.Fields(s), " ")); err == nil {
+		now := time.Now().UTC()
+		t = t.AddDate(now.Year(), 0, 0)
+		if t.After(now.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unknown time format %q", s)
+}
+
+// parseEpoch parses an Epoch, up to 11 integer digits are seconds, 14 milliseconds, 17 microseconds,
+// more are nanoseconds
+func parseEpoch(s string, digits int, fraction bool) (time.Time, error) {
+	unit := time.Second
+	switch {
+	case fraction || digits <= 11:
+	case digits <= 14:
+		unit = time.Millisecond
+	case digits <= 17:
+		unit = time.Microsecond
+	default:
+		unit = time.Nanosecond
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid Epoch %q", s)
+	}
+	r.Mul(r, big.NewRat(int64(unit), 1))
+	ns := new(big.Int).Quo(r.Num(), r.Denom())
+	if !ns.IsInt64() {
+		return time.Time{}, errors.New("Epoch out of range")
+	}
+	return time.Unix(0, ns.Int64()), nil
+}
+
+var strftimeDirectives = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'f': "000000", 'L': "000", 'N': "000000000", 'p': "PM",
+	'b': "Jan", 'h': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'z': "-0700", 'Z': "MST",
+	'F': "2006-01-02", 'T': "15:04:05", 'D': "01/02/06", 'R': "15:04", 'c': time.ANSIC,
+	'%': "%",
+}
+
+// goLayout returns a Go layout for a strftime layout, layouts without % are Go layouts
+func goLayout(layout string) (string, error) {
+	if !strings.Contains(layout, "%") {
+		return layout, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			b.WriteByte(layout[i])
+			continue
+		}
+		if i+1 == len(layout) {
+			return "", errors.New("layout ends with %")
+		}
+		i++
+		d, ok := strftimeDirectives[layout[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c", layout[i])
+		}
+		b.WriteString(d)
+	}
+	return b.String(), nil
+}
+
+// truncateTime returns t truncated to a calendar unit, and the start of the next unit
+func truncateTime(t time.Time, unit string) (start, next time.Time, err error) {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	ns := t.Nanosecond()
+	loc := t.Location()
+
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "ms":
+		start = time.Date(y, mo, d, h, mi, s, ns-ns%int(time.Millisecond), loc)
+		return start, start.Add(time.Millisecond), nil
+	case "s":
+		start = time.Date(y, mo, d, h, mi, s, 0, loc)
+		return start, start.Add(time.Second), nil
+	case "m":
+		start = time.Date(y, mo, d, h, mi, 0, 0, loc)
+		return start, start.Add(time.Minute), nil
+	case "h":
+		start = time.Date(y, mo, d, h, 0, 0, 0, loc)
+		return start, start.Add(time.Hour), nil
+	case "d", "day":
+		start = time.Date(y, mo, d, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 1), nil
+	case "month":
+		start = time.Date(y, mo, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0), nil
+	case "y", "year":
+		start = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown unit %q", unit)
+}
+
+// zoneinfoDirs are the usual locations of the IANA timezone database
+var zoneinfoDirs = []string{
+	"/usr/share/zoneinfo/",
+	"/usr/share/lib/zoneinfo/",
+	"/usr/lib/locale/TZ/",
+	"/etc/zoneinfo/",
+}
+
+var (
+	timeZones     []string
+	timeZonesOnce sync.Once
+)
+
+// TimeZones returns the names of the IANA timezones found on the system, Local first
+func TimeZones() []string {
+	timeZonesOnce.Do(func() {
+		seen := make(map[string]bool)
+		dirs := zoneinfoDirs
+		if dir := os.Getenv("ZONEINFO"); dir != "" {
+			dirs = append([]string{dir}, dirs...)
+		}
+		for _, dir := range dirs {
+			_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				name, _ := filepath.Rel(dir, path)
+				// posix and right are copies of the database with other leap seconds handling
+				if d.IsDir() && (name == "posix" || name == "right") {
+					return filepath.SkipDir
+				}
+				if d.IsDir() || !isZoneName(name) {
+					return nil
+				}
+				if isTZif(path) {
+					seen[filepath.ToSlash(name)] = true
+				}
+				return nil
+			})
+		}
+
+		// the Go distribution embeds a copy of the database
+		if len(seen) == 0 {
+			if z, err := zip.OpenReader(filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip")); err == nil {
+				for _, f := range z.File {
+					if isZoneName(f.Name) {
+						seen[f.Name] = true
+					}
+				}
+				z.Close()
+			}
+		}
+
+		for name := range seen {
+			timeZones = append(timeZones, name)
+		}
+		sort.Strings(timeZones)
+		timeZones = append([]string{"Local"}, timeZones...)
+	})
+	return timeZones
+}
+
+// isZoneName reports if name looks like a zone name and not one of the database extra files
+func isZoneName(name string) bool {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' || strings.Contains(name, ".") {
+		return false
+	}
+	return name != "Factory" && name != "SECURITY" && name != "README"
+}
+
+// isTZif reports if the file at path is a TZif zone file
+func isTZif(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := f.Read(magic); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, []byte("TZif"))
+}
+
+// loadLocation loads an IANA timezone, names are case insensitive if the zone is known
+func loadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc, nil
+	}
+	for _, z := range TimeZones() {
+		if strings.EqualFold(z, name) {
+			return time.LoadLocation(z)
+		}
+	}
+	return nil, fmt.Errorf("unknown timezone %q", name)
+}
+
+// worldClockZones returns the zones configured in the environment or a default set
+func worldClockZones() string {
+	if zones := os.Getenv(worldClockEnv); zones != "" {
+		return zones
+	}
+	return "America/Los_Angeles,America/New_York,UTC,Europe/London,Europe/Paris,Asia/Kolkata,Asia/Tokyo"
+}
+
+// formatOffset returns an UTC offset in seconds as +hh:mm
+func formatOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
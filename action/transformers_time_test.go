@@ -0,0 +1,157 @@
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_TextTimeParse(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(timeActions...)
+
+	want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		args    []string
+		want    time.Time
+		wantErr bool
+	}{
+		{"rfc3339", "time", "2024-01-02T15:04:05Z", nil, want, false},
+		{"rfc3339 offset", "time", "2024-01-02T16:04:05+01:00", nil, want, false},
+		{"rfc3339 nano", "time", "2024-01-02T15:04:05.123456789Z", nil, want.Add(123456789), false},
+		{"no zone", "time", "2024-01-02 15:04:05", nil, want, false},
+		{"no zone fraction", "time", "2024-01-02 15:04:05.5", nil, want.Add(500 * time.Millisecond), false},
+		{"go time string", "time", "2024-01-02 15:04:05 +0000 UTC", nil, want, false},
+		{"date", "time", "2024-01-02", nil, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		{"rfc1123", "time", "Tue, 02 Jan 2024 15:04:05 GMT", nil, want, false},
+		{"rfc1123z", "time", "Tue, 02 Jan 2024 16:04:05 +0100", nil, want, false},
+		{"rfc822z", "time", "02 Jan 24 16:04 +0100", nil, want.Add(-5 * time.Second), false},
+		{"unix date", "time", "Tue Jan  2 15:04:05 UTC 2024", nil, want, false},
+		{"common log", "time", "02/Jan/2024:16:04:05 +0100", nil, want, false},
+		{"seconds", "time", "1704207845", nil, want, false},
+		{"seconds fraction", "time", "1704207845.25", nil, want.Add(250 * time.Millisecond), false},
+		{"milliseconds", "time", "1704207845123", nil, want.Add(123 * time.Millisecond), false},
+		{"microseconds", "time", "1704207845123456", nil, want.Add(123456 * time.Microsecond), false},
+		{"nanoseconds", "time", "1704207845123456789", nil, want.Add(123456789), false},
+		{"invalid", "time", "the day after", nil, time.Time{}, true},
+		{"strptime", "strptime", "02/01/2024 15h04", []string{"%d/%m/%Y %Hh%M"}, want.Add(-5 * time.Second), false},
+		{"strptime default", "strptime", "2024-01-02 15:04:05", nil, want, false},
+		{"strptime go layout", "strptime", "2024.01.02 15:04:05", []string{"2006.01.02 15:04:05"}, want, false},
+		{"strptime unsupported", "strptime", "2024", []string{"%Q"}, time.Time{}, true},
+		{"strptime mismatch", "strptime", "2024", []string{"%Y-%m"}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := r.m[textFormat.Prefix+","+tt.action]
+			require.True(t, ok)
+			got, err := a.Func([]byte(tt.in), a.WithArgs(tt.args...).args()...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tt.want.Equal(got.(time.Time)), "got %v", got)
+		})
+	}
+}
+
+func TestAction_TextTimeParseSyslog(t *testing.T) {
+	got, err := parseTimeText("Jan  2 15:04:05")
+	require.NoError(t, err)
+	require.Equal(t, time.January, got.Month())
+	require.Equal(t, 2, got.Day())
+	require.Equal(t, 15, got.Hour())
+	require.False(t, got.After(time.Now().Add(24*time.Hour)))
+}
+
+func TestAction_TimeLayoutTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(timeActions...)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	in := time.Date(2024, 1, 31, 15, 44, 35, 600000000, time.UTC)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      time.Time
+		args    []string
+		want    any
+		wantErr bool
+	}{
+		{"format default", "format", in, nil, "2024-01-31T15:44:35Z", false},
+		{"format strftime", "format", in, []string{"%a %d %b %Y %H:%M:%S.%L %%"}, "Wed 31 Jan 2024 15:44:35.600 %", false},
+		{"format go", "format", in.In(paris), []string{time.Kitchen + " MST"}, "4:44PM CET", false},
+		{"format trailing %", "format", in, []string{"%Y%"}, nil, true},
+		{"truncate default", "truncate", in, nil, time.Date(2024, 1, 31, 15, 0, 0, 0, time.UTC), false},
+		{"truncate seconds", "truncate", in, []string{"s"}, time.Date(2024, 1, 31, 15, 44, 35, 0, time.UTC), false},
+		{"truncate day in zone", "truncate", in.In(paris), []string{"day"}, time.Date(2024, 1, 31, 0, 0, 0, 0, paris), false},
+		{"truncate hour half zone", "truncate", in.In(kolkata), []string{"h"}, time.Date(2024, 1, 31, 21, 0, 0, 0, kolkata), false},
+		{"truncate month", "truncate", in, []string{"month"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"truncate unknown", "truncate", in, []string{"fortnight"}, nil, true},
+		{"round default", "round", in, nil, time.Date(2024, 1, 31, 16, 0, 0, 0, time.UTC), false},
+		{"round seconds", "round", in, []string{"s"}, time.Date(2024, 1, 31, 15, 44, 36, 0, time.UTC), false},
+		{"round day", "round", in, []string{"day"}, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), false},
+		{"round year", "round", in, []string{"year"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := r.m[timeFormat.Prefix+","+tt.action]
+			require.True(t, ok)
+			got, err := a.Func(tt.in, a.WithArgs(tt.args...).args()...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch want := tt.want.(type) {
+			case string:
+				require.Equal(t, want, string(got.([]byte)))
+			case time.Time:
+				require.True(t, want.Equal(got.(time.Time)), "got %v", got)
+			}
+		})
+	}
+}
+
+func TestAction_TimeWorldClockTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(timeActions...)
+
+	a := r.m[timeFormat.Prefix+",worldclock"]
+
+	in := time.Date(2024, 7, 2, 15, 4, 5, 0, time.UTC)
+	got, err := a.Func(in, "America/New_York,Asia/Kolkata")
+	require.NoError(t, err)
+	require.Equal(t, &Table{
+		Header: []string{"zone", "time", "abbreviation", "offset", "dst"},
+		Rows: [][]string{
+			{"America/New_York", "Tue 2024-07-02 11:04:05", "EDT", "-04:00", "DST"},
+			{"Asia/Kolkata", "Tue 2024-07-02 20:34:05", "IST", "+05:30", ""},
+		},
+		Delimiter: ',',
+	}, got)
+
+	_, err = a.Func(in, "Europe/Paris,Mars/Olympus")
+	require.Error(t, err)
+}
+
+func TestTimeZones(t *testing.T) {
+	zones := TimeZones()
+	require.Equal(t, "Local", zones[0])
+	require.Contains(t, zones, "Europe/Paris")
+	require.NotContains(t, zones, "posix/Europe/Paris")
+	require.NotContains(t, zones, "zone1970.tab")
+}
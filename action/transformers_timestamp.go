@@ -0,0 +1,378 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exotic timestamps, counting units from an epoch, all are UTC.
+// Each is parsed from text to time and formatted from time to text with the same name.
+
+var (
+	windowsEpoch = time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)
+	dotNetEpoch  = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	excelEpoch   = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	gpsEpoch     = time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC)
+	ntpEpoch     = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+	julianEpoch  = time.Date(-4713, 11, 24, 12, 0, 0, 0, time.UTC)
+	cocoaEpoch   = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// excelLeapBug is the first day after the 1900-02-29 Lotus 1-2-3 believed to exist
+	excelLeapBug = time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// gpsLeapSeconds are the UTC dates when GPS time got one more second ahead of UTC
+var gpsLeapSeconds = []time.Time{
+	time.Date(1981, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1982, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1983, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1985, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1992, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1993, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1994, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1997, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+var timestampActions = []Action{
+	parseFileTimeAction, fileTimeAction, parseTicksAction, ticksAction,
+	parseExcelAction, excelAction, parseGPSAction, gpsAction, parseNTPAction, ntpAction,
+	parseJulianAction, julianAction, parseCocoaAction, cocoaAction, parseWebKitAction, webKitAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(timestampActions...)
+}
+
+var parseFileTimeAction = Action{
+	Doc:          "Parse Windows FILETIME, 100ns intervals since 1601-01-01",
+	Names:        []string{"filetime"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseUnits(string(in.([]byte)), windowsEpoch, 100)
+	},
+}
+
+var fileTimeAction = Action{
+	Doc:          "time to Windows FILETIME, 100ns intervals since 1601-01-01",
+	Names:        []string{"filetime"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(formatUnits(in.(time.Time), windowsEpoch, 100, true)), nil
+	},
+}
+
+var parseTicksAction = Action{
+	Doc:          "Parse .NET ticks, 100ns intervals since 0001-01-01",
+	Names:        []string{"ticks", "dotnet"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseUnits(string(in.([]byte)), dotNetEpoch, 100)
+	},
+}
+
+var ticksAction = Action{
+	Doc:          "time to .NET ticks, 100ns intervals since 0001-01-01",
+	Names:        []string{"ticks", "dotnet"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(formatUnits(in.(time.Time), dotNetEpoch, 100, true)), nil
+	},
+}
+
+var parseExcelAction = Action{
+	Doc:          "Parse Excel/Lotus serial date, days since 1899-12-30 with the time as fraction",
+	Names:        []string{"excel", "lotus"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t, err := parseUnits(string(in.([]byte)), excelEpoch, int64(day))
+		if err != nil {
+			return nil, err
+		}
+		// serials before 61 count the 1900-02-29 that does not exist
+		if t.Before(excelLeapBug) {
+			if !t.Before(excelLeapBug.AddDate(0, 0, -1)) {
+				return nil, errors.New("serial 60 is 1900-02-29, a day that does not exist")
+			}
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	},
+}
+
+var excelAction = Action{
+	Doc:          "time to Excel/Lotus serial date, days since 1899-12-30 with the time as fraction",
+	Names:        []string{"excel", "lotus"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := in.(time.Time)
+		if t.Before(excelLeapBug) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return []byte(formatUnits(t, excelEpoch, int64(day), false)), nil
+	},
+}
+
+var parseGPSAction = Action{
+	Doc:          "Parse GPS time, week and seconds of week since 1980-01-06 or seconds, leap seconds are removed",
+	Names:        []string{"gps"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		fields := strings.FieldsFunc(string(in.([]byte)), func(r rune) bool {
+			return r == ' ' || r == ':' || r == ',' || r == '\t' || r == '\n'
+		})
+
+		var secs string
+		switch len(fields) {
+		case 1:
+			secs = fields[0]
+		case 2:
+			w, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid GPS week %q", fields[0])
+			}
+			sow, err := parseNumber(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			sowRat, err := sow.rat()
+			if err != nil {
+				return nil, err
+			}
+			r := new(big.Rat).Add(sowRat, new(big.Rat).SetInt64(w*int64(week/time.Second)))
+			secs = r.FloatString(9)
+		default:
+			return nil, errors.New("expecting a GPS week and seconds of week")
+		}
+
+		t, err := parseUnits(secs, gpsEpoch, int64(time.Second))
+		if err != nil {
+			return nil, err
+		}
+		// GPS time ignores leap seconds, it is ahead of UTC
+		return t.Add(-gpsLeap(t.Add(-gpsLeap(t)))), nil
+	},
+}
+
+var gpsAction = Action{
+	Doc:          "time to GPS week and seconds of week",
+	Names:        []string{"gps"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := in.(time.Time)
+		if t.Before(gpsEpoch) {
+			return nil, errors.New("time before the GPS epoch")
+		}
+		d := t.Add(gpsLeap(t)).Sub(gpsEpoch)
+		weeks := d / week
+		sow := d - weeks*week
+		return []byte(fmt.Sprintf("%d %s", weeks, strconv.FormatFloat(sow.Seconds(), 'f', -1, 64))), nil
+	},
+}
+
+var parseNTPAction = Action{
+	Doc:          "Parse NTP 64 bits timestamp, as seconds.fraction in hex (e93c6a85.3a3d70a3) or a 64 bits integer",
+	Names:        []string{"ntp"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		s := strings.TrimSpace(string(in.([]byte)))
+
+		var v uint64
+		if sec, frac, ok := strings.Cut(s, "."); ok {
+			hs, err := strconv.ParseUint(strings.TrimPrefix(sec, "0x"), 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NTP seconds %q", sec)
+			}
+			hf, err := strconv.ParseUint(frac, 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NTP fraction %q", frac)
+			}
+			v = hs<<32 | hf
+		} else {
+			var err error
+			v, err = strconv.ParseUint(s, 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NTP timestamp %q", s)
+			}
+		}
+		return ntpTime(v), nil
+	},
+}
+
+var ntpAction = Action{
+	Doc:          "time to NTP 64 bits timestamp, as seconds.fraction in hex",
+	Names:        []string{"ntp"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		t := in.(time.Time)
+		secs := t.Unix() - ntpEpoch.Unix()
+		if secs < 0 || secs >= 2<<32 {
+			return nil, errors.New("time out of the NTP eras 0 and 1")
+		}
+		frac := (uint64(t.Nanosecond())<<32 + uint64(time.Second)/2) / uint64(time.Second)
+		return []byte(fmt.Sprintf("%08x.%08x", uint32(secs), uint32(frac))), nil
+	},
+}
+
+var parseJulianAction = Action{
+	Doc:          "Parse Julian day number, days since -4713-11-24 12:00 with the time as fraction",
+	Names:        []string{"julian", "jd"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseUnits(string(in.([]byte)), julianEpoch, int64(day))
+	},
+}
+
+var julianAction = Action{
+	Doc:          "time to Julian day number, days since -4713-11-24 12:00 with the time as fraction",
+	Names:        []string{"julian", "jd"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(formatUnits(in.(time.Time), julianEpoch, int64(day), false)), nil
+	},
+}
+
+var parseCocoaAction = Action{
+	Doc:          "Parse Apple Cocoa (Core Data) time, seconds since 2001-01-01",
+	Names:        []string{"cocoa", "coredata"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseUnits(string(in.([]byte)), cocoaEpoch, int64(time.Second))
+	},
+}
+
+var cocoaAction = Action{
+	Doc:          "time to Apple Cocoa (Core Data) time, seconds since 2001-01-01",
+	Names:        []string{"cocoa", "coredata"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(formatUnits(in.(time.Time), cocoaEpoch, int64(time.Second), false)), nil
+	},
+}
+
+var parseWebKitAction = Action{
+	Doc:          "Parse Chrome/WebKit time, microseconds since 1601-01-01",
+	Names:        []string{"webkit", "chrome"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseUnits(string(in.([]byte)), windowsEpoch, int64(time.Microsecond))
+	},
+}
+
+var webKitAction = Action{
+	Doc:          "time to Chrome/WebKit time, microseconds since 1601-01-01",
+	Names:        []string{"webkit", "chrome"},
+	Type:         TransformAction,
+	InputFormat:  timeFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(formatUnits(in.(time.Time), windowsEpoch, int64(time.Microsecond), true)), nil
+	},
+}
+
+var nsPerSecond = big.NewInt(int64(time.Second))
+
+// parseUnits parses a number of units of unitNs nanoseconds since epoch
+func parseUnits(s string, epoch time.Time, unitNs int64) (time.Time, error) {
+	n, err := parseNumber(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	units, err := n.rat()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", s, err)
+	}
+	r := new(big.Rat).Mul(units, new(big.Rat).SetInt64(unitNs))
+	ns := new(big.Int).Quo(r.Num(), r.Denom())
+	ns.Add(ns, new(big.Int).Mul(big.NewInt(epoch.Unix()), nsPerSecond))
+
+	// Euclidean division keeps the nanoseconds positive before 1970
+	sec, nsec := new(big.Int).DivMod(ns, nsPerSecond, new(big.Int))
+	if !sec.IsInt64() {
+		return time.Time{}, errors.New("time out of range")
+	}
+	return time.Unix(sec.Int64(), nsec.Int64()).UTC(), nil
+}
+
+// formatUnits returns the number of units of unitNs nanoseconds since epoch,
+// truncated if integer is set
+func formatUnits(t, epoch time.Time, unitNs int64, integer bool) string {
+	ns := new(big.Int).Mul(big.NewInt(t.Unix()-epoch.Unix()), nsPerSecond)
+	ns.Add(ns, big.NewInt(int64(t.Nanosecond()-epoch.Nanosecond())))
+
+	if integer {
+		// floor, so times before epoch are not rounded toward it
+		q, _ := new(big.Int).DivMod(ns, big.NewInt(unitNs), new(big.Int))
+		return q.String()
+	}
+	f, _ := new(big.Rat).SetFrac(ns, big.NewInt(unitNs)).Float64()
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// gpsLeap returns the number of leap seconds between GPS time and UTC at t
+func gpsLeap(t time.Time) time.Duration {
+	var n time.Duration
+	for _, l := range gpsLeapSeconds {
+		if !t.Before(l) {
+			n++
+		}
+	}
+	return n * time.Second
+}
+
+// ntpTime returns the time of a 64 bits NTP timestamp,
+// seconds with the high bit unset are in era 1, after 2036
+func ntpTime(v uint64) time.Time {
+	secs := int64(v >> 32)
+	if secs < 1<<31 {
+		secs += 1 << 32
+	}
+	nsec := ((v&0xffffffff)*uint64(time.Second) + 1<<31) >> 32
+	return time.Unix(ntpEpoch.Unix()+secs, int64(nsec)).UTC()
+}
@@ -0,0 +1,105 @@
+package action
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_TextTimestampTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(timestampActions...)
+
+	want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"filetime", "filetime", "133486814450000000", want, false},
+		{"filetime hex", "filetime", "0x1DA3D8CED7DA080", want, false},
+		{"ticks", "ticks", "638398046450000000", want, false},
+		{"excel", "excel", "45293.62783564815", want, false},
+		{"excel day", "excel", "45293", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		{"excel 1900", "excel", "1", time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"excel leap bug", "excel", "61", time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"excel missing day", "excel", "60", time.Time{}, true},
+		{"gps", "gps", "2295 227063", want, false},
+		{"gps seconds", "gps", "1388243063", want, false},
+		{"gps invalid", "gps", "2295 227063 1", time.Time{}, true},
+		{"ntp", "ntp", "e93ea465.40000000", want.Add(250 * time.Millisecond), false},
+		{"ntp integer", "ntp", "0xe93ea46540000000", want.Add(250 * time.Millisecond), false},
+		{"ntp era 1", "ntp", "00000000.00000000", time.Date(2036, 2, 7, 6, 28, 16, 0, time.UTC), false},
+		{"ntp invalid", "ntp", "e93ea465.xyz", time.Time{}, true},
+		{"julian", "julian", "2460312.127835648", want, false},
+		{"julian epoch", "julian", "2440587.5", time.Unix(0, 0), false},
+		{"cocoa", "cocoa", "725900645", want, false},
+		{"webkit", "webkit", "13348681445000000", want, false},
+		{"invalid", "webkit", "yesterday", time.Time{}, true},
+		{"filetime infinite", "filetime", "inf", time.Time{}, true},
+		{"excel infinite", "excel", "-inf", time.Time{}, true},
+		{"gps infinite seconds of week", "gps", "2295 +Inf", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.TextTimeAction(tt.action, []byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			// day units written as float are precise to about 100µs
+			require.WithinDuration(t, tt.want, got, time.Millisecond, "got %v", got)
+		})
+	}
+
+	// an infinite number built otherwise is not the epoch
+	_, err := (&Number{Float: new(big.Float).SetInf(false)}).rat()
+	require.Error(t, err)
+}
+
+func TestAction_TimestampTextTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(timestampActions...)
+
+	in := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      time.Time
+		want    string
+		wantErr bool
+	}{
+		{"filetime", "filetime", in, "133486814450000000", false},
+		{"ticks", "ticks", in, "638398046450000000", false},
+		{"excel", "excel", in, "45293.62783564815", false},
+		{"excel 1900", "excel", time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), "1", false},
+		{"gps", "gps", in, "2295 227063", false},
+		{"gps before epoch", "gps", time.Unix(0, 0), "", true},
+		{"ntp", "ntp", in.Add(250 * time.Millisecond), "e93ea465.40000000", false},
+		{"julian", "julian", in, "2460312.127835648", false},
+		{"cocoa", "cocoa", in, "725900645", false},
+		{"cocoa fraction", "cocoa", in.Add(-500 * time.Millisecond), "725900644.5", false},
+		{"webkit", "webkit", in, "13348681445000000", false},
+		{"webkit before epoch", "webkit", time.Date(1600, 12, 31, 23, 59, 59, 999999000, time.UTC), "-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.TimeTextAction(tt.action, tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
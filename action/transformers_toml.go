@@ -0,0 +1,231 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+var tomlActions = []Action{
+	parseTOMLAction, toTOMLAction, parseHCLAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(tomlActions...)
+}
+
+var parseTOMLAction = Action{
+	Doc:          "Parse TOML from input into a JSON tree",
+	Names:        []string{"toml"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var v map[string]any
+		// toml.ParseError already reports the line
+		if _, err := toml.Decode(string(in.([]byte)), &v); err != nil {
+			return nil, err
+		}
+
+		return jsonTree(tomlLocalTimes(v))
+	},
+}
+
+var toTOMLAction = Action{
+	Doc:          "Transforms a JSON tree to TOML",
+	Names:        []string{"totoml"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		if _, ok := in.(map[string]any); !ok {
+			return nil, errors.New("a TOML document must be a JSON object")
+		}
+
+		v, err := tomlValue(in)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	},
+}
+
+var parseHCLAction = Action{
+	Doc: "Parse HCL from input into a JSON tree, blocks are nested by type then labels, " +
+		"expressions needing variables or functions are kept as \"${expression}\" strings",
+	Names:        []string{"hcl", "tfvars"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		src := in.([]byte)
+		f, diags := hclsyntax.ParseConfig(src, "input.hcl", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, hclError(diags)
+		}
+
+		v, err := hclBody(f.Body.(*hclsyntax.Body), src)
+		if err != nil {
+			return nil, err
+		}
+		return jsonTree(v)
+	},
+}
+
+// tomlLocalTimes replaces TOML local dates and times, which have no timezone, by their TOML text representation
+func tomlLocalTimes(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = tomlLocalTimes(e)
+		}
+	case []map[string]any:
+		for i, e := range v {
+			v[i] = tomlLocalTimes(e).(map[string]any)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = tomlLocalTimes(e)
+		}
+	case time.Time:
+		// the TOML decoder marks local values with these zone names
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format("2006-01-02")
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999")
+		}
+	}
+	return v
+}
+
+// tomlValue converts a JSON tree to values the TOML encoder understands,
+// json.Number are converted to integers when possible, null object members are omitted
+func tomlValue(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			if e == nil {
+				continue
+			}
+			tv, err := tomlValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			m[k] = tv
+		}
+		return m, nil
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			if e == nil {
+				return nil, fmt.Errorf("index %d: TOML has no null value", i)
+			}
+			tv, err := tomlValue(e)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = tv
+		}
+		return l, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+		return v, nil
+	case nil:
+		return nil, errors.New("TOML has no null value")
+	default:
+		return v, nil
+	}
+}
+
+// hclBody converts an HCL body to a Go map
+func hclBody(body *hclsyntax.Body, src []byte) (map[string]any, error) {
+	m := make(map[string]any, len(body.Attributes)+len(body.Blocks))
+
+	for name, attr := range body.Attributes {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || !v.IsWhollyKnown() {
+			m[name] = "${" + string(attr.Expr.Range().SliceBytes(src)) + "}"
+			continue
+		}
+		b, err := ctyjson.Marshal(v, v.Type())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", attr.SrcRange.Start.Line, err)
+		}
+		m[name] = json.RawMessage(b)
+	}
+
+	for _, block := range body.Blocks {
+		bv, err := hclBody(block.Body, src)
+		if err != nil {
+			return nil, err
+		}
+
+		// nest the block body under its type then labels: type.label1.label2
+		keys := append([]string{block.Type}, block.Labels...)
+		parent := m
+		for _, k := range keys[:len(keys)-1] {
+			child, ok := parent[k].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				parent[k] = child
+			}
+			parent = child
+		}
+
+		// repeated blocks become a list
+		last := keys[len(keys)-1]
+		switch existing := parent[last].(type) {
+		case nil:
+			parent[last] = bv
+		case []any:
+			parent[last] = append(existing, bv)
+		default:
+			parent[last] = []any{existing, bv}
+		}
+	}
+
+	return m, nil
+}
+
+// hclError returns the first error of diags with its line
+func hclError(diags hcl.Diagnostics) error {
+	for _, d := range diags {
+		if d.Severity != hcl.DiagError {
+			continue
+		}
+		if d.Subject == nil {
+			return errors.New(d.Summary)
+		}
+		msg := d.Summary
+		if d.Detail != "" {
+			msg += ": " + d.Detail
+		}
+		return fmt.Errorf("line %d, column %d: %s", d.Subject.Start.Line, d.Subject.Start.Column, msg)
+	}
+	return diags
+}
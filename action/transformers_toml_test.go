@@ -0,0 +1,92 @@
+package action
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_TextTOMLTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(tomlActions...)
+
+	tests := []struct {
+		name    string
+		action  string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{
+			"toml",
+			"toml",
+			"title = \"ovr\"\n[server]\nport = 8080\nratio = 0.5\nstart = 1979-05-27\n\n[[deps]]\nname = \"a\"\n[[deps]]\nname = \"b\"\n",
+			`{"deps":[{"name":"a"},{"name":"b"}],"server":{"port":8080,"ratio":0.5,"start":"1979-05-27"},"title":"ovr"}`,
+			false,
+		},
+		{"toml error", "toml", "a = 1\nb = \"x\" y\n", "toml: line 2: expected a top-level item to end with a newline", true},
+		{
+			"tfvars",
+			"hcl",
+			"region = \"eu-west-1\"\ncount = 3\ntags = { env = \"prod\" }\nzones = [\"a\", \"b\"]\nname = var.prefix\n",
+			`{"count":3,"name":"${var.prefix}","region":"eu-west-1","tags":{"env":"prod"},"zones":["a","b"]}`,
+			false,
+		},
+		{
+			"hcl blocks",
+			"hcl",
+			"resource \"aws_instance\" \"web\" {\n  ami = \"abc\"\n}\nprovisioner {\n  a = 1\n}\nprovisioner {\n  a = 2\n}\n",
+			`{"provisioner":[{"a":1},{"a":2}],"resource":{"aws_instance":{"web":{"ami":"abc"}}}}`,
+			false,
+		},
+		{"hcl error", "hcl", "a = 1\nb = {\n", "line 3, column 1: Missing expression", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.TextJSONAction(tt.action, []byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.want)
+			} else {
+				require.NoError(t, err)
+				b, err := json.Marshal(got)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(b))
+			}
+		})
+	}
+}
+
+func TestAction_JSONTOMLTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(tomlActions...)
+
+	tests := []struct {
+		action  string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"totoml", `{"name":"ovr","port":8080,"ratio":0.5,"skip":null}`, "name = \"ovr\"\nport = 8080\nratio = 0.5\n", false},
+		{"totoml", `{"server":{"hosts":["a","b"]}}`, "[server]\n  hosts = [\"a\", \"b\"]\n", false},
+		{"totoml", `[1,2]`, "", true},
+		{"totoml", `{"a":[1,null]}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			v, err := decodeJSON([]byte(tt.in))
+			require.NoError(t, err)
+
+			got, err := r.JSONTextAction(tt.action, v)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(got))
+			}
+		})
+	}
+}
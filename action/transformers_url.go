@@ -0,0 +1,327 @@
+package action
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var urlActions = []Action{
+	parseURLAction, urlToTextAction, urlInfoAction, urlQueryAction, urlQueryJSONAction,
+	urlSetParamAction, urlRemoveParamAction, urlToASCIIAction, urlToUnicodeAction, urlResolveAction,
+	textURLEncodeAction, textURLDecodeAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(urlActions...)
+}
+
+// urlComponents are the choices for percent-encoding
+func urlComponents() []string {
+	return []string{"query", "path"}
+}
+
+var parseURLAction = Action{
+	Doc:          "Parse an URL",
+	Names:        []string{"url", "uri"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: urlFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		s := strings.TrimSpace(string(in.([]byte)))
+		if s == "" {
+			return nil, errors.New("empty URL")
+		}
+		return url.Parse(s)
+	},
+}
+
+var urlToTextAction = Action{
+	Doc:          "Transforms the URL to text",
+	Names:        []string{"totext", "string"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return []byte(urlString(in.(*url.URL))), nil
+	},
+}
+
+var urlInfoAction = Action{
+	Doc:          "Show the scheme, user, host, port, path, query and fragment",
+	Names:        []string{"info", "parts"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		u := in.(*url.URL)
+
+		t := &Table{Header: []string{"component", "value"}, Delimiter: ','}
+		add := func(k, v string) { t.Rows = append(t.Rows, []string{k, v}) }
+
+		add("scheme", u.Scheme)
+		add("user", u.User.Username())
+		if p, ok := u.User.Password(); ok {
+			add("password", p)
+		}
+		add("host", u.Hostname())
+		add("port", u.Port())
+		add("path", u.Path)
+		add("query", u.RawQuery)
+		add("fragment", u.Fragment)
+		return t, nil
+	},
+}
+
+var urlQueryAction = Action{
+	Doc:          "Show the query parameters as a key/value table",
+	Names:        []string{"query", "params"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		params, err := queryParams(in.(*url.URL).RawQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		t := &Table{Header: []string{"key", "value"}, Delimiter: ','}
+		for _, p := range params {
+			t.Rows = append(t.Rows, []string{p.key, p.value})
+		}
+		return t, nil
+	},
+}
+
+var urlQueryJSONAction = Action{
+	Doc:          "Transforms the query parameters to a JSON object, repeated keys become arrays",
+	Names:        []string{"queryjson", "paramsjson"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		params, err := queryParams(in.(*url.URL).RawQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		m := make(map[string]any)
+		for _, p := range params {
+			switch v := m[p.key].(type) {
+			case nil:
+				m[p.key] = p.value
+			case string:
+				m[p.key] = []any{v, p.value}
+			case []any:
+				m[p.key] = append(v, p.value)
+			}
+		}
+		return m, nil
+	},
+}
+
+var urlSetParamAction = Action{
+	Doc:          "Set a query parameter, replacing existing values",
+	Names:        []string{"set", "setparam"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: urlFormat,
+	Params: []Param{
+		{Name: "name", Doc: "parameter name"},
+		{Name: "value", Doc: "parameter value"},
+	},
+	Func: func(in any, args ...string) (any, error) {
+		if args[0] == "" {
+			return nil, errors.New("missing parameter name")
+		}
+		return editQuery(in.(*url.URL), args[0], &args[1])
+	},
+}
+
+var urlRemoveParamAction = Action{
+	Doc:          "Remove a query parameter",
+	Names:        []string{"remove", "unset"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: urlFormat,
+	Params:       []Param{{Name: "name", Doc: "parameter name"}},
+	Func: func(in any, args ...string) (any, error) {
+		return editQuery(in.(*url.URL), args[0], nil)
+	},
+}
+
+var urlToASCIIAction = Action{
+	Doc:          "Converts an internationalized host to punycode",
+	Names:        []string{"punycode", "toascii"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: urlFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return convertHost(in.(*url.URL), idna.Lookup.ToASCII)
+	},
+}
+
+var urlToUnicodeAction = Action{
+	Doc:          "Converts a punycode host to unicode",
+	Names:        []string{"tounicode", "idn"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: urlFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return convertHost(in.(*url.URL), idna.Display.ToUnicode)
+	},
+}
+
+var urlResolveAction = Action{
+	Doc:          "Resolve a relative reference against the URL",
+	Names:        []string{"resolve", "join"},
+	Type:         TransformAction,
+	InputFormat:  urlFormat,
+	OutputFormat: urlFormat,
+	Params:       []Param{{Name: "ref", Doc: "relative reference, ../img/a.png", Default: "/"}},
+	Func: func(in any, args ...string) (any, error) {
+		ref, err := url.Parse(strings.TrimSpace(args[0]))
+		if err != nil {
+			return nil, err
+		}
+		return in.(*url.URL).ResolveReference(ref), nil
+	},
+}
+
+var textURLEncodeAction = Action{
+	Doc:          "Percent-encode text as a query or a path component",
+	Names:        []string{"urlencode", "percentencode"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "component", Doc: "query or path", Default: "query", Choices: urlComponents}},
+	Func: func(in any, args ...string) (any, error) {
+		switch args[0] {
+		case "query":
+			return []byte(url.QueryEscape(string(in.([]byte)))), nil
+		case "path":
+			return []byte(url.PathEscape(string(in.([]byte)))), nil
+		}
+		return nil, errors.New("unknown component " + args[0])
+	},
+}
+
+var textURLDecodeAction = Action{
+	Doc:          "Percent-decode a query or a path component",
+	Names:        []string{"urldecode", "percentdecode"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "component", Doc: "query (+ is a space) or path", Default: "query", Choices: urlComponents}},
+	Func: func(in any, args ...string) (any, error) {
+		var s string
+		var err error
+		switch args[0] {
+		case "query":
+			s, err = url.QueryUnescape(string(in.([]byte)))
+		case "path":
+			s, err = url.PathUnescape(string(in.([]byte)))
+		default:
+			return nil, errors.New("unknown component " + args[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	},
+}
+
+// queryParam is a decoded query parameter, raw is kept to rewrite untouched parameters as is
+type queryParam struct {
+	key, value, raw string
+}
+
+// queryParams decodes a raw query keeping the order, url.Values is a map
+func queryParams(raw string) ([]queryParam, error) {
+	var params []queryParam
+	for _, s := range strings.Split(raw, "&") {
+		if s == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(s, "=")
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, queryParam{key: key, value: value, raw: s})
+	}
+	return params, nil
+}
+
+// editQuery replaces the first parameter name with value and drops the others,
+// a nil value removes them all
+func editQuery(u *url.URL, name string, value *string) (*url.URL, error) {
+	params, err := queryParams(u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []string
+	set := value == nil
+	for _, p := range params {
+		if p.key != name {
+			parts = append(parts, p.raw)
+			continue
+		}
+		if !set {
+			parts = append(parts, url.QueryEscape(name)+"="+url.QueryEscape(*value))
+			set = true
+		}
+	}
+	if !set {
+		parts = append(parts, url.QueryEscape(name)+"="+url.QueryEscape(*value))
+	}
+
+	nu := *u
+	nu.RawQuery = strings.Join(parts, "&")
+	nu.ForceQuery = false
+	return &nu, nil
+}
+
+// convertHost applies an idna conversion to the host keeping the port
+func convertHost(u *url.URL, conv func(string) (string, error)) (*url.URL, error) {
+	if u.Host == "" || net.ParseIP(u.Hostname()) != nil {
+		return u, nil
+	}
+
+	host, err := conv(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	nu := *u
+	nu.Host = host
+	if port := u.Port(); port != "" {
+		nu.Host = net.JoinHostPort(host, port)
+	}
+	return &nu, nil
+}
+
+// urlString is url.URL.String without percent-encoding an unicode host
+func urlString(u *url.URL) string {
+	s := u.String()
+	escaped := strings.TrimPrefix((&url.URL{Host: u.Host}).String(), "//")
+	if escaped == u.Host {
+		return s
+	}
+	// the host follows the user info, written as String does
+	authority := "//"
+	if u.User != nil {
+		authority += u.User.String() + "@"
+	}
+	return strings.Replace(s, authority+escaped, authority+u.Host, 1)
+}
@@ -0,0 +1,459 @@
+package action
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Certificate is a parsed X.509 certificate or certificate signing request, one of them is set
+type Certificate struct {
+	Cert *x509.Certificate
+	CSR  *x509.CertificateRequest
+}
+
+// extensionNames are the names of the common extensions by OID
+var extensionNames = map[string]string{
+	"2.5.29.14":               "subject key identifier",
+	"2.5.29.15":               "key usage",
+	"2.5.29.17":               "subject alternative name",
+	"2.5.29.18":               "issuer alternative name",
+	"2.5.29.19":               "basic constraints",
+	"2.5.29.30":               "name constraints",
+	"2.5.29.31":               "CRL distribution points",
+	"2.5.29.32":               "certificate policies",
+	"2.5.29.35":               "authority key identifier",
+	"2.5.29.37":               "extended key usage",
+	"1.3.6.1.5.5.7.1.1":       "authority information access",
+	"1.3.6.1.4.1.11129.2.4.2": "signed certificate timestamps",
+	"1.3.6.1.5.5.7.1.24":      "TLS feature",
+}
+
+// keyUsageNames are the names of the key usage bits, in bit order
+var keyUsageNames = []string{
+	"digital signature", "content commitment", "key encipherment", "data encipherment",
+	"key agreement", "certificate sign", "CRL sign", "encipher only", "decipher only",
+}
+
+// extKeyUsageNames are indexed by x509.ExtKeyUsage
+var extKeyUsageNames = []string{
+	"any", "server auth", "client auth", "code signing", "email protection",
+	"IPSec end system", "IPSec tunnel", "IPSec user", "time stamping", "OCSP signing",
+	"Microsoft server gated crypto", "Netscape server gated crypto",
+	"Microsoft commercial code signing", "Microsoft kernel code signing",
+}
+
+var x509Actions = []Action{
+	parseCertificateAction, certificateInfoAction, certificateFingerprintAction, certificateExpiryAction,
+	certificatePEMAction, certificateDERAction, pemSplitAction, certificateChainAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(x509Actions...)
+}
+
+// fingerprintAlgorithms are the choices for fingerprint
+func fingerprintAlgorithms() []string {
+	return []string{"sha256", "sha1"}
+}
+
+var parseCertificateAction = Action{
+	Doc:          "Parse a PEM or DER X.509 certificate or signing request, the first of a bundle",
+	Names:        []string{"x509", "certificate", "cert", "csr"},
+	Type:         ParseAction,
+	InputFormat:  binFormat,
+	OutputFormat: certFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseCertificate(in.([]byte))
+	},
+}
+
+var certificateInfoAction = Action{
+	Doc:          "Show the subject, issuer, names, validity, key, extensions and fingerprints",
+	Names:        []string{"info"},
+	Type:         TransformAction,
+	InputFormat:  certFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		c := in.(*Certificate)
+		if c.CSR != nil {
+			return csrInfo(c.CSR), nil
+		}
+		return certificateInfo(c.Cert, time.Now()), nil
+	},
+}
+
+var certificateFingerprintAction = Action{
+	Doc:          "Fingerprint of the DER encoding, as displayed by openssl",
+	Names:        []string{"fingerprint"},
+	Type:         TransformAction,
+	InputFormat:  certFormat,
+	OutputFormat: textFormat,
+	Params:       []Param{{Name: "algorithm", Doc: "sha256 or sha1", Default: "sha256", Choices: fingerprintAlgorithms}},
+	Func: func(in any, args ...string) (any, error) {
+		raw := in.(*Certificate).Raw()
+		switch strings.ToLower(strings.ReplaceAll(args[0], "-", "")) {
+		case "sha256":
+			sum := sha256.Sum256(raw)
+			return []byte(fingerprint(sum[:])), nil
+		case "sha1":
+			sum := sha1.Sum(raw)
+			return []byte(fingerprint(sum[:])), nil
+		}
+		return nil, fmt.Errorf("unknown algorithm %q", args[0])
+	},
+}
+
+var certificateExpiryAction = Action{
+	Doc:          "Expiration time of the certificate",
+	Names:        []string{"expiry", "notafter"},
+	Type:         TransformAction,
+	InputFormat:  certFormat,
+	OutputFormat: timeFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		c := in.(*Certificate)
+		if c.Cert == nil {
+			return nil, errors.New("a signing request does not expire")
+		}
+		return c.Cert.NotAfter, nil
+	},
+}
+
+var certificatePEMAction = Action{
+	Doc:          "Encode to PEM",
+	Names:        []string{"topem", "pem"},
+	Type:         TransformAction,
+	InputFormat:  certFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		c := in.(*Certificate)
+		return pem.EncodeToMemory(&pem.Block{Type: c.pemType(), Bytes: c.Raw()}), nil
+	},
+}
+
+var certificateDERAction = Action{
+	Doc:          "Encode to DER",
+	Names:        []string{"toder", "der"},
+	Type:         TransformAction,
+	InputFormat:  certFormat,
+	OutputFormat: binFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return in.(*Certificate).Raw(), nil
+	},
+}
+
+var pemSplitAction = Action{
+	Doc:          "Split a PEM bundle into a list of blocks",
+	Names:        []string{"pemsplit", "splitpem"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: textListFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var l []string
+		for _, b := range pemBlocks(in.([]byte)) {
+			l = append(l, strings.TrimSuffix(string(pem.EncodeToMemory(b)), "\n"))
+		}
+		if len(l) == 0 {
+			return nil, errors.New("no PEM block found")
+		}
+		return l, nil
+	},
+}
+
+var certificateChainAction = Action{
+	Doc:          "Verify the order of a PEM bundle, each certificate must be issued by the next one",
+	Names:        []string{"chain", "verifychain"},
+	Type:         TransformAction,
+	InputFormat:  textFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var certs []*x509.Certificate
+		for _, b := range pemBlocks(in.([]byte)) {
+			if b.Type != "CERTIFICATE" {
+				continue
+			}
+			c, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("certificate %d: %w", len(certs)+1, err)
+			}
+			certs = append(certs, c)
+		}
+		if len(certs) == 0 {
+			return nil, errors.New("no certificate found")
+		}
+		return verifyChainOrder(certs, time.Now()), nil
+	},
+}
+
+func (c *Certificate) String() string {
+	if c.CSR != nil {
+		return "certificate request for " + c.CSR.Subject.String()
+	}
+	return fmt.Sprintf("certificate for %s issued by %s, valid until %s",
+		c.Cert.Subject, c.Cert.Issuer, c.Cert.NotAfter.UTC().Format(time.RFC3339))
+}
+
+// Raw returns the DER encoding
+func (c *Certificate) Raw() []byte {
+	if c.CSR != nil {
+		return c.CSR.Raw
+	}
+	return c.Cert.Raw
+}
+
+func (c *Certificate) pemType() string {
+	if c.CSR != nil {
+		return "CERTIFICATE REQUEST"
+	}
+	return "CERTIFICATE"
+}
+
+// pemBlocks returns the PEM blocks of b, the text around them is ignored
+func pemBlocks(b []byte) []*pem.Block {
+	var blocks []*pem.Block
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return blocks
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+// parseCertificate parses the first certificate or request of a PEM bundle, DER or base64 DER
+func parseCertificate(b []byte) (*Certificate, error) {
+	der := b
+	if bytes.Contains(der, []byte("-----BEGIN")) {
+		der = nil
+		for _, block := range pemBlocks(b) {
+			switch block.Type {
+			case "CERTIFICATE", "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+				der = block.Bytes
+			}
+			if der != nil {
+				break
+			}
+		}
+		if der == nil {
+			return nil, errors.New("no certificate or certificate request PEM block found")
+		}
+	} else if d, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(der), nil))); err == nil {
+		der = d
+	}
+
+	if c, err := x509.ParseCertificate(der); err == nil {
+		return &Certificate{Cert: c}, nil
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, errors.New("not a certificate or certificate request")
+	}
+	return &Certificate{CSR: csr}, nil
+}
+
+func certificateInfo(c *x509.Certificate, now time.Time) *Table {
+	t := &Table{Header: []string{"field", "value"}, Delimiter: ','}
+	add := func(k, v string) {
+		if v != "" {
+			t.Rows = append(t.Rows, []string{k, v})
+		}
+	}
+
+	add("subject", c.Subject.String())
+	add("issuer", c.Issuer.String())
+	add("serial", fingerprint(c.SerialNumber.Bytes()))
+	add("version", strconv.Itoa(c.Version))
+	add("names", subjectAltNames(c.DNSNames, c.EmailAddresses, c.IPAddresses, c.URIs))
+	add("not before", c.NotBefore.UTC().Format(time.RFC3339))
+	add("not after", c.NotAfter.UTC().Format(time.RFC3339))
+	switch {
+	case now.Before(c.NotBefore):
+		add("validity", "not yet valid, starts in "+humanDuration(c.NotBefore.Sub(now), 2))
+	case now.After(c.NotAfter):
+		add("validity", "expired "+humanDuration(now.Sub(c.NotAfter), 2)+" ago")
+	default:
+		add("validity", "valid, expires in "+humanDuration(c.NotAfter.Sub(now), 2))
+	}
+	add("key", publicKeyName(c.PublicKey))
+	add("signature", c.SignatureAlgorithm.String())
+	if c.BasicConstraintsValid {
+		ca := "CA:FALSE"
+		if c.IsCA {
+			ca = "CA:TRUE"
+			if c.MaxPathLen > 0 || c.MaxPathLenZero {
+				ca += ", path length " + strconv.Itoa(c.MaxPathLen)
+			}
+		}
+		add("basic constraints", ca)
+	}
+	add("key usage", keyUsage(c.KeyUsage))
+	add("extended key usage", extKeyUsage(c.ExtKeyUsage))
+	add("OCSP", strings.Join(c.OCSPServer, " "))
+	add("CA issuers", strings.Join(c.IssuingCertificateURL, " "))
+	add("CRL", strings.Join(c.CRLDistributionPoints, " "))
+	add("extensions", extensions(c.Extensions))
+
+	sha1Sum, sha256Sum := sha1.Sum(c.Raw), sha256.Sum256(c.Raw)
+	add("SHA-1", fingerprint(sha1Sum[:]))
+	add("SHA-256", fingerprint(sha256Sum[:]))
+	return t
+}
+
+func csrInfo(c *x509.CertificateRequest) *Table {
+	t := &Table{Header: []string{"field", "value"}, Delimiter: ','}
+	add := func(k, v string) {
+		if v != "" {
+			t.Rows = append(t.Rows, []string{k, v})
+		}
+	}
+
+	add("subject", c.Subject.String())
+	add("names", subjectAltNames(c.DNSNames, c.EmailAddresses, c.IPAddresses, c.URIs))
+	add("key", publicKeyName(c.PublicKey))
+	add("signature", c.SignatureAlgorithm.String())
+	if err := c.CheckSignature(); err != nil {
+		add("signature check", err.Error())
+	} else {
+		add("signature check", "valid")
+	}
+	add("extensions", extensions(c.Extensions))
+
+	sum := sha256.Sum256(c.Raw)
+	add("SHA-256", fingerprint(sum[:]))
+	return t
+}
+
+// verifyChainOrder checks each certificate is issued by the next one and the last is a root
+func verifyChainOrder(certs []*x509.Certificate, now time.Time) *Table {
+	t := &Table{Header: []string{"index", "subject", "issuer", "not after", "status"}, Delimiter: ','}
+	for i, c := range certs {
+		var status []string
+		if now.After(c.NotAfter) {
+			status = append(status, "expired")
+		}
+
+		switch {
+		case i+1 < len(certs) && issuedBy(c, certs[i+1]):
+		case issuedBy(c, c):
+			if i+1 < len(certs) {
+				status = append(status, "self-signed certificate before the end of the chain")
+			} else {
+				status = append(status, "root")
+			}
+		default:
+			found := false
+			for j, p := range certs {
+				if j != i && issuedBy(c, p) {
+					status = append(status, fmt.Sprintf("wrong order, issued by %d", j+1))
+					found = true
+					break
+				}
+			}
+			if !found && i+1 < len(certs) {
+				status = append(status, "not issued by the next certificate")
+			} else if !found {
+				status = append(status, "issuer not in the bundle")
+			}
+		}
+		if len(status) == 0 {
+			status = append(status, "ok")
+		}
+
+		t.Rows = append(t.Rows, []string{
+			strconv.Itoa(i + 1), c.Subject.String(), c.Issuer.String(),
+			c.NotAfter.UTC().Format(time.RFC3339), strings.Join(status, ", "),
+		})
+	}
+	return t
+}
+
+// issuedBy returns true if c names parent as its issuer and parent signature is valid
+func issuedBy(c, parent *x509.Certificate) bool {
+	return bytes.Equal(c.RawIssuer, parent.RawSubject) && c.CheckSignatureFrom(parent) == nil
+}
+
+// fingerprint returns b as upper case hexadecimal bytes separated by colons
+func fingerprint(b []byte) string {
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func publicKeyName(k any) string {
+	switch k := k.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d bits", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s %d bits", k.Curve.Params().Name, k.Curve.Params().BitSize)
+	case ed25519.PublicKey:
+		return "Ed25519 256 bits"
+	}
+	return fmt.Sprintf("%T", k)
+}
+
+func subjectAltNames(dns, emails []string, ips []net.IP, uris []*url.URL) string {
+	names := append(slices.Clone(dns), emails...)
+	for _, ip := range ips {
+		names = append(names, ip.String())
+	}
+	for _, u := range uris {
+		names = append(names, u.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func keyUsage(u x509.KeyUsage) string {
+	var names []string
+	for i, name := range keyUsageNames {
+		if u&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func extKeyUsage(l []x509.ExtKeyUsage) string {
+	names := make([]string, len(l))
+	for i, u := range l {
+		names[i] = strconv.Itoa(int(u))
+		if int(u) < len(extKeyUsageNames) {
+			names[i] = extKeyUsageNames[u]
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func extensions(l []pkix.Extension) string {
+	names := make([]string, len(l))
+	for i, e := range l {
+		name, ok := extensionNames[e.Id.String()]
+		if !ok {
+			name = e.Id.String()
+		}
+		if e.Critical {
+			name += " (critical)"
+		}
+		names[i] = name
+	}
+	return strings.Join(names, ", ")
+}
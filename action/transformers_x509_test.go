@@ -0,0 +1,240 @@
+package action
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func (r *ActionRegistry) CertificateAction(action string, in *Certificate, args ...string) (any, error) {
+	a, ok := r.m[certFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for certificate input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+// testChain returns a leaf, intermediate and root certificates
+func testChain(t *testing.T) (leaf, inter, root *x509.Certificate) {
+	t.Helper()
+	now := time.Now()
+	create := func(tmpl, parent *x509.Certificate, parentKey any) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+		require.NoError(t, err)
+		c, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return c, key
+	}
+
+	root, rootKey := create(&x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test Root"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(10 * 365 * 24 * time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+	inter, interKey := create(&x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "Test Intermediate"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(5 * 365 * 24 * time.Hour),
+		IsCA: true, BasicConstraintsValid: true, MaxPathLenZero: true, KeyUsage: x509.KeyUsageCertSign,
+	}, root, rootKey)
+	leaf, _ = create(&x509.Certificate{
+		SerialNumber: big.NewInt(0x1234), Subject: pkix.Name{CommonName: "example.com", Organization: []string{"Example"}},
+		DNSNames: []string{"example.com", "www.example.com"}, IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(90*24*time.Hour + time.Minute),
+		KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true, OCSPServer: []string{"http://ocsp.example.com"},
+	}, inter, interKey)
+	return leaf, inter, root
+}
+
+func toPEM(certs ...*x509.Certificate) string {
+	var sb strings.Builder
+	for _, c := range certs {
+		sb.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}))
+	}
+	return sb.String()
+}
+
+func TestAction_BinCertificateTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(x509Actions...)
+
+	leaf, inter, _ := testChain(t)
+	lines := strings.Split(strings.TrimSpace(toPEM(inter)), "\n")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "req.example.com"}, DNSNames: []string{"req.example.com"},
+	}, key)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantCSR bool
+		wantErr bool
+	}{
+		{"pem bundle", "leaf:\n" + toPEM(leaf, inter), "CN=example.com,O=Example", false, false},
+		{"der", string(leaf.Raw), "CN=example.com,O=Example", false, false},
+		{"base64", "  " + strings.Join(lines[1:len(lines)-1], "\n"), "CN=Test Intermediate", false, false},
+		{"csr", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})), "CN=req.example.com", true, false},
+		{"other pem", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1}})), "", false, true},
+		{"garbage", "hello", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[binFormat.Prefix+",x509"].Func([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			c := got.(*Certificate)
+			if tt.wantCSR {
+				require.Equal(t, tt.want, c.CSR.Subject.String())
+				return
+			}
+			require.Equal(t, tt.want, c.Cert.Subject.String())
+		})
+	}
+}
+
+// TestParseCertificateTrailingSpace is a DER certificate ending with a newline or a space byte,
+// trimming the input before parsing truncated it
+func TestParseCertificateTrailingSpace(t *testing.T) {
+	// ed25519 signatures are deterministic, the serial is searched once for the same result
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	var der []byte
+	for serial := int64(1); serial < 10000; serial++ {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "space.example.com"},
+			NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:     time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		b, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		require.NoError(t, err)
+		if last := b[len(b)-1]; last == '\n' || last == ' ' {
+			der = b
+			break
+		}
+	}
+	require.NotNil(t, der, "no certificate ending with a space byte")
+
+	c, err := parseCertificate(der)
+	require.NoError(t, err)
+	require.Equal(t, "CN=space.example.com", c.Cert.Subject.String())
+}
+
+func TestAction_CertificateTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(x509Actions...)
+
+	leaf, _, _ := testChain(t)
+	in := &Certificate{Cert: leaf}
+
+	got, err := r.CertificateAction("info", in)
+	require.NoError(t, err)
+	info := map[string]string{}
+	for _, row := range got.(*Table).Rows {
+		info[row[0]] = row[1]
+	}
+	require.Equal(t, "CN=example.com,O=Example", info["subject"])
+	require.Equal(t, "CN=Test Intermediate", info["issuer"])
+	require.Equal(t, "12:34", info["serial"])
+	require.Equal(t, "example.com, www.example.com, 10.0.0.1", info["names"])
+	require.Equal(t, "valid, expires in 90 days", info["validity"])
+	require.Equal(t, "ECDSA P-256 256 bits", info["key"])
+	require.Equal(t, "ECDSA-SHA256", info["signature"])
+	require.Equal(t, "CA:FALSE", info["basic constraints"])
+	require.Equal(t, "digital signature", info["key usage"])
+	require.Equal(t, "server auth", info["extended key usage"])
+	require.Equal(t, "http://ocsp.example.com", info["OCSP"])
+	require.Contains(t, info["extensions"], "key usage (critical), extended key usage, basic constraints (critical)")
+	require.Len(t, info["SHA-1"], 20*3-1)
+
+	got, err = r.CertificateAction("fingerprint", in)
+	require.NoError(t, err)
+	require.Equal(t, info["SHA-256"], string(got.([]byte)))
+	require.Regexp(t, `^([0-9A-F]{2}:){31}[0-9A-F]{2}$`, info["SHA-256"])
+
+	got, err = r.CertificateAction("fingerprint", in, "SHA-1")
+	require.NoError(t, err)
+	require.Equal(t, info["SHA-1"], string(got.([]byte)))
+
+	_, err = r.CertificateAction("fingerprint", in, "md5")
+	require.Error(t, err)
+
+	got, err = r.CertificateAction("expiry", in)
+	require.NoError(t, err)
+	require.True(t, leaf.NotAfter.Equal(got.(time.Time)))
+
+	got, err = r.CertificateAction("topem", in)
+	require.NoError(t, err)
+	require.Equal(t, toPEM(leaf), string(got.([]byte)))
+
+	got, err = r.CertificateAction("toder", in)
+	require.NoError(t, err)
+	require.Equal(t, leaf.Raw, got)
+}
+
+func TestAction_TextPEMTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(x509Actions...)
+
+	leaf, inter, root := testChain(t)
+
+	got, err := r.m[textFormat.Prefix+",pemsplit"].Func([]byte("# bundle\n" + toPEM(leaf, inter, root)))
+	require.NoError(t, err)
+	l := got.([]string)
+	require.Len(t, l, 3)
+	require.Equal(t, strings.TrimSuffix(toPEM(inter), "\n"), l[1])
+
+	_, err = r.m[textFormat.Prefix+",pemsplit"].Func([]byte("nothing"))
+	require.Error(t, err)
+
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"ordered", toPEM(leaf, inter, root), []string{"ok", "ok", "root"}},
+		{"no root", toPEM(leaf, inter), []string{"ok", "issuer not in the bundle"}},
+		{"wrong order", toPEM(inter, leaf, root), []string{"wrong order, issued by 3", "wrong order, issued by 1", "root"}},
+		{"missing intermediate", toPEM(leaf, root), []string{"not issued by the next certificate", "root"}},
+		{"root first", toPEM(root, leaf, inter), []string{"self-signed certificate before the end of the chain", "ok", "wrong order, issued by 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.m[textFormat.Prefix+",chain"].Func([]byte(tt.in))
+			require.NoError(t, err)
+			var status []string
+			for _, row := range got.(*Table).Rows {
+				status = append(status, row[4])
+			}
+			require.Equal(t, tt.want, status)
+		})
+	}
+}
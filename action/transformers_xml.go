@@ -0,0 +1,397 @@
+package action
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// XML to JSON tree convention:
+//   - a document is an object with the root element name as single key
+//   - an element is an object, its attributes are keys prefixed by "@", its text is under "#text"
+//   - an element with only text and no attribute is a string, an empty element is an empty string
+//   - repeated child elements are an array
+//   - namespace prefixes are kept in names ("soap:Body", "@xmlns:soap"), comments are dropped
+//
+// Converting back, object keys are sorted: the order of differently named siblings is not kept.
+
+var xmlActions = []Action{
+	parseXMLAction, xmlPrettyAction, xmlMinifyAction, xpathAction, xmlElementsAction,
+	xmlToJSONAction, jsonToXMLAction,
+}
+
+func init() {
+	r := DefaultRegistry()
+
+	r.RegisterActions(xmlActions...)
+}
+
+var parseXMLAction = Action{
+	Doc:          "Parse XML from input",
+	Names:        []string{"xml"},
+	Type:         ParseAction,
+	InputFormat:  textFormat,
+	OutputFormat: xmlFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return parseXML(in.([]byte))
+	},
+}
+
+var xmlPrettyAction = Action{
+	Doc:          "Transforms XML to indented text",
+	Names:        []string{"pretty", "toxml"},
+	Type:         TransformAction,
+	InputFormat:  xmlFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return indentXML([]byte(in.(*xmlquery.Node).OutputXML(true)), "  ")
+	},
+}
+
+var xmlMinifyAction = Action{
+	Doc:          "Transforms XML to compact text, removing blanks between elements",
+	Names:        []string{"minify"},
+	Type:         TransformAction,
+	InputFormat:  xmlFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		return indentXML([]byte(in.(*xmlquery.Node).OutputXML(true)), "")
+	},
+}
+
+var xpathAction = Action{
+	Doc:          "Query XML using XPath, elements are returned as XML, other nodes as text",
+	Names:        []string{"xpath"},
+	Type:         TransformAction,
+	InputFormat:  xmlFormat,
+	OutputFormat: textListFormat,
+	Params:       []Param{{Name: "xpath", Doc: "XPath expression, e.g. //book[@id='1']/title", Default: "/*"}},
+	Func: func(in any, args ...string) (any, error) {
+		expr, err := xpath.Compile(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := expr.Evaluate(xmlquery.CreateXPathNavigator(in.(*xmlquery.Node))).(type) {
+		case *xpath.NodeIterator:
+			var l []string
+			for v.MoveNext() {
+				nav := v.Current().(*xmlquery.NodeNavigator)
+				if nav.NodeType() == xpath.ElementNode {
+					l = append(l, nav.Current().OutputXML(true))
+					continue
+				}
+				l = append(l, nav.Value())
+			}
+			if len(l) == 0 {
+				return nil, errors.New("no match")
+			}
+			return l, nil
+		case float64:
+			return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+		default:
+			return []string{fmt.Sprint(v)}, nil
+		}
+	},
+}
+
+var xmlElementsAction = Action{
+	Doc:          "List the element names with their count and attribute names",
+	Names:        []string{"elements"},
+	Type:         TransformAction,
+	InputFormat:  xmlFormat,
+	OutputFormat: tableFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		var names []string
+		counts := make(map[string]int)
+		attrs := make(map[string]map[string]struct{})
+
+		var walk func(n *xmlquery.Node)
+		walk = func(n *xmlquery.Node) {
+			if n.Type == xmlquery.ElementNode {
+				name := xmlNodeName(n)
+				if _, ok := counts[name]; !ok {
+					names = append(names, name)
+					attrs[name] = make(map[string]struct{})
+				}
+				counts[name]++
+				for _, a := range n.Attr {
+					attrs[name][xmlAttrName(a)] = struct{}{}
+				}
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+		walk(in.(*xmlquery.Node))
+
+		t := &Table{Header: []string{"element", "count", "attributes"}, Delimiter: ','}
+		for _, name := range names {
+			var al []string
+			for a := range attrs[name] {
+				al = append(al, a)
+			}
+			sort.Strings(al)
+			t.Rows = append(t.Rows, []string{name, strconv.Itoa(counts[name]), strings.Join(al, " ")})
+		}
+		return t, nil
+	},
+}
+
+var xmlToJSONAction = Action{
+	Doc:          "Transforms XML to a JSON tree, attributes are prefixed by @, text is under #text",
+	Names:        []string{"tojson"},
+	Type:         TransformAction,
+	InputFormat:  xmlFormat,
+	OutputFormat: jsonFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		doc := in.(*xmlquery.Node)
+		for c := doc.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == xmlquery.ElementNode {
+				return map[string]any{xmlNodeName(c): xmlElementJSON(c)}, nil
+			}
+		}
+		return nil, errors.New("no root element")
+	},
+}
+
+var jsonToXMLAction = Action{
+	Doc:          "Transforms a JSON tree to XML, keys prefixed by @ are attributes, #text is the text",
+	Names:        []string{"toxml"},
+	Type:         TransformAction,
+	InputFormat:  jsonFormat,
+	OutputFormat: textFormat,
+	Func: func(in any, _ ...string) (any, error) {
+		m, ok := in.(map[string]any)
+		if !ok || len(m) != 1 {
+			return nil, errors.New("expecting an object with the root element as single key")
+		}
+
+		var buf bytes.Buffer
+		enc := xml.NewEncoder(&buf)
+		enc.Indent("", "  ")
+		for name, v := range m {
+			if err := encodeJSONElement(enc, name, v); err != nil {
+				return nil, err
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	},
+}
+
+// parseXML parses b, syntax errors are reported with their line and column
+func parseXML(b []byte) (*xmlquery.Node, error) {
+	// xmlquery only reports the line of errors, validate first
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, col := d.InputPos()
+			var serr *xml.SyntaxError
+			if errors.As(err, &serr) {
+				return nil, fmt.Errorf("line %d, column %d: %s", line, col, serr.Msg)
+			}
+			return nil, fmt.Errorf("line %d, column %d: %w", line, col, err)
+		}
+	}
+
+	doc, err := xmlquery.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xmlquery.ElementNode {
+			return doc, nil
+		}
+	}
+	return nil, errors.New("no root element")
+}
+
+// indentXML re-encodes XML tokens with indent, blank text between elements is removed
+func indentXML(b []byte, indent string) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", indent)
+
+	for first := true; ; first = false {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// raw tokens keep the namespace prefix in Space, the encoder expects an URL there
+		switch t := tok.(type) {
+		case xml.StartElement:
+			t.Name = xmlRawName(t.Name)
+			for i, a := range t.Attr {
+				t.Attr[i].Name = xmlRawName(a.Name)
+			}
+			tok = t
+		case xml.EndElement:
+			t.Name = xmlRawName(t.Name)
+			tok = t
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		case xml.ProcInst:
+			// the encoder writes its own declaration only if it is the first token
+			if t.Target == "xml" && !first {
+				continue
+			}
+		}
+
+		if err := enc.EncodeToken(xml.CopyToken(tok)); err != nil {
+			return nil, err
+		}
+
+		// the encoder does not indent after the declaration
+		if pi, ok := tok.(xml.ProcInst); ok && pi.Target == "xml" && indent != "" {
+			if err := enc.Flush(); err != nil {
+				return nil, err
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func xmlRawName(n xml.Name) xml.Name {
+	if n.Space == "" {
+		return n
+	}
+	return xml.Name{Local: n.Space + ":" + n.Local}
+}
+
+func xmlNodeName(n *xmlquery.Node) string {
+	if n.Prefix != "" {
+		return n.Prefix + ":" + n.Data
+	}
+	return n.Data
+}
+
+func xmlAttrName(a xmlquery.Attr) string {
+	if a.Name.Space != "" {
+		return a.Name.Space + ":" + a.Name.Local
+	}
+	return a.Name.Local
+}
+
+// xmlElementJSON converts an element to its JSON tree representation
+func xmlElementJSON(n *xmlquery.Node) any {
+	o := make(map[string]any)
+	for _, a := range n.Attr {
+		o["@"+xmlAttrName(a)] = a.Value
+	}
+
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case xmlquery.TextNode, xmlquery.CharDataNode:
+			text.WriteString(c.Data)
+		case xmlquery.ElementNode:
+			name := xmlNodeName(c)
+			v := xmlElementJSON(c)
+			switch existing := o[name].(type) {
+			case nil:
+				o[name] = v
+			case []any:
+				o[name] = append(existing, v)
+			default:
+				o[name] = []any{existing, v}
+			}
+		}
+	}
+
+	s := strings.TrimSpace(text.String())
+	if len(o) == 0 {
+		return s
+	}
+	if s != "" {
+		o["#text"] = s
+	}
+	return o
+}
+
+// encodeJSONElement encodes v as an element called name
+func encodeJSONElement(enc *xml.Encoder, name string, v any) error {
+	if l, ok := v.([]any); ok {
+		for _, e := range l {
+			if err := encodeJSONElement(enc, name, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	o, isObject := v.(map[string]any)
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if strings.HasPrefix(k, "@") {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: k[1:]}, Value: jsonCell(o[k])})
+		}
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if isObject {
+		if text, ok := o["#text"]; ok {
+			if err := enc.EncodeToken(xml.CharData(jsonCell(text))); err != nil {
+				return err
+			}
+		}
+		for _, k := range keys {
+			if strings.HasPrefix(k, "@") || k == "#text" {
+				continue
+			}
+			if err := encodeJSONElement(enc, k, o[k]); err != nil {
+				return err
+			}
+		}
+	} else if v != nil {
+		if err := enc.EncodeToken(xml.CharData(jsonCell(v))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// xmlString returns the indented XML of a document
+func xmlString(doc *xmlquery.Node) string {
+	b, err := indentXML([]byte(doc.OutputXML(true)), "  ")
+	if err != nil {
+		return doc.OutputXML(true)
+	}
+	return string(b)
+}
@@ -0,0 +1,156 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/stretchr/testify/require"
+)
+
+const soapEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body>
+    <m:GetPriceResponse xmlns:m="https://www.example.org/stock">
+      <m:Price currency="USD">34.5</m:Price>
+      <m:Price currency="EUR">31.2</m:Price>
+      <m:Symbol>ACME</m:Symbol>
+    </m:GetPriceResponse>
+  </soap:Body>
+</soap:Envelope>
+`
+
+func (r *ActionRegistry) XMLAction(action string, in *xmlquery.Node, args ...string) (any, error) {
+	a, ok := r.m[xmlFormat.Prefix+","+action]
+	if !ok {
+		return nil, fmt.Errorf("action %s does not exist for xml input", action)
+	}
+	return a.Func(in, a.WithArgs(args...).args()...)
+}
+
+func TestAction_TextXMLTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(xmlActions...)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"soap", soapEnvelope, "", false},
+		{"unclosed", "<a>\n  <b>\n</a>", "line 3, column 5: element <b> closed by </a>", true},
+		{"bad attribute", "<a>\n<b c=d/></a>", "line 2, column 7: unquoted or missing attribute value in element", true},
+		{"no root", "just text", "no root element", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := r.m[textFormat.Prefix+",xml"]
+			_, err := a.Func([]byte(tt.in))
+			if tt.wantErr {
+				require.EqualError(t, err, tt.want)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAction_XMLTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(xmlActions...)
+
+	doc, err := parseXML([]byte(soapEnvelope))
+	require.NoError(t, err)
+
+	tests := []struct {
+		action  string
+		args    []string
+		want    any
+		wantErr bool
+	}{
+		{"xpath", []string{"//m:Price[@currency='EUR']"}, []string{`<m:Price currency="EUR">31.2</m:Price>`}, false},
+		{"xpath", []string{"//m:Price/@currency"}, []string{"USD", "EUR"}, false},
+		{"xpath", []string{"count(//m:Price)"}, []string{"2"}, false},
+		{"xpath", []string{"//nothing"}, nil, true},
+		{"xpath", []string{"//["}, nil, true},
+		{
+			"elements",
+			nil,
+			"element,count,attributes\nsoap:Envelope,1,xmlns:soap\nsoap:Body,1,\nm:GetPriceResponse,1,xmlns:m\nm:Price,2,currency\nm:Symbol,1,\n",
+			false,
+		},
+		{
+			"minify",
+			nil,
+			`<?xml version="1.0" encoding="UTF-8"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><m:GetPriceResponse xmlns:m="https://www.example.org/stock"><m:Price currency="USD">34.5</m:Price><m:Price currency="EUR">31.2</m:Price><m:Symbol>ACME</m:Symbol></m:GetPriceResponse></soap:Body></soap:Envelope>`,
+			false,
+		},
+		{"pretty", nil, soapEnvelope[:len(soapEnvelope)-1], false},
+		{
+			"tojson",
+			nil,
+			`{"soap:Envelope":{"@xmlns:soap":"http://www.w3.org/2003/05/soap-envelope","soap:Body":{"m:GetPriceResponse":{"@xmlns:m":"https://www.example.org/stock","m:Price":[{"#text":"34.5","@currency":"USD"},{"#text":"31.2","@currency":"EUR"}],"m:Symbol":"ACME"}}}}`,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			got, err := r.XMLAction(tt.action, doc, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			switch v := got.(type) {
+			case []string:
+				require.Equal(t, tt.want, v)
+			case []byte:
+				require.Equal(t, tt.want, string(v))
+			case *Table:
+				require.Equal(t, tt.want, string(v.CSV(',')))
+			default:
+				b, err := json.Marshal(v)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(b))
+			}
+		})
+	}
+}
+
+func TestAction_JSONXMLTransform(t *testing.T) {
+	r := NewRegistry()
+
+	r.RegisterActions(xmlActions...)
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{
+			`{"order":{"@id":"7","item":[{"#text":"apple","@qty":2},"pear"],"note":"a < b","empty":""}}`,
+			"<order id=\"7\">\n  <empty></empty>\n  <item qty=\"2\">apple</item>\n  <item>pear</item>\n  <note>a &lt; b</note>\n</order>",
+			false,
+		},
+		{`{"a":1,"b":2}`, "", true},
+		{`[1]`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := decodeJSON([]byte(tt.in))
+			require.NoError(t, err)
+
+			got, err := r.JSONTextAction("toxml", v)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(got))
+			}
+		})
+	}
+}
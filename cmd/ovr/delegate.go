@@ -76,3 +76,19 @@ func newDelegateKeyMap() *delegateKeyMap {
 		),
 	}
 }
+
+// choice is a parameter value proposed in the choices picker
+type choice string
+
+func (c choice) FilterValue() string { return string(c) }
+
+func (c choice) Title() string { return string(c) }
+
+func (c choice) Description() string { return "" }
+
+func newChoiceDelegate() list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	d.ShowDescription = false
+	d.SetSpacing(0)
+	return d
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/png"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/image/draw"
+)
+
+// graphics protocols used to preview images
+const (
+	graphicsBlocks = "blocks"
+	graphicsKitty  = "kitty"
+	graphicsSixel  = "sixel"
+)
+
+const (
+	// kittyChunkSize is the maximum payload of a kitty graphics escape
+	kittyChunkSize = 4096
+	// cellWidth and cellHeight approximate the pixel size of a cell
+	cellWidth  = 10
+	cellHeight = 20
+	// graphicsDelay lets the frame render before drawing over it
+	graphicsDelay = 50 * time.Millisecond
+)
+
+// graphics is the protocol used to preview images, OVR_GRAPHICS overrides the detection
+var graphics = detectGraphics()
+
+// drawGraphicsMsg asks to draw the escape sequence of an image preview
+type drawGraphicsMsg string
+
+func detectGraphics() string {
+	if g := os.Getenv("OVR_GRAPHICS"); g != "" {
+		return g
+	}
+
+	term, program := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" ||
+		program == "WezTerm" || program == "ghostty":
+		return graphicsKitty
+	case strings.Contains(term, "sixel") || term == "foot" || term == "mlterm":
+		return graphicsSixel
+	}
+	return graphicsBlocks
+}
+
+// setImageOutput renders the image preview followed by its description
+func (m *model) setImageOutput(img image.Image) {
+	cols, rows := fitCells(img.Bounds(), m.output.Width, m.output.Height-2)
+
+	seq := ""
+	var preview string
+	switch graphics {
+	case graphicsKitty:
+		seq = kittySequence(img, cols, rows)
+		preview = strings.Repeat("\n", rows-1)
+	case graphicsSixel:
+		seq = sixelSequence(img, cols, rows)
+		preview = strings.Repeat("\n", rows-1)
+	default:
+		preview = halfBlocks(img, cols, rows)
+	}
+	m.setGraphics(seq)
+	m.output.SetContent(preview + "\n\n" + m.out.String())
+	m.output.GotoTop()
+}
+
+// setGraphics records the sequence to draw over the output pane, empty to remove it
+func (m *model) setGraphics(seq string) {
+	if seq != m.graphics {
+		m.graphicsDirty = true
+	}
+	m.graphics = seq
+}
+
+// graphicsCmd draws the current preview after the next frame or removes the previous one
+func (m model) graphicsCmd() tea.Cmd {
+	if m.graphics != "" {
+		seq := m.graphics
+		return tea.Tick(graphicsDelay, func(time.Time) tea.Msg { return drawGraphicsMsg(seq) })
+	}
+	if graphics == graphicsKitty {
+		return func() tea.Msg {
+			fmt.Fprint(os.Stdout, "\x1b_Ga=d,q=2\x1b\\")
+			return nil
+		}
+	}
+	// sixels are pixels on screen, a full repaint removes them
+	return tea.ClearScreen
+}
+
+// drawGraphics writes the preview at the top left of the output pane
+func (m model) drawGraphics(seq string) {
+	// a newer output may have replaced the image while waiting
+	if seq != m.graphics {
+		return
+	}
+	row := appStyle.GetPaddingTop() + outputStyle.GetBorderTopSize() + 1
+	col := appStyle.GetPaddingLeft() + outputStyle.GetBorderLeftSize() + 1
+	fmt.Fprintf(os.Stdout, "\x1b7\x1b[%d;%dH%s\x1b8", row, col, seq)
+}
+
+// fitCells returns the number of cells to display an image in a box of cols x rows,
+// a cell is about twice as high as wide, small images are not enlarged
+func fitCells(b image.Rectangle, cols, rows int) (int, int) {
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return 1, 1
+	}
+	cols = min(cols, w)
+	r := (h*cols + w) / (2 * w)
+	if r > rows {
+		r = rows
+		cols = (2*w*r + h/2) / h
+	}
+	return max(cols, 1), max(r, 1)
+}
+
+func scaleImage(img image.Image, w, h int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.BiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// halfBlocks renders the image with ▀, the foreground is the upper pixel, the background the lower
+func halfBlocks(img image.Image, cols, rows int) string {
+	px := scaleImage(img, cols, rows*2)
+
+	color := func(x, y int) (lipgloss.Color, bool) {
+		c := px.NRGBAAt(x, y)
+		if c.A < 128 {
+			return "", false
+		}
+		return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)), true
+	}
+
+	lines := make([]string, rows)
+	for y := 0; y < rows; y++ {
+		var b strings.Builder
+		for x := 0; x < cols; x++ {
+			top, okTop := color(x, 2*y)
+			bottom, okBottom := color(x, 2*y+1)
+			switch {
+			case okTop && okBottom:
+				b.WriteString(lipgloss.NewStyle().Foreground(top).Background(bottom).Render("▀"))
+			case okTop:
+				b.WriteString(lipgloss.NewStyle().Foreground(top).Render("▀"))
+			case okBottom:
+				b.WriteString(lipgloss.NewStyle().Foreground(bottom).Render("▄"))
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		lines[y] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// kittySequence returns the kitty graphics escapes displaying the image on cols x rows cells
+func kittySequence(img image.Image, cols, rows int) string {
+	// the terminal scales the image to the cells, a larger one only costs encoding time
+	bounds := img.Bounds()
+	if w, h := cols*cellWidth, rows*cellHeight; bounds.Dx() > w || bounds.Dy() > h {
+		img = scaleImage(img, min(w, bounds.Dx()), min(h, bounds.Dy()))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	var b strings.Builder
+	// remove the previous preview
+	b.WriteString("\x1b_Ga=d,q=2\x1b\\")
+	for i := 0; i < len(data); i += kittyChunkSize {
+		chunk := data[i:min(i+kittyChunkSize, len(data))]
+		more := 0
+		if i+kittyChunkSize < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			// C=1 keeps the cursor in place
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, chunk)
+			continue
+		}
+		fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+	}
+	return b.String()
+}
+
+// sixelSequence returns the sixel escape displaying the image on about cols x rows cells
+func sixelSequence(img image.Image, cols, rows int) string {
+	w, h := cols*cellWidth, rows*cellHeight
+	px := image.NewPaletted(image.Rect(0, 0, w, h), palette.WebSafe)
+	draw.FloydSteinberg.Draw(px, px.Bounds(), scaleImage(img, w, h), image.Point{})
+
+	var b strings.Builder
+	// 1 as second parameter leaves unset pixels transparent
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	for i, c := range px.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	for y := 0; y < h; y += 6 {
+		var used [256]bool
+		for dy := 0; dy < 6 && y+dy < h; dy++ {
+			for x := 0; x < w; x++ {
+				used[px.ColorIndexAt(x, y+dy)] = true
+			}
+		}
+
+		for c := range used {
+			if !used[c] {
+				continue
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			// run length encoding of the 6 pixels high columns
+			var last byte
+			count := 0
+			flush := func() {
+				if count > 3 {
+					fmt.Fprintf(&b, "!%d%c", count, last)
+				} else {
+					b.WriteString(strings.Repeat(string(last), count))
+				}
+			}
+			for x := 0; x < w; x++ {
+				bits := 0
+				for dy := 0; dy < 6 && y+dy < h; dy++ {
+					if int(px.ColorIndexAt(x, y+dy)) == c {
+						bits |= 1 << dy
+					}
+				}
+				ch := byte(63 + bits)
+				if ch == last {
+					count++
+					continue
+				}
+				if count > 0 {
+					flush()
+				}
+				last, count = ch, 1
+			}
+			flush()
+			// back to the start of the band for the next color
+			b.WriteByte('$')
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
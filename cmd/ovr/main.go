@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"golang.design/x/clipboard"
@@ -30,6 +32,13 @@ var (
 	errorMessageStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#FF1111", Dark: "#FF1111"}).
 				Render
+
+	outputStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#874BFD"))
+
+	focusedOutputStyle = outputStyle.Copy().
+				BorderForeground(lipgloss.Color("#25A065"))
 )
 
 type listKeyMap struct {
@@ -38,6 +47,7 @@ type listKeyMap struct {
 	togglePagination key.Binding
 	toggleHelpMenu   key.Binding
 	removeAction     key.Binding
+	switchFocus      key.Binding
 }
 
 func newListKeyMap() *listKeyMap {
@@ -62,6 +72,10 @@ func newListKeyMap() *listKeyMap {
 			key.WithKeys("backspace", "d"),
 			key.WithHelp("backspace", "undo last action"),
 		),
+		switchFocus: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch focus between actions and output"),
+		),
 	}
 }
 
@@ -72,6 +86,21 @@ type model struct {
 	delegateKeys *delegateKeyMap
 	in           []byte
 	out          *action.Data
+
+	// output pane, table is used when the output is a table
+	output        viewport.Model
+	table         table.Model
+	outputFocused bool
+
+	// parameters prompt for the pending action, choices is the picker for params with choices
+	input   textinput.Model
+	choices list.Model
+	pending *action.Action
+	args    []string
+
+	// graphics is the kitty or sixel sequence of the image preview, drawn over the output pane
+	graphics      string
+	graphicsDirty bool
 }
 
 func newModel(in []byte) model {
@@ -91,7 +120,7 @@ func newModel(in []byte) model {
 	// Setup list
 	delegate := newItemDelegate(delegateKeys)
 	actionList := list.New(items, delegate, 0, 0)
-	actionList.Title = fmt.Sprintf("Text Input: %s", strings.TrimRight(string(in), "\r\n"))
+	actionList.Title = "Text Input"
 	actionList.Styles.Title = titleStyle
 	actionList.SetShowStatusBar(false)
 	actionList.AdditionalFullHelpKeys = func() []key.Binding {
@@ -101,17 +130,31 @@ func newModel(in []byte) model {
 			listKeys.togglePagination,
 			listKeys.toggleHelpMenu,
 			listKeys.removeAction,
+			listKeys.switchFocus,
 		}
 	}
 
-	return model{
+	// Setup parameter choices picker
+	choices := list.New(nil, newChoiceDelegate(), 0, 0)
+	choices.Styles.Title = titleStyle
+	// esc cancels the prompt, it should not quit
+	choices.KeyMap.Quit.SetEnabled(false)
+
+	m := model{
 		r:            r,
 		list:         actionList,
 		keys:         listKeys,
 		delegateKeys: delegateKeys,
 		in:           in,
 		out:          action.NewDataText(in),
+		output:       viewport.New(0, 0),
+		table:        table.New(),
+		input:        textinput.New(),
+		choices:      choices,
 	}
+	m.setOutput()
+
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -119,19 +162,70 @@ func (m model) Init() tea.Cmd {
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if seq, ok := msg.(drawGraphicsMsg); ok {
+		m.drawGraphics(string(seq))
+		return m, nil
+	}
+
+	tm, cmd := m.update(msg)
+	if m, ok := tm.(model); ok && m.graphicsDirty {
+		m.graphicsDirty = false
+		return m, tea.Batch(cmd, m.graphicsCmd())
+	}
+	return tm, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		h, v := appStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		m.setSize(msg.Width-h, msg.Height-v)
+		// the image preview and the markdown wrapping depend on the output size
+		switch m.out.Value.(type) {
+		case *action.Image, *action.Markdown:
+			m.setOutput()
+		}
+
+	case list.FilterMatchesMsg:
+		// the matches are computed asynchronously, they belong to the choices while picking
+		if m.choosing() {
+			var cmd tea.Cmd
+			m.choices, cmd = m.choices.Update(msg)
+			return m, cmd
+		}
 
 	case tea.KeyMsg:
+		if m.pending != nil {
+			return m.updateParams(msg)
+		}
+
 		// Don't match any of the keys below if we're actively filtering.
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
 
+		if key.Matches(msg, m.keys.switchFocus) {
+			m.outputFocused = !m.outputFocused
+			if m.outputFocused {
+				m.table.Focus()
+			} else {
+				m.table.Blur()
+			}
+			return m, nil
+		}
+
+		if m.outputFocused {
+			var cmd tea.Cmd
+			if _, ok := m.out.Value.(*action.Table); ok {
+				m.table, cmd = m.table.Update(msg)
+			} else {
+				m.output, cmd = m.output.Update(msg)
+			}
+			return m, cmd
+		}
+
 		switch {
 
 		case key.Matches(msg, m.keys.toggleTitleBar):
@@ -161,39 +255,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.out = d
 			m.list.NewStatusMessage(statusMessageStyle("Removed action: " + oa.Title()))
-
-			m.list.Title = fmt.Sprintf("%s: %s", m.out.Format.Name, strings.TrimRight(m.out.String(), "\r\n"))
-
-			m.list.ResetFilter()
-
-			actions := m.r.ActionsForData(m.out)
-			items := make([]list.Item, len(actions))
-			for i := 0; i < len(actions); i++ {
-				items[i] = actions[i]
-			}
-			m.list.SetItems(items)
+			m.refresh()
 
 			return m, nil
 
 		case msg.String() == "enter":
 			a, ok := m.list.SelectedItem().(*action.Action)
 			if ok {
-				out, err := a.Transform(m.out)
-				if err != nil {
-					m.list.NewStatusMessage(errorMessageStyle("Error " + err.Error()))
-					return m, nil
+				if len(a.Params) > 0 {
+					m.pending = a
+					m.args = nil
+					m.promptParam()
+					return m, textinput.Blink
 				}
-				m.list.Title = fmt.Sprintf("%s: %s", out.Format.Name, strings.TrimRight(out.String(), "\r\n"))
-				m.out = out
-
-				m.list.ResetFilter()
-
-				actions := m.r.ActionsForData(m.out)
-				items := make([]list.Item, len(actions))
-				for i := 0; i < len(actions); i++ {
-					items[i] = actions[i]
-				}
-				m.list.SetItems(items)
+				m.apply(a)
 			}
 		}
 	}
@@ -206,8 +281,145 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// updateParams handles the keys while prompting for the pending action parameters
+func (m model) updateParams(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	choosing := m.choosing()
+
+	switch msg.String() {
+	case "esc":
+		// the first esc clears the choices filter
+		if choosing && m.choices.FilterState() != list.Unfiltered {
+			break
+		}
+		m.pending = nil
+		m.input.Blur()
+		m.list.NewStatusMessage(statusMessageStyle("Cancelled"))
+		return m, nil
+	case "enter":
+		v := m.input.Value()
+		if choosing {
+			// the typed filter is used as is if nothing matches
+			v = m.choices.FilterValue()
+			if c, ok := m.choices.SelectedItem().(choice); ok {
+				v = string(c)
+			}
+		}
+		return m.submitParam(v)
+	case "ctrl+v":
+		if choosing {
+			break
+		}
+		// the input is a single line, the clipboard text is used as is with its newlines
+		return m.submitParam(string(clipboard.Read(clipboard.FmtText)))
+	}
+
+	var cmd tea.Cmd
+	if choosing {
+		m.choices, cmd = m.choices.Update(msg)
+		return m, cmd
+	}
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// submitParam records v for the prompted parameter, the pending action is applied after the last one
+func (m model) submitParam(v string) (tea.Model, tea.Cmd) {
+	m.args = append(m.args, v)
+	if len(m.args) < len(m.pending.Params) {
+		m.promptParam()
+		return m, nil
+	}
+	a := m.pending.WithArgs(m.args...)
+	m.pending = nil
+	m.input.Blur()
+	m.apply(a)
+	return m, nil
+}
+
+// choosing reports if the pending parameter is picked from its choices
+func (m model) choosing() bool {
+	return m.pending != nil && m.pending.Params[len(m.args)].Choices != nil
+}
+
+// promptParam prepares the input or the choices for the next parameter of the pending action
+func (m *model) promptParam() {
+	p := m.pending.Params[len(m.args)]
+	if p.Choices != nil {
+		values := p.Choices()
+		items := make([]list.Item, len(values))
+		selected := 0
+		for i, v := range values {
+			items[i] = choice(v)
+			if v == p.Default {
+				selected = i
+			}
+		}
+		m.choices.Title = p.Name
+		m.choices.ResetFilter()
+		m.choices.SetItems(items)
+		m.choices.Select(selected)
+		// start filtering right away, typing searches the choices
+		m.choices, _ = m.choices.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+		m.list.NewStatusMessage(statusMessageStyle(p.Doc))
+		return
+	}
+
+	m.input.Reset()
+	m.input.Prompt = p.Name + ": "
+	m.input.Placeholder = p.Default
+	if p.Default == "" {
+		m.input.Placeholder = p.Doc
+	}
+	m.input.Focus()
+	m.list.NewStatusMessage(statusMessageStyle(p.Doc))
+}
+
+// apply transforms the current output with a
+func (m *model) apply(a *action.Action) {
+	out, err := a.Transform(m.out)
+	if err != nil {
+		m.list.NewStatusMessage(errorMessageStyle("Error " + err.Error()))
+		return
+	}
+	m.out = out
+	m.refresh()
+}
+
+// refresh updates the title, output and the list of actions available for the current output
+func (m *model) refresh() {
+	m.list.Title = fmt.Sprintf("%s: %s", m.out.Format.Name, m.out.StackString())
+	if c, ok := m.out.Value.(*action.Color); ok {
+		m.list.Title += " " + swatch(c, 2, 1)
+	}
+	m.setOutput()
+
+	m.list.ResetFilter()
+
+	actions := m.r.ActionsForData(m.out)
+	items := make([]list.Item, len(actions))
+	for i := 0; i < len(actions); i++ {
+		items[i] = actions[i]
+	}
+	m.list.SetItems(items)
+}
+
 func (m model) View() string {
-	return appStyle.Render(m.list.View())
+	style := outputStyle
+	if m.outputFocused {
+		style = focusedOutputStyle
+	}
+
+	views := []string{style.Copy().Width(m.output.Width).Render(m.outputView())}
+	switch {
+	case m.choosing():
+		views = append(views, m.choices.View())
+	case m.pending != nil:
+		views = append(views, m.input.View(), m.list.View())
+	default:
+		views = append(views, m.list.View())
+	}
+
+	return appStyle.Render(lipgloss.JoinVertical(lipgloss.Left, views...))
 }
 
 func main() {
@@ -236,7 +448,11 @@ func main() {
 		stdin, _ := io.ReadAll(os.Stdin)
 		input = stdin
 	} else {
-		input = []byte(clipboard.Read(clipboard.FmtText))
+		input = clipboard.Read(clipboard.FmtText)
+		// a screenshot, the image action decodes it
+		if len(input) == 0 {
+			input = clipboard.Read(clipboard.FmtImage)
+		}
 	}
 
 	p := tea.NewProgram(newModel(input))
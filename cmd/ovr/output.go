@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sourcegraph/go-diff/diff"
+
+	"github.com/akhenakh/ovr/action"
+)
+
+// maxColumnWidth caps the width of a table column in the output pane
+const maxColumnWidth = 40
+
+// logStyles colorize the log records by level
+var logStyles = map[string]lipgloss.Style{
+	"trace": lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
+	"debug": lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
+	"info":  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#0A7A4B", Dark: "#04B575"}),
+	"warn":  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#B58900", Dark: "#FFD75F"}),
+	"error": lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1111")),
+	"fatal": lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1111")).Bold(true).Reverse(true),
+}
+
+// diffStyles colorize the diff lines by their first character, d styles the file headers
+var diffStyles = map[byte]lipgloss.Style{
+	'+':  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#0A7A4B", Dark: "#04B575"}),
+	'-':  lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1111")),
+	'@':  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#00838F", Dark: "#5FD7FF"}),
+	'\\': lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
+	'd':  lipgloss.NewStyle().Bold(true),
+}
+
+// swatchWidth and swatchHeight are the size of the color swatch in the output pane
+const (
+	swatchWidth  = 16
+	swatchHeight = 4
+)
+
+// setSize splits the available space between the output pane and the actions list
+func (m *model) setSize(width, height int) {
+	fw, fh := outputStyle.GetFrameSize()
+	outputHeight := height/2 - fh
+
+	m.output.Width = width - fw
+	m.output.Height = outputHeight
+	m.table.SetWidth(width - fw)
+	m.table.SetHeight(outputHeight)
+
+	// keep a line for the parameters prompt
+	m.list.SetSize(width, height-outputHeight-fh-1)
+	m.choices.SetSize(width, height-outputHeight-fh)
+}
+
+// setOutput renders the current data in the output pane
+func (m *model) setOutput() {
+	if img, ok := m.out.Value.(*action.Image); ok {
+		m.setImageOutput(img.Image)
+		return
+	}
+	m.setGraphics("")
+
+	if c, ok := m.out.Value.(*action.Color); ok {
+		m.output.SetContent(swatch(c, swatchWidth, swatchHeight) + "\n\n" + m.out.String())
+		m.output.GotoTop()
+		return
+	}
+
+	if l, ok := m.out.Value.(*action.Logs); ok {
+		m.output.SetContent(renderLogs(l))
+		m.output.GotoTop()
+		return
+	}
+
+	if d, ok := m.out.Value.(*action.Diff); ok {
+		m.output.SetContent(renderDiff(d))
+		m.output.GotoTop()
+		return
+	}
+
+	if md, ok := m.out.Value.(*action.Markdown); ok {
+		m.output.SetContent(renderMarkdown(md, m.output.Width))
+		m.output.GotoTop()
+		return
+	}
+
+	t, ok := m.out.Value.(*action.Table)
+	if !ok {
+		m.output.SetContent(m.out.String())
+		m.output.GotoTop()
+		return
+	}
+
+	width := t.Width()
+	cols := make([]table.Column, width)
+	for i := range cols {
+		title := strconv.Itoa(i + 1)
+		if i < len(t.Header) {
+			title = t.Header[i]
+		}
+		cols[i] = table.Column{Title: title, Width: lipgloss.Width(title)}
+	}
+
+	rows := make([]table.Row, len(t.Rows))
+	for i, r := range t.Rows {
+		row := make(table.Row, width)
+		copy(row, r)
+		for j, c := range row {
+			if w := lipgloss.Width(c); w > cols[j].Width {
+				cols[j].Width = min(w, maxColumnWidth)
+			}
+		}
+		rows[i] = row
+	}
+
+	// columns must be set before the rows, the rows are rendered using the columns
+	m.table.SetRows(nil)
+	m.table.SetColumns(cols)
+	m.table.SetRows(rows)
+	m.table.GotoTop()
+}
+
+// outputView returns the view of the output pane
+func (m model) outputView() string {
+	if _, ok := m.out.Value.(*action.Table); ok {
+		return m.table.View()
+	}
+	return m.output.View()
+}
+
+// swatch renders a block of the color, ignoring the transparency
+func swatch(c *action.Color, width, height int) string {
+	line := lipgloss.NewStyle().
+		Background(lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))).
+		Render(strings.Repeat(" ", width))
+	return strings.TrimSuffix(strings.Repeat(line+"\n", height), "\n")
+}
+
+// renderMarkdown styles the markdown for the terminal, the source is displayed if it fails
+func renderMarkdown(md *action.Markdown, width int) string {
+	style := "dark"
+	if !lipgloss.HasDarkBackground() {
+		style = "light"
+	}
+	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle(style), glamour.WithWordWrap(width))
+	if err != nil {
+		return md.String()
+	}
+	s, err := r.Render(md.String())
+	if err != nil {
+		return md.String()
+	}
+	return s
+}
+
+// renderLogs colorizes each record by its level, records of unknown level are not styled
+func renderLogs(l *action.Logs) string {
+	lines := make([]string, len(l.Records))
+	for i, r := range l.Records {
+		lines[i] = r.Raw
+		if style, ok := logStyles[r.Level]; ok {
+			lines[i] = style.Render(r.Raw)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDiff colorizes the added and removed lines, each hunk is printed apart
+// so a removed line starting with -- is not mistaken for a file header
+func renderDiff(d *action.Diff) string {
+	var lines []string
+	style := func(s string, key byte) {
+		for _, l := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+			if l == "" {
+				lines = append(lines, l)
+				continue
+			}
+			k := key
+			if k == 0 {
+				k = l[0]
+			}
+			if st, ok := diffStyles[k]; ok {
+				l = st.Render(l)
+			}
+			lines = append(lines, l)
+		}
+	}
+	for _, fd := range d.Files {
+		full, err := diff.PrintFileDiff(fd)
+		if err != nil {
+			return d.String()
+		}
+		hunks, err := diff.PrintHunks(fd.Hunks)
+		if err != nil {
+			return d.String()
+		}
+		style(strings.TrimSuffix(string(full), string(hunks)), 'd')
+		for _, h := range fd.Hunks {
+			b, err := diff.PrintHunks([]*diff.Hunk{h})
+			if err != nil {
+				return d.String()
+			}
+			header, body, _ := strings.Cut(string(b), "\n")
+			style(header, '@')
+			if body != "" {
+				style(body, 0)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}